@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing is the console mode flag that makes Windows terminals interpret ANSI escape codes.
+const enableVirtualTerminalProcessing = 0x0004
+
+// setConsoleMode is SetConsoleMode of kernel32.dll, which the syscall package does not wrap.
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// enableColor makes the console of a terminal interpret ANSI escape codes, reporting false when it cannot, as on
+// consoles older than Windows 10, so that colors are left out rather than printed as garbage.
+func enableColor(file *os.File) bool {
+	handle := syscall.Handle(file.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ok, _, _ := setConsoleMode.Call(uintptr(handle), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
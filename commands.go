@@ -0,0 +1,487 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JoshuaAtTrimble/SSO-Simplifier/utils"
+	"github.com/JoshuaAtTrimble/SSO-Simplifier/version"
+)
+
+// stage is how far a command runs the pipeline of scanning, comparing, and writing.
+type stage int
+
+const (
+	stageScan  stage = iota // Scan the input path and report the SSOs found
+	stageDiff               // Also compare the API of the SSOs found with a baseline
+	stageWrite              // Also write the simplified SSOs and the other outputs
+)
+
+// command is a subcommand of sso_simplifier, with the flags it accepts and what it runs.
+type command struct {
+	name        string                                // The name of the command, empty for running without one
+	summary     string                                // One line for the list of commands
+	description string                                // The start of the help message of the command
+	register    func(o *options, flags *flag.FlagSet) // Registers the flags of the command
+	run         func(o *options)                      // Runs the command, exiting with its status
+}
+
+// commands returns the subcommands of sso_simplifier, in the order they are listed.
+func commands() []command {
+	return []command{
+		{
+			name:        "scan",
+			summary:     "List the SSOs in the input path, optionally exporting a manifest, SARIF log, or graph.",
+			description: "sso_simplifier scan lists the SSOs found in the input path with their member counts, writing no simplified SSOs.",
+			register: func(o *options, flags *flag.FlagSet) {
+				o.registerScanFlags(flags)
+				o.registerExportFlags(flags)
+			},
+			run: func(o *options) { os.Exit(runPipeline(o, stageScan).status) },
+		},
+		{
+			name:        "simplify",
+			summary:     "Scan the input path and write a simplified SSO for each SSO found, and any other outputs.",
+			description: "sso_simplifier simplify writes a simplified SSO for each SSO found in the input path.",
+			register:    registerSimplifyFlags,
+			run:         runSimplify,
+		},
+		{
+			name:        "compile",
+			summary:     "Compile the simplified SSOs in an output directory into a jar.",
+			description: "sso_simplifier compile compiles the simplified SSOs in an output directory into a jar with javac and jar.",
+			register: func(o *options, flags *flag.FlagSet) {
+				flags.StringVar(&o.outputPath, "outputPath", "", "(Required) Directory of the simplified SSOs to compile.")
+				flags.StringVar(&o.jar, "jar", o.jar, "Name of the jar to write into outputPath.")
+				flags.Var(&o.sourceDirs, "sourceDir", "Another directory of sources to compile alongside outputPath. Repeatable.")
+			},
+			run: runCompile,
+		},
+		{
+			name:        "verify",
+			summary:     "Check that the simplified SSOs in an output directory are up to date, writing nothing.",
+			description: "sso_simplifier verify checks that the simplified SSOs in the output directory match what simplify would write now.",
+			register: func(o *options, flags *flag.FlagSet) {
+				o.registerScanFlags(flags)
+				o.registerFormatFlags(flags)
+				flags.BoolVar(&o.verifyChecksums, "verifyChecksums", false, "Check outputPath against its SHA256SUMS file instead of the SSOs.")
+			},
+			run: func(o *options) {
+				o.verify = true
+				os.Exit(runPipeline(o, stageWrite).status)
+			},
+		},
+		{
+			name:        "diff",
+			summary:     "Compare the API of the SSOs in the input path with a manifest or directory of an earlier version.",
+			description: "sso_simplifier diff reports the classes, methods, and fields added, removed, or changed since a baseline.",
+			register: func(o *options, flags *flag.FlagSet) {
+				o.registerScanFlags(flags)
+				o.registerDiffFlags(flags)
+			},
+			run: func(o *options) {
+				if o.diffAgainst == "" {
+					usageError("Error: the --diffAgainst flag is required.")
+				}
+				os.Exit(runPipeline(o, stageDiff).status)
+			},
+		},
+	}
+}
+
+// legacyCommand is what running sso_simplifier with options but no command does: simplify, with --version, as
+// before there were commands.
+var legacyCommand = command{
+	description: "sso_simplifier simplifies SSO Java class files for the VIP SSO Gallery by extracting the package line, class signature, and public method signatures with minimal method code.",
+	register: func(o *options, flags *flag.FlagSet) {
+		flags.BoolVar(&o.showVersion, "version", false, "Print the version, git commit, and build date of sso_simplifier and exit.")
+		registerSimplifyFlags(o, flags)
+	},
+	run: runSimplify,
+}
+
+// registerSimplifyFlags registers the flags of the simplify command, which are every flag of the pipeline.
+func registerSimplifyFlags(o *options, flags *flag.FlagSet) {
+	o.registerScanFlags(flags)
+	o.registerFormatFlags(flags)
+	o.registerExportFlags(flags)
+	o.registerDiffFlags(flags)
+	o.registerOutputFlags(flags)
+}
+
+// usage prints the help message of the command being run to stderr, for usage errors. main sets it once the command
+// is known.
+var usage func()
+
+func main() {
+	// If no arguments or flags are provided, behave as if the user entered the help command
+	if len(os.Args) == 1 {
+		printHelp(os.Stdout)
+		os.Exit(exitSuccess)
+	}
+
+	// Find the command, treating options without one as the deprecated way of running simplify
+	name, args := os.Args[1], os.Args[2:]
+	var cmd command
+	switch {
+	case name == "version":
+		fmt.Printf("sso_simplifier %s\n", version.String())
+		os.Exit(exitSuccess)
+	case name == "help" && len(args) == 0:
+		printHelp(os.Stdout)
+		os.Exit(exitSuccess)
+	case name == "help":
+		name, args = args[0], []string{"--help"}
+	case strings.HasPrefix(name, "-"):
+		cmd, args = legacyCommand, os.Args[1:]
+	}
+	if cmd.register == nil {
+		for _, candidate := range commands() {
+			if candidate.name == name {
+				cmd = candidate
+			}
+		}
+		if cmd.register == nil {
+			errorf("Error: unknown command %q.", name)
+			printHelp(os.Stderr)
+			os.Exit(exitUsage)
+		}
+	}
+
+	// Answer --help and --version before checking any other flag, and report an unknown flag with the help message
+	o := newOptions()
+	flags := flag.NewFlagSet("sso_simplifier "+cmd.name, flag.ExitOnError)
+	flags.BoolVar(&o.help, "help", false, "Display help information.")
+	flags.StringVar(&o.config, "config", "", "Config file to read options from (default sso_simplifier.yaml, if it exists).")
+	flags.BoolVar(&o.printConfig, "printConfig", false, "Print the resolved configuration and exit.")
+	o.registerConsoleFlags(flags)
+	cmd.register(o, flags)
+	usage = func() { printCommandHelp(os.Stderr, cmd, flags) }
+	flags.Usage = usage
+	flags.Parse(args)
+	if o.help {
+		printCommandHelp(os.Stdout, cmd, flags)
+		os.Exit(exitSuccess)
+	}
+	if o.showVersion {
+		fmt.Printf("sso_simplifier %s\n", version.String())
+		os.Exit(exitSuccess)
+	}
+	if flags.NArg() > 0 {
+		usageError("Error: unexpected argument %q.", flags.Arg(0))
+	}
+
+	// Fill in the flags the command line leaves unset from the environment and the config file
+	sources, err := applyConfig(flags, o.config)
+	if err != nil {
+		usageError("Error: %v.", err)
+	}
+	if o.printConfig {
+		printConfig(os.Stdout, flags, sources)
+		os.Exit(exitSuccess)
+	}
+	if file := setUpConsole(o, flags); file != nil {
+		defer file.Close()
+	}
+	if cmd.name == "" {
+		// Print the notice even with --quiet, so that the scripts that need updating say so
+		fmt.Fprintln(os.Stderr, "Warning: running sso_simplifier without a command is deprecated and will stop working in a future release; run sso_simplifier simplify with the same options instead.")
+	}
+	cmd.run(o)
+}
+
+// setUpConsole sets how much the console says and in which colors, and opens the --logFile, if any, which the caller
+// closes. It runs before anything is printed, while stdout is still the terminal, if it is one.
+func setUpConsole(o *options, flags *flag.FlagSet) *os.File {
+	if o.quiet && o.verbose {
+		usageError("Error: --quiet and --verbose cannot be used together.")
+	}
+	setConsoleVerbosity(o.quiet, o.verbose)
+	setConsoleColor(o.noColor)
+	debugf("Configuration:")
+	flags.VisitAll(func(f *flag.Flag) {
+		debugf("  --%s=%s", f.Name, f.Value)
+	})
+
+	// Keep a log of the run for going back to, alongside the console
+	level, err := parseLogLevel(o.logLevel)
+	if err != nil {
+		usageError("Error: %v.", err)
+	}
+	if o.logFile == "" {
+		return nil
+	}
+	file, err := openLogFile(o.logFile, level)
+	if err != nil {
+		errorf("Error: cannot open --logFile: %v", err)
+		os.Exit(exitFailure)
+	}
+	return file
+}
+
+// runSimplify writes the simplified SSOs once, or with --watch, keeps them up to date until interrupted.
+func runSimplify(o *options) {
+	if o.watch {
+		os.Exit(watch(o))
+	}
+	os.Exit(runPipeline(o, stageWrite).status)
+}
+
+// runCompile compiles the simplified SSOs in the output directory, and any other source directories, into a jar.
+func runCompile(o *options) {
+	if o.outputPath == "" {
+		usageError("Error: the --outputPath flag is required.")
+	}
+	jarName := o.jar
+	if !strings.HasSuffix(jarName, ".jar") {
+		jarName += ".jar"
+	}
+	jarPath := filepath.Join(o.outputPath, jarName)
+	infof("Compiling the simplified SSOs in %s into: %s\n", o.outputPath, jarName)
+	if err := compileJar(o.outputPath, o.sourceDirs, jarPath, nil, false); err != nil {
+		errorf("Error %v\n", err)
+		os.Exit(exitCompileFailed)
+	}
+	summaryf("Compiled .jar file created at: %s\n", jarPath)
+}
+
+// options holds the value of every flag. Each command registers the flags it accepts, and the others keep their
+// defaults.
+type options struct {
+	// The flags of every command, or of running without one
+	help        bool
+	showVersion bool
+	config      string // The config file to read flags from
+	printConfig bool   // Whether to print the resolved configuration instead of running
+
+	// The flags of registerConsoleFlags
+	verbose  bool
+	noColor  bool
+	quiet    bool
+	logFile  string
+	logLevel string
+
+	// The flags of registerScanFlags
+	inputPath            string
+	strict               bool
+	excludes             stringList
+	respectGitignore     bool
+	skipTests            bool
+	includeTests         bool
+	includeClass         string
+	excludeClass         string
+	includePackage       string
+	excludePackage       string
+	sourceEncoding       string
+	maxFileSizeMB        int64
+	followSymlinks       bool
+	showProgress         bool
+	parallel             int
+	baseClasses          stringList
+	baseInterfaces       stringList
+	allowTypeEntries     stringList
+	allowTypesFile       string
+	lenient              bool
+	includeProtected     bool
+	previousManifestPath string
+	failOnEmpty          bool
+	minSSOs              int
+
+	// The flags of registerFormatFlags
+	outputPath          string
+	dropNested          bool
+	emitEnums           bool
+	flatOutput          bool
+	onCollision         string
+	noExtends           bool
+	emitBaseStub        bool
+	baseStubPackage     string
+	accessors           string
+	emitImplements      bool
+	stripJavadoc        bool
+	headerFile          string
+	reproducible        bool
+	indent              string
+	stubBody            string
+	sortMembers         bool
+	templatePath        string
+	lang                string
+	lineEndings         string
+	braceStyle          string
+	interfacesDir       string
+	implementInterfaces bool
+	interfacePrefix     string
+	interfaceSuffix     string
+
+	// The flags of registerExportFlags
+	manifestPath string
+	sarifPath    string
+	graphPath    string
+
+	// The flags of registerDiffFlags
+	diffAgainst string
+	diffJSON    string
+
+	// The flags of registerOutputFlags
+	compile            string
+	excludeBaseStub    bool
+	forceCompile       bool
+	force              bool
+	failFast           bool
+	touch              bool
+	prune              bool
+	dryRun             bool
+	jsonSummary        bool
+	junitReport        string
+	csvPath            string
+	csvMethods         bool
+	typescriptDir      string
+	typescriptCombined bool
+	typescriptTypes    stringList
+	docsDir            string
+	htmlDir            string
+	verify             bool
+	checksums          bool
+	verifyChecksums    bool
+	sourcesJar         string
+	combined           bool
+	stream             bool
+	watch              bool
+	watchInterval      time.Duration
+
+	watchParseCache *utils.ParseCache // The parse results of the last rebuild in watch mode, not a flag
+
+	jar        string     // The name of the jar the compile command writes
+	sourceDirs stringList // Directories of sources the compile command compiles alongside outputPath
+}
+
+// newOptions returns the options with the defaults of the flags, for commands that do not register them.
+func newOptions() *options {
+	return &options{
+		skipTests:       true,
+		sourceEncoding:  "utf-8",
+		maxFileSizeMB:   utils.DefaultMaxFileSize / (1024 * 1024),
+		logLevel:        "info",
+		onCollision:     "fail",
+		accessors:       string(utils.AccessorsNone),
+		indent:          "4",
+		stubBody:        string(utils.StubBodyDefault),
+		lang:            string(utils.LanguageJava),
+		lineEndings:     string(utils.LineEndingLF),
+		braceStyle:      string(utils.BraceSameLine),
+		interfacePrefix: "I",
+		watchInterval:   time.Second,
+		jar:             "ssos.jar",
+	}
+}
+
+// registerConsoleFlags registers the flags of every command that decide what the console prints and what is logged.
+func (o *options) registerConsoleFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&o.verbose, "verbose", false, "Print the configuration, every file visited and member extracted or skipped, and phase timings.")
+	flags.BoolVar(&o.noColor, "no-color", false, "Print without colors, as when the NO_COLOR environment variable is set.")
+	flags.BoolVar(&o.quiet, "quiet", false, "Print only errors and the one-line summary, leaving out warnings too.")
+	flags.StringVar(&o.logFile, "logFile", "", "Also append the messages of the run to a log file at this path.")
+	flags.StringVar(&o.logLevel, "logLevel", o.logLevel, "Lowest level of the messages written to --logFile: debug, info, warn, or error.")
+}
+
+// registerScanFlags registers the flags that decide which files are scanned and which SSOs are found in them.
+func (o *options) registerScanFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.inputPath, "inputPath", "", "Directory, .java file, .zip, or .jar archive to search for ServerSideObjects (SSOs) to simplify.")
+	flags.BoolVar(&o.strict, "strict", false, "Stop at the first file that cannot be scanned, and fail on parse warnings, instead of warning and continuing.")
+	flags.Var(&o.excludes, "exclude", "Glob pattern, relative to inputPath, of files and directories to skip (e.g. **/test/**). Repeatable.")
+	flags.BoolVar(&o.respectGitignore, "respectGitignore", false, "Skip files and directories ignored by .gitignore files.")
+	flags.BoolVar(&o.skipTests, "skipTests", o.skipTests, "Skip SSO-like classes in test source roots or named like tests.")
+	flags.BoolVar(&o.includeTests, "includeTests", false, "Keep SSO-like classes that look like tests, overriding --skipTests.")
+	flags.StringVar(&o.includeClass, "includeClass", "", "Regular expression; only SSOs whose class name matches are processed.")
+	flags.StringVar(&o.excludeClass, "excludeClass", "", "Regular expression; SSOs whose class name matches are not processed.")
+	flags.StringVar(&o.includePackage, "includePackage", "", "Regular expression; only SSOs whose package matches are processed.")
+	flags.StringVar(&o.excludePackage, "excludePackage", "", "Regular expression; SSOs whose package matches are not processed.")
+	flags.StringVar(&o.sourceEncoding, "sourceEncoding", o.sourceEncoding, "Encoding of the source files: utf-8, iso-8859-1, or windows-1252.")
+	flags.Int64Var(&o.maxFileSizeMB, "maxFileSizeMB", o.maxFileSizeMB, "Skip source files larger than this many megabytes, or 0 for no limit.")
+	flags.BoolVar(&o.followSymlinks, "followSymlinks", false, "Walk through symlinks to directories, skipping any that would loop.")
+	flags.BoolVar(&o.showProgress, "progress", false, "Print files-scanned and SSOs-found counters during the scan, and a summary after it.")
+	flags.IntVar(&o.parallel, "parallel", 0, "Number of files to parse concurrently (default: the number of CPUs).")
+	flags.Var(&o.baseClasses, "baseClass", "Simple name of a class whose subclasses are SSOs (default ServerSideObject). Repeatable.")
+	flags.Var(&o.baseInterfaces, "baseInterface", "Simple name of an interface whose implementations are SSOs too. Repeatable.")
+	flags.Var(&o.allowTypeEntries, "allowType", "Type to allow in member signatures, as TypeName=defaultReturnExpr (e.g. BigDecimal=null). Repeatable.")
+	flags.StringVar(&o.allowTypesFile, "allowTypesFile", "", "File of --allowType entries, one per line; # starts a comment.")
+	flags.BoolVar(&o.lenient, "lenient", false, "Keep methods with unsupported object return or parameter types instead of skipping them.")
+	flags.BoolVar(&o.includeProtected, "includeProtected", false, "Extract protected methods and fields too, keeping their access modifier in the stubs.")
+	flags.StringVar(&o.previousManifestPath, "previousManifest", "", "Manifest of an earlier run whose parse results are reused for unchanged files.")
+	flags.BoolVar(&o.failOnEmpty, "failOnEmpty", false, "Fail the run when no SSOs are found.")
+	flags.IntVar(&o.minSSOs, "minSSOs", 0, "Fail the run when fewer than this many SSOs are found.")
+}
+
+// registerFormatFlags registers the flags that decide where the simplified SSOs go and what they contain.
+func (o *options) registerFormatFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.outputPath, "outputPath", "", "Path to save simplified SSOs.")
+	flags.BoolVar(&o.dropNested, "dropNested", false, "Drop public nested classes with a warning instead of writing nested stubs.")
+	flags.BoolVar(&o.emitEnums, "emitEnums", false, "Reproduce enums declared in or alongside SSOs in the simplified output.")
+	flags.BoolVar(&o.flatOutput, "flatOutput", false, "Write all simplified SSOs directly into outputPath instead of package directories.")
+	flags.StringVar(&o.onCollision, "onCollision", o.onCollision, "What to do when two SSOs would be written to the same file: fail, skip, or suffix.")
+	flags.BoolVar(&o.noExtends, "noExtends", false, "Leave the extends clause out of the simplified SSOs, which is reproduced by default.")
+	flags.BoolVar(&o.emitBaseStub, "emitBaseStub", false, "Also write a stub of each base class the SSOs extend, declaring the methods they inherit.")
+	flags.StringVar(&o.baseStubPackage, "baseStubPackage", "", "Package to write the base class stubs to, imported by every SSO that extends a base class directly.")
+	flags.StringVar(&o.accessors, "accessors", o.accessors, "JavaBeans getters and setters for the instance fields of each SSO: none, add, or replace.")
+	flags.BoolVar(&o.emitImplements, "emitImplements", false, "Reproduce the implements clause of each simplified SSO.")
+	flags.BoolVar(&o.stripJavadoc, "stripJavadoc", false, "Leave the Javadoc comments of classes and methods out of the simplified SSOs.")
+	flags.StringVar(&o.headerFile, "headerFile", "", "File of license text to start every simplified SSO with; ${YEAR} is replaced with the year of generation.")
+	flags.BoolVar(&o.reproducible, "reproducible", false, "Leave the generation time out of the header of simplified SSOs, so re-runs are byte-identical.")
+	flags.StringVar(&o.indent, "indent", o.indent, "Indentation of the simplified SSOs: a number of spaces, or tab.")
+	flags.StringVar(&o.stubBody, "stubBody", o.stubBody, "Body of each simplified method: default, throw, or todo.")
+	flags.BoolVar(&o.sortMembers, "sortMembers", false, "Order fields by name and methods by name, then parameter types, instead of source order.")
+	flags.StringVar(&o.templatePath, "template", "", "Go text/template file to render each simplified SSO with instead of the built-in format.")
+	flags.StringVar(&o.lang, "lang", o.lang, "Language of the simplified SSOs: java or kotlin.")
+	flags.StringVar(&o.lineEndings, "lineEndings", o.lineEndings, "Line endings of the simplified SSOs: lf, crlf, or native.")
+	flags.StringVar(&o.braceStyle, "braceStyle", o.braceStyle, "Placement of opening braces: same-line or next-line.")
+	flags.StringVar(&o.interfacesDir, "interfaces", "", "Also write a Java interface declaring the public instance methods of each SSO to this directory.")
+	flags.BoolVar(&o.implementInterfaces, "implementInterfaces", false, "Make each simplified SSO implement its --interfaces interface.")
+	flags.StringVar(&o.interfacePrefix, "interfacePrefix", o.interfacePrefix, "Prefix of the names of the --interfaces interfaces.")
+	flags.StringVar(&o.interfaceSuffix, "interfaceSuffix", "", "Suffix of the names of the --interfaces interfaces.")
+}
+
+// registerExportFlags registers the flags that export what the scan found, which need nothing written.
+func (o *options) registerExportFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.manifestPath, "manifest", "", "Also write a JSON manifest describing every simplified SSO and its skipped methods to this path.")
+	flags.StringVar(&o.sarifPath, "sarif", "", "Also write the parse warnings as a SARIF 2.1.0 log to this path.")
+	flags.StringVar(&o.graphPath, "graph", "", "Also write a Graphviz DOT graph of the SSOs by package and their inheritance to this path.")
+}
+
+// registerDiffFlags registers the flags that compare the API of the SSOs found with a baseline.
+func (o *options) registerDiffFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.diffAgainst, "diffAgainst", "", "Manifest (.json) or directory to compare the API of the SSOs found with.")
+	flags.StringVar(&o.diffJSON, "diffJSON", "", "Also write the --diffAgainst report as JSON to this path.")
+}
+
+// registerOutputFlags registers the flags of the files simplify writes besides the simplified SSOs, and of how it writes them.
+func (o *options) registerOutputFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.compile, "compile", "", "Compile simplified SSOs into a single Java archive.")
+	flags.BoolVar(&o.excludeBaseStub, "excludeBaseStub", false, "Leave the base class stubs out of the --compile jar, for runtimes that provide the real ones.")
+	flags.BoolVar(&o.forceCompile, "forceCompile", false, "Run --compile even when some simplified SSOs could not be written.")
+	flags.BoolVar(&o.force, "force", false, "Overwrite existing files in the output directory that were not generated by this tool.")
+	flags.BoolVar(&o.failFast, "failFast", false, "Stop writing simplified SSOs at the first that cannot be written instead of writing the rest.")
+	flags.BoolVar(&o.touch, "touch", false, "Rewrite simplified SSOs whose content has not changed, updating their modification times.")
+	flags.BoolVar(&o.prune, "prune", false, "After writing, remove the generated files in outputPath that belong to no SSO found.")
+	flags.BoolVar(&o.dryRun, "dryRun", false, "With --prune, list the files that would be removed without removing them.")
+	flags.BoolVar(&o.jsonSummary, "json", false, "Write a JSON summary of the run to stdout, sending all other output to stderr.")
+	flags.StringVar(&o.junitReport, "junitReport", "", "Also write a JUnit XML report with a test case per SSO, passed, failed, or skipped, to this path.")
+	flags.StringVar(&o.csvPath, "csv", "", "Also write a CSV summary with a row per SSO, its member counts, and how it was written, to this path.")
+	flags.BoolVar(&o.csvMethods, "csvMethods", false, "Write a row per method, kept or skipped, to the --csv summary instead of a row per SSO.")
+	flags.StringVar(&o.typescriptDir, "typescript", "", "Also write TypeScript declarations of the SSOs, a .d.ts file per package, to this directory.")
+	flags.BoolVar(&o.typescriptCombined, "typescriptCombined", false, "Write the --typescript declarations into a single ssos.d.ts with a namespace per package.")
+	flags.Var(&o.typescriptTypes, "tsType", "TypeScript type of a Java type for --typescript, as JavaType=TypeScriptType. Can be repeated.")
+	flags.StringVar(&o.docsDir, "docs", "", "Also write a Markdown page for every SSO, and an index.md linking them by package, to this directory.")
+	flags.StringVar(&o.htmlDir, "html", "", "Also write a static HTML gallery of every SSO, with a searchable index.html, to this directory.")
+	flags.BoolVar(&o.verify, "verify", false, "Check that the simplified SSOs in outputPath are up to date instead of writing them.")
+	flags.BoolVar(&o.checksums, "checksums", false, "Also write a SHA256SUMS file listing every file written this run to outputPath.")
+	flags.BoolVar(&o.verifyChecksums, "verifyChecksums", false, "Check outputPath against its SHA256SUMS file instead of simplifying.")
+	flags.StringVar(&o.sourcesJar, "sourcesJar", "", "Also write the simplified SSOs into a reproducible sources jar at this path, laid out by package.")
+	flags.BoolVar(&o.combined, "combined", false, "Write a single Markdown digest of every simplified SSO to AllSSOs.md instead of a file per SSO.")
+	flags.BoolVar(&o.stream, "stream", false, "Write each simplified SSO as soon as it is found instead of after the scan.")
+	flags.BoolVar(&o.watch, "watch", false, "Keep running, rewriting the simplified SSOs of the source files that change, until interrupted.")
+	flags.DurationVar(&o.watchInterval, "watchInterval", o.watchInterval, "How often --watch checks the input path for changes.")
+}
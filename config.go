@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// defaultConfigPath is the config file read from the working directory when --config is not given.
+const defaultConfigPath = "sso_simplifier.yaml"
+
+// envPrefix starts the names of the environment variables that set flags, such as SSO_SIMPLIFIER_INPUT_PATH.
+const envPrefix = "SSO_SIMPLIFIER_"
+
+// configEntry is a key of a config file with its value, or its values for a list.
+type configEntry struct {
+	key    string
+	values []string
+	list   bool // Whether the values were given as a list
+	line   int  // The line of the key, for error messages
+}
+
+// parseConfig reads a config file in the subset of YAML that options need: a key per line, named after its flag,
+// with a value or a list of values, either inline as [a, b] or as "- " items on the lines that follow. Values may be
+// quoted, and # starts a comment. Nested keys are an error, as is a key given twice.
+func parseConfig(path string) ([]configEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []configEntry
+	seen := make(map[string]bool)
+	inBlockList := false // Whether the last key has no value, so that "- " items may follow
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(stripConfigComment(scanner.Text()), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		// Add the items of a block list to the key before them
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok && (item == "" || item[0] == ' ') {
+			if !inBlockList {
+				return nil, fmt.Errorf("%s:%d: list item without a key", path, lineNumber)
+			}
+			value, err := unquoteConfigValue(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			entries[len(entries)-1].values = append(entries[len(entries)-1].values, value)
+			continue
+		}
+		if line != trimmed {
+			return nil, fmt.Errorf("%s:%d: unexpected indentation; nested keys are not supported", path, lineNumber)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected key: value", path, lineNumber)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s:%d: %s is set twice", path, lineNumber, key)
+		}
+		seen[key] = true
+		entry := configEntry{key: key, line: lineNumber}
+		inBlockList = false
+		switch value = strings.TrimSpace(value); {
+		case value == "":
+			// A block list follows, or the key is empty
+			entry.list, inBlockList = true, true
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			entry.list = true
+			for _, item := range splitConfigList(value[1 : len(value)-1]) {
+				item, err := unquoteConfigValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+				}
+				entry.values = append(entry.values, item)
+			}
+		default:
+			value, err := unquoteConfigValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+			entry.values = []string{value}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// stripConfigComment removes a comment from a line of a config file: a # at the start of the line or after a space,
+// outside quotes.
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitConfigList splits the items of an inline list at the commas outside quotes, trimming each.
+func splitConfigList(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range list {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// unquoteConfigValue returns a value of a config file without its quotes: double-quoted values take Go escapes, and
+// in single-quoted values a doubled single quote stands for one, as in YAML.
+func unquoteConfigValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return unquoted, nil
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	return value, nil
+}
+
+// envName returns the environment variable that sets a flag, such as SSO_SIMPLIFIER_MAX_FILE_SIZE_MB for
+// maxFileSizeMB, with a word break before each capital that starts a word.
+func envName(flagName string) string {
+	runes := []rune(flagName)
+	var name strings.Builder
+	name.WriteString(envPrefix)
+	for i, r := range runes {
+		switch {
+		case r == '-':
+			name.WriteRune('_')
+			continue
+		case i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])):
+			name.WriteRune('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
+
+// allFlagNames returns the names of the flags of every command, so that a config file or environment shared by
+// several commands can set flags that only some of them accept.
+func allFlagNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, cmd := range append(commands(), legacyCommand) {
+		flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		o := newOptions()
+		o.registerConsoleFlags(flags)
+		cmd.register(o, flags)
+		flags.VisitAll(func(f *flag.Flag) { names[f.Name] = true })
+	}
+	return names
+}
+
+// isRepeatable reports whether a flag collects every value it is given, so that a config file or environment
+// variable can give it a list.
+func isRepeatable(f *flag.Flag) bool {
+	_, ok := f.Value.(*stringList)
+	return ok
+}
+
+// applyConfig sets the flags the command line left unset from the config file, if any, and then from the
+// environment variables, which take precedence over the file, returning where each flag was set from for
+// --printConfig. Keys that name no flag of any command are an error, while such variables are ignored, since the
+// environment is shared with other tools; keys and variables of flags of other commands are ignored too. The config
+// file is the one at configPath, or sso_simplifier.yaml when configPath is empty and it exists.
+func applyConfig(flags *flag.FlagSet, configPath string) (map[string]string, error) {
+	sources := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) { sources[f.Name] = "command line" })
+	known := allFlagNames()
+
+	// Read the config file, which may be missing only when it was not asked for
+	var entries []configEntry
+	if configPath == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			configPath = defaultConfigPath
+		}
+	}
+	if configPath != "" {
+		var err error
+		if entries, err = parseConfig(configPath); errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found", configPath)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range entries {
+		if !known[entry.key] {
+			return nil, fmt.Errorf("%s:%d: unknown key %q", configPath, entry.line, entry.key)
+		}
+		f := flags.Lookup(entry.key)
+		if f == nil || sources[f.Name] != "" {
+			continue
+		}
+		if entry.list && !isRepeatable(f) && len(entry.values) == 0 {
+			entry.values = []string{""} // A key without a value
+		} else if entry.list && !isRepeatable(f) {
+			return nil, fmt.Errorf("%s:%d: %s takes a single value, not a list", configPath, entry.line, entry.key)
+		}
+		for _, value := range entry.values {
+			if err := flags.Set(f.Name, value); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid value %q for %s: %v", configPath, entry.line, value, entry.key, err)
+			}
+		}
+		sources[f.Name] = fmt.Sprintf("%s:%d", configPath, entry.line)
+	}
+
+	// Let the environment override the file, each variable replacing any values the file gave
+	byEnvName := make(map[string]string)
+	for name := range known {
+		byEnvName[envName(name)] = name
+	}
+	environ := os.Environ()
+	slices.Sort(environ)
+	for _, variable := range environ {
+		key, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		name, ok := byEnvName[key]
+		if !ok {
+			continue // Such as SSO_SIMPLIFIER_HOME, set for something other than a flag
+		}
+		f := flags.Lookup(name)
+		if f == nil || sources[name] == "command line" {
+			continue
+		}
+		values := []string{value}
+		if list, ok := f.Value.(*stringList); ok {
+			*list = nil
+			values = strings.Split(value, ",")
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return nil, fmt.Errorf("invalid value %q for %s: %v", value, key, err)
+			}
+		}
+		sources[name] = "environment variable " + key
+	}
+	return sources, nil
+}
+
+// printConfig writes the value of every flag of the command as a config file, commented with where each was set
+// from, so that the resolved configuration can be checked or saved.
+func printConfig(out io.Writer, flags *flag.FlagSet, sources map[string]string) {
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "help" || f.Name == "config" || f.Name == "printConfig" {
+			return
+		}
+		source := sources[f.Name]
+		if source == "" {
+			source = "default"
+		}
+		if list, ok := f.Value.(*stringList); ok {
+			if len(*list) == 0 {
+				fmt.Fprintf(out, "%s: [] # %s\n", f.Name, source)
+				return
+			}
+			fmt.Fprintf(out, "%s: # %s\n", f.Name, source)
+			for _, value := range *list {
+				fmt.Fprintf(out, "  - %s\n", quoteConfigValue(value))
+			}
+			return
+		}
+		fmt.Fprintf(out, "%s: %s # %s\n", f.Name, quoteConfigValue(f.Value.String()), source)
+	})
+}
+
+// quoteConfigValue quotes a value for a config file when it would not read back as itself unquoted.
+func quoteConfigValue(value string) string {
+	if value == "" || strings.ContainsAny(value, "#'\"[]{}:,*&!|>%@`?") || strings.TrimSpace(value) != value || strings.HasPrefix(value, "-") {
+		return strconv.Quote(value)
+	}
+	return value
+}
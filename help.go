@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagHelp describes every flag for the help messages, in the order they are listed. The text of a flag continues
+// on indented lines after each line break.
+var flagHelp = []struct {
+	name string
+	text string
+}{
+	{"help", "Display help information."},
+	{"config", "YAML file of options to read, with a key per flag, such as inputPath: src or exclude: [\"**/test/**\"],\n" +
+		"and a list for a repeatable flag. Defaults to sso_simplifier.yaml in the working directory, if it\n" +
+		"exists. Environment variables named after the flags, such as SSO_SIMPLIFIER_INPUT_PATH, override\n" +
+		"the file, with commas between the values of a repeatable flag, and flags given on the command\n" +
+		"line override both. Keys that name no flag are an error."},
+	{"printConfig", "Print the value of every option as a config file, commented with where it was set from, and exit."},
+	{"version", "Print the version, git commit, and build date of sso_simplifier and exit."},
+	{"inputPath", "(Required) Directory, .java file, .zip, or .jar archive to search for ServerSideObjects (SSOs) to simplify."},
+	{"outputPath", "(Required) Path to save simplified SSOs."},
+	{"compile", "Compile simplified SSOs into a single Java archive."},
+	{"dropNested", "Drop public nested classes with a warning instead of writing nested stubs."},
+	{"emitEnums", "Reproduce enums declared in or alongside SSOs in the simplified output."},
+	{"flatOutput", "Write all simplified SSOs directly into outputPath instead of package directories."},
+	{"strict", "Stop at the first file that cannot be scanned, and fail on parse warnings, instead of warning and continuing."},
+	{"exclude", "Glob pattern, relative to inputPath, of files and directories to skip (e.g. **/test/**). Repeatable."},
+	{"respectGitignore", "Skip files and directories ignored by .gitignore files."},
+	{"skipTests", "Skip SSO-like classes in test source roots or named like tests (default true)."},
+	{"includeTests", "Keep SSO-like classes that look like tests, overriding --skipTests."},
+	{"includeClass", "Regular expression; only SSOs whose class name matches are processed."},
+	{"excludeClass", "Regular expression; SSOs whose class name matches are not processed."},
+	{"includePackage", "Regular expression; only SSOs whose package matches are processed."},
+	{"excludePackage", "Regular expression; SSOs whose package matches are not processed."},
+	{"sourceEncoding", "Encoding of the source files: utf-8 (default), iso-8859-1, or windows-1252."},
+	{"maxFileSizeMB", "Skip source files larger than this many megabytes, or 0 for no limit (default 10)."},
+	{"followSymlinks", "Walk through symlinks to directories, skipping any that would loop."},
+	{"verbose", "Print the full story: the resolved configuration, every file visited, every extracted class,\n" +
+		"method, and field with its source line, every skipped member with its reason, and the\n" +
+		"time each phase took."},
+	{"no-color", "Print without colors. On terminals, written SSOs are green, warnings and skipped SSOs\n" +
+		"yellow, and errors red, unless the NO_COLOR environment variable is set."},
+	{"quiet", "Print only errors and the one-line summary, leaving out the SSOs found, warnings, and the\n" +
+		"files written. When stdout is not a terminal, as in CI, the SSOs found and the files written\n" +
+		"are left out by default too, but warnings are kept. Cannot be combined with --verbose."},
+	{"logFile", "Also append the messages of the run to a log file at this path, in the text format of log/slog."},
+	{"logLevel", "Lowest level of the messages written to --logFile: debug, info (default), warn, or error.\n" +
+		"debug adds every file visited and every skipped member with its reason. Errors are\n" +
+		"always written to both stderr and the log file."},
+	{"progress", "Print files-scanned and SSOs-found counters during the scan, and a summary after it."},
+	{"parallel", "Number of files to parse concurrently (default: the number of CPUs)."},
+	{"onCollision", "What to do when two SSOs would be written to the same file: fail (default), skip, or suffix."},
+	{"baseClass", "Simple name of a class whose subclasses are SSOs (default ServerSideObject). Repeatable."},
+	{"baseInterface", "Simple name of an interface whose implementations are SSOs too. Repeatable."},
+	{"noExtends", "Leave the extends clause out of the simplified SSOs, which is reproduced by default."},
+	{"emitBaseStub", "Also write a stub of each base class the SSOs extend, declaring the methods they inherit."},
+	{"baseStubPackage", "Package to write the base class stubs to, imported by every SSO that extends a base class\n" +
+		"directly; by default each stub goes to the package its SSOs import it from."},
+	{"excludeBaseStub", "Leave the base class stubs out of the --compile jar, for runtimes that provide the real ones."},
+	{"forceCompile", "Run --compile even when some simplified SSOs could not be written, which otherwise skips it."},
+	{"accessors", "JavaBeans getters and setters for the instance fields of each SSO: none (default), add\n" +
+		"(alongside the fields), or replace (instead of the fields)."},
+	{"emitImplements", "Reproduce the implements clause of each simplified SSO."},
+	{"allowType", "Type to allow in member signatures, as TypeName=defaultReturnExpr (e.g. BigDecimal=null);\n" +
+		"the default return defaults to null. Repeatable."},
+	{"allowTypesFile", "File of --allowType entries, one per line; # starts a comment. --allowType entries override it."},
+	{"lenient", "Keep methods with unsupported object return or parameter types, returning null and\n" +
+		"qualifying the types through the file's imports, instead of skipping them."},
+	{"includeProtected", "Extract protected methods and fields too, keeping their access modifier in the stubs."},
+	{"stripJavadoc", "Leave the Javadoc comments of classes and methods out of the simplified SSOs."},
+	{"force", "Overwrite existing files in the output directory that were not generated by this tool."},
+	{"failFast", "Stop writing simplified SSOs at the first that cannot be written instead of writing the rest.\n" +
+		"The reports are still written, and the run fails as for any write failure."},
+	{"touch", "Rewrite simplified SSOs whose content has not changed, updating their modification times."},
+	{"headerFile", "File of license text to start every simplified SSO with, wrapped in a block comment unless\n" +
+		"it is a comment already. ${YEAR} is replaced with the year of generation."},
+	{"reproducible", "Leave the generation time out of the header of simplified SSOs, so re-runs are byte-identical."},
+	{"indent", "Indentation of the simplified SSOs: a number of spaces, or tab (default 4)."},
+	{"stubBody", "Body of each simplified method: default (return a default value), throw (throw an\n" +
+		"UnsupportedOperationException), or todo (return a default value below a TODO comment)."},
+	{"sortMembers", "Order fields by name and methods by name, then parameter types, instead of source order."},
+	{"template", "Go text/template file to render each simplified SSO with instead of the built-in format.\n" +
+		"It is executed with the SSO, its rendered Header, Import, and Class, and the helpers\n" +
+		"JoinedParameters and DefaultReturn."},
+	{"lang", "Language of the simplified SSOs: java (default) or kotlin. Kotlin stubs extend the base\n" +
+		"class directly and cannot be used with --compile."},
+	{"lineEndings", "Line endings of the simplified SSOs: lf (default), crlf, or native."},
+	{"braceStyle", "Placement of opening braces: same-line (default) or next-line."},
+	{"manifest", "Also write a JSON manifest describing every simplified SSO and its skipped methods to this path."},
+	{"previousManifest", "Manifest of an earlier run whose parse results are reused for unchanged files."},
+	{"prune", "After writing, remove the generated files in outputPath that belong to no SSO found, such as\n" +
+		"those of SSOs deleted upstream, and any package directories left empty. Files without the\n" +
+		"generated header are never removed."},
+	{"dryRun", "With --prune, list the files that would be removed without removing them."},
+	{"diffAgainst", "Manifest (.json) or directory to compare the API of the SSOs found with. Removed or changed\n" +
+		fmt.Sprintf("classes, methods, and fields make the run exit with status %d once everything is written.", exitBreakingChanges)},
+	{"diffJSON", "Also write the --diffAgainst report as JSON to this path."},
+	{"sourcesJar", "Also write the simplified SSOs into a reproducible sources jar at this path, laid out by\n" +
+		"package. Needs no JDK."},
+	{"json", "Write a JSON summary of the run (utils.RunSummary) to stdout at the end, sending all other\n" +
+		"output to stderr."},
+	{"junitReport", "Also write a JUnit XML report to this path, with a test suite per package and a test case\n" +
+		"per SSO: passed when written, failed when it could not be, and skipped when filtered out or left\n" +
+		"unwritten by --failFast."},
+	{"sarif", "Also write the parse warnings, and files that could not be scanned, as a SARIF 2.1.0 log to\n" +
+		"this path, with a rule ID per kind of warning, such as SSO001 unsupported-return-type."},
+	{"csv", "Also write a CSV summary to this path, with a row per SSO giving its class, package, source,\n" +
+		"method, field, and skipped method counts, and whether it was written, unchanged, failed, or\n" +
+		"aborted by --failFast."},
+	{"csvMethods", "Write a row per method, kept or skipped, to the --csv summary instead of a row per SSO."},
+	{"typescript", "Also write TypeScript declarations to this directory: a .d.ts file per package, with an\n" +
+		"exported interface per SSO declaring its instance methods and fields."},
+	{"typescriptCombined", "Write the --typescript declarations into a single ssos.d.ts, with a namespace per package."},
+	{"tsType", "TypeScript type of a Java type for --typescript, as JavaType=TypeScriptType, such as\n" +
+		"BigDecimal=string. Can be repeated. Other types added with --allowType are declared unknown."},
+	{"docs", "Also write a Markdown page documenting the methods and fields of every SSO, and an\n" +
+		"index.md linking them by package, to this directory."},
+	{"html", "Also write a static HTML gallery of every SSO, with a searchable index.html and a page per\n" +
+		"class showing its members and highlighted simplified source, to this directory."},
+	{"verify", "Check that the simplified SSOs in outputPath match what would be written now, without\n" +
+		"writing anything, reporting each as up-to-date, stale (with a diff), missing, or orphaned, and\n" +
+		"exiting with status 1 unless all are up to date. Generation times are ignored."},
+	{"checksums", "Also write a SHA256SUMS file to outputPath listing the SHA-256 of every simplified SSO, base\n" +
+		"class stub, manifest, and jar of the run, with paths relative to outputPath."},
+	{"verifyChecksums", "Check outputPath against its SHA256SUMS file instead of the SSOs, listing missing and\n" +
+		"changed files and exiting with status 1 if there are any. Only --outputPath is needed."},
+	{"graph", "Also write a Graphviz DOT graph of the SSOs to this path, with a cluster per package, a node\n" +
+		"per SSO labeled with its method count, and edges along the inheritance chain to the base class."},
+	{"interfaces", "Also write a Java interface per SSO to this directory, named ITokenSSO for TokenSSO and in the\n" +
+		"same package, declaring its public instance methods, so that consumers can mock it."},
+	{"implementInterfaces", "Make each simplified SSO implement its --interfaces interface. With --compile, the\n" +
+		"interfaces are compiled into the jar too."},
+	{"interfacePrefix", "Prefix of the --interfaces names, I by default."},
+	{"interfaceSuffix", "Suffix of the --interfaces names, such as API for TokenSSOAPI; empty by default."},
+	{"combined", "Write a single Markdown digest of every simplified SSO, grouped by package, to\n" +
+		"AllSSOs.md in outputPath instead of a file per SSO, for review."},
+	{"failOnEmpty", "Fail the run when no SSOs are found, rather than writing nothing and succeeding, printing\n" +
+		"the absolute input path and how many .java files were visited."},
+	{"minSSOs", "Fail the run like --failOnEmpty when fewer than this many SSOs are found, such as a little\n" +
+		"under the usual count, to catch an input path that misses part of the tree."},
+	{"stream", "Write each simplified SSO as soon as it is found instead of after the scan. Collisions are\n" +
+		"then resolved in discovery order, and --onCollision=fail stops at the first one."},
+	{"watch", "Keep running after writing the simplified SSOs, checking the input path for changed, added, and\n" +
+		"deleted source files and then rewriting just the simplified SSOs that change, pruning those of deleted\n" +
+		"files, and updating the --manifest. Bursts of changes are handled together once the input path has\n" +
+		"been quiet for a --watchInterval. --compile, being slow, runs on each rebuild only when given. A\n" +
+		"rebuild that fails, such as on colliding SSOs, is reported and watching goes on. Stop with Ctrl+C\n" +
+		"for a summary. Cannot be combined with --json, --verify, or --verifyChecksums."},
+	{"watchInterval", "How often --watch checks the input path for changes, such as 500ms or 2s (default 1s)."},
+	{"jar", "Name of the jar to write into outputPath (default ssos.jar)."},
+	{"sourceDir", "Another directory of sources to compile alongside outputPath, such as an --interfaces directory.\n" +
+		"Repeatable."},
+}
+
+// helpIndent is the column the descriptions of the flags start at in the help messages.
+const helpIndent = 18
+
+// printHelp prints the help message for the program, listing its commands and exit statuses.
+func printHelp(out io.Writer) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "sso_simplifier simplifies SSO Java class files for the VIP SSO Gallery by extracting the package line, class signature, and public method signatures with minimal method code.")
+	fmt.Fprintln(out, "Usage: sso_simplifier <command> [options]")
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(out, "  %-*s%s\n", helpIndent-2, cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "  %-*s%s\n", helpIndent-2, "version", "Print the version, git commit, and build date of sso_simplifier.")
+	fmt.Fprintf(out, "  %-*s%s\n", helpIndent-2, "help", "Print this message, or with a command, the options of the command.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run sso_simplifier <command> --help for the options of a command. Running sso_simplifier with the options")
+	fmt.Fprintln(out, "of simplify and no command still works, but is deprecated and will stop working in a future release.")
+	printExitStatuses(out)
+}
+
+// printCommandHelp prints the help message for a command, listing the flags it accepts and the exit statuses.
+func printCommandHelp(out io.Writer, cmd command, flags *flag.FlagSet) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, cmd.description)
+	if cmd.name == "" {
+		fmt.Fprintln(out, "Usage: sso_simplifier [options] (deprecated; use sso_simplifier simplify [options])")
+	} else {
+		fmt.Fprintf(out, "Usage: sso_simplifier %s [options]\n", cmd.name)
+	}
+	fmt.Fprintln(out, "Options:")
+	for _, entry := range flagHelp {
+		if flags.Lookup(entry.name) == nil {
+			continue
+		}
+		name := "  --" + entry.name
+		if len(name) < helpIndent {
+			name += strings.Repeat(" ", helpIndent-len(name))
+		} else {
+			name += " "
+		}
+		fmt.Fprintln(out, name+strings.ReplaceAll(entry.text, "\n", "\n"+strings.Repeat(" ", helpIndent)))
+	}
+	printExitStatuses(out)
+}
+
+// printExitStatuses prints the exit statuses of a run for the help messages.
+func printExitStatuses(out io.Writer) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Exit statuses:")
+	fmt.Fprintf(out, "  %d  Success.\n", exitSuccess)
+	fmt.Fprintf(out, "  %d  Another failure, such as out-of-date files found by verify, or an unreadable --headerFile,\n", exitFailure)
+	fmt.Fprintln(out, "     --template, or --previousManifest.")
+	fmt.Fprintf(out, "  %d  Invalid usage: a missing, unknown, or invalid flag, or flags that cannot be combined.\n", exitUsage)
+	fmt.Fprintf(out, "  %d  Breaking API changes since the --diffAgainst baseline.\n", exitBreakingChanges)
+	fmt.Fprintf(out, "  %d  The input path could not be scanned, or had parse warnings with --strict.\n", exitScanFailed)
+	fmt.Fprintf(out, "  %d  A simplified SSO, base class stub, or other output could not be written, or SSOs collided\n", exitWriteFailed)
+	fmt.Fprintln(out, "     with --onCollision=fail.")
+	fmt.Fprintf(out, "  %d  The simplified SSOs could not be compiled by compile or --compile.\n", exitCompileFailed)
+	fmt.Fprintf(out, "  %d  Fewer SSOs were found than --failOnEmpty or --minSSOs require.\n", exitNoSSOs)
+}
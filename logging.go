@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/JoshuaAtTrimble/SSO-Simplifier/version"
+)
+
+// levelSummary is the level of the one-line summaries of a run, which --quiet keeps alongside errors.
+const levelSummary = slog.LevelWarn + 2
+
+// consoleLevel is the lowest level the console prints, informational messages until --quiet or --verbose say
+// otherwise.
+var consoleLevel = new(slog.LevelVar)
+
+// logger receives every message of the run, for the console and, with --logFile, the log file.
+var logger = slog.New(newConsoleHandler(consoleLevel))
+
+// setConsoleVerbosity sets the lowest level the console prints: debug messages such as skipped members and phase
+// timings with verbose, only summaries and errors with quiet, and otherwise informational messages on a terminal but
+// only warnings, summaries, and errors when stdout is piped, as in CI, so that skipped members are still reported there.
+func setConsoleVerbosity(quiet bool, verbose bool) {
+	switch {
+	case verbose:
+		consoleLevel.Set(slog.LevelDebug)
+	case quiet:
+		consoleLevel.Set(levelSummary)
+	case !isTerminal(os.Stdout):
+		consoleLevel.Set(slog.LevelWarn)
+	default:
+		consoleLevel.Set(slog.LevelInfo)
+	}
+}
+
+// isTerminal reports whether a file is a terminal rather than a pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// debugf logs a debug message, such as a file visited or a method skipped, formatted like fmt.Printf.
+func debugf(format string, args ...any) {
+	logf(slog.LevelDebug, format, args...)
+}
+
+// infof logs an informational message, such as a file written, formatted like fmt.Printf.
+func infof(format string, args ...any) {
+	logf(slog.LevelInfo, format, args...)
+}
+
+// warnf logs a warning about something the run left out or worked around, formatted like fmt.Printf.
+func warnf(format string, args ...any) {
+	logf(slog.LevelWarn, format, args...)
+}
+
+// summaryf logs a one-line summary of the run, which the console prints even with --quiet, formatted like fmt.Printf.
+func summaryf(format string, args ...any) {
+	logf(levelSummary, format, args...)
+}
+
+// errorf logs an error, formatted like fmt.Printf.
+func errorf(format string, args ...any) {
+	logf(slog.LevelError, format, args...)
+}
+
+// logf formats a message and logs it at the level, without the trailing newline the console adds back.
+func logf(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if logger.Enabled(ctx, level) {
+		logger.Log(ctx, level, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	}
+}
+
+// parseLogLevel parses the name of a --logLevel: debug, info, warn, or error.
+func parseLogLevel(name string) (slog.Level, error) {
+	switch name {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown --logLevel %q, expected debug, info, warn, or error", name)
+}
+
+// openLogFile sends the messages at the level and above to a log file at path, in the text format of log/slog,
+// alongside the console. Every level includes errors. The file is appended to, so that the logs of successive runs
+// are kept, each starting with the version and arguments of the run, and the caller closes it.
+func openLogFile(path string, level slog.Level) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fileHandler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: level, ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+		if attr.Key == slog.LevelKey && attr.Value.Any() == levelSummary {
+			attr.Value = slog.StringValue("SUMMARY")
+		}
+		return attr
+	}})
+	logger = slog.New(multiHandler{logger.Handler(), fileHandler})
+
+	// Mark where the run starts in the file only, since the console shows one run at a time
+	slog.New(fileHandler).Info("Run started", "version", version.String(), "args", strings.Join(os.Args[1:], " "))
+	return file, nil
+}
+
+// outcomeKey is the attribute that gives the outcome of writing an SSO, such as written or failed, which the console
+// shows as the color of the line rather than as text.
+const outcomeKey = "outcome"
+
+// The ANSI escape codes of the console colors.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// outcomeColors are the colors of the lines of written, skipped, and failed SSOs; other outcomes are not colored.
+var outcomeColors = map[string]string{
+	"written":   colorGreen,
+	"skipped":   colorYellow,
+	"protected": colorRed,
+	"failed":    colorRed,
+}
+
+// setConsoleColor colors the console output on terminals that support it, unless noColor is set or the NO_COLOR
+// environment variable is, following https://no-color.org.
+func setConsoleColor(noColor bool) {
+	if handler, ok := logger.Handler().(*consoleHandler); ok {
+		colorAllowed := !noColor && os.Getenv("NO_COLOR") == ""
+		handler.colorStdout = colorAllowed && isTerminal(os.Stdout) && enableColor(os.Stdout)
+		handler.colorStderr = colorAllowed && isTerminal(os.Stderr) && enableColor(os.Stderr)
+	}
+}
+
+// consoleHandler prints the message of each record as a line, as the tool always has, followed by any attributes as
+// key=value pairs. Errors go to stderr and everything else to stdout. Records are printed whole, one at a time. When
+// colors are on, errors are red, warnings yellow, and the lines of SSOs colored by their outcomeKey attribute.
+type consoleHandler struct {
+	level       slog.Leveler
+	mu          *sync.Mutex
+	attrs       []slog.Attr
+	colorStdout bool // Whether stdout gets colors
+	colorStderr bool // Whether stderr gets colors
+}
+
+// newConsoleHandler returns a consoleHandler for the records at the level and above.
+func newConsoleHandler(level slog.Leveler) *consoleHandler {
+	return &consoleHandler{level: level, mu: &sync.Mutex{}}
+}
+
+// Enabled reports whether records at the level are printed.
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle prints the record. The output stream is looked up on every record, so that --json can redirect stdout.
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	line.WriteString(record.Message)
+	color := ""
+	switch {
+	case record.Level >= slog.LevelError:
+		color = colorRed
+	case record.Level >= slog.LevelWarn && record.Level < levelSummary:
+		color = colorYellow
+	}
+	appendAttr := func(attr slog.Attr) bool {
+		if attr.Key == outcomeKey {
+			color = outcomeColors[attr.Value.String()]
+			return true
+		}
+		line.WriteString(" " + attr.Key + "=" + attr.Value.String())
+		return true
+	}
+	for _, attr := range h.attrs {
+		appendAttr(attr)
+	}
+	record.Attrs(appendAttr)
+
+	out, colored := os.Stdout, h.colorStdout
+	if record.Level >= slog.LevelError {
+		out, colored = os.Stderr, h.colorStderr
+	}
+	text := line.String()
+	if colored && color != "" {
+		// Color each line on its own, so that a multi-line message never leaves the terminal colored
+		text = color + strings.ReplaceAll(text, "\n", colorReset+"\n"+color) + colorReset
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := out.WriteString(text + "\n")
+	return err
+}
+
+// WithAttrs returns a handler that prints the attributes with every record.
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &handler
+}
+
+// WithGroup returns the handler itself, since the console does not qualify attribute keys.
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// multiHandler passes each record to every handler that is enabled for it.
+type multiHandler []slog.Handler
+
+// Enabled reports whether any of the handlers is enabled for the level.
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range m {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes the record to the enabled handlers, returning the errors of any that fail.
+func (m multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range m {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a multiHandler of the handlers with the attributes.
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup returns a multiHandler of the handlers in the group.
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
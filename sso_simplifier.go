@@ -1,130 +1,1461 @@
 package main
 
 import (
-	"flag"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/JoshuaAtTrimble/SSO-Simplifier/utils"
+	"github.com/JoshuaAtTrimble/SSO-Simplifier/version"
 )
 
-// printHelp prints the help message for the program, indicating required flags and available options.
-func printHelp() {
-	fmt.Println()
-	fmt.Println("sso_simplifier simplifies SSO Java class files for the VIP SSO Gallery by extracting the package line, class signature, and public method signatures with minimal method code.")
-	fmt.Println("Usage: sso_simplifier [options]")
-	fmt.Println("Options:")
-	fmt.Println("  --help          Display help information.")
-	fmt.Println("  --inputPath     (Required) Path to search for ServerSideObjects (SSOs) to simplify.")
-	fmt.Println("  --outputPath    (Required) Path to save simplified SSOs.")
-	fmt.Println("  --compile       Compile simplified SSOs into a single Java archive.")
-	fmt.Println()
+// The exit statuses of a run, distinct so that CI can tell a misconfigured run from one that failed, and where.
+const (
+	exitSuccess         = 0
+	exitFailure         = 1 // A failure without a status of its own
+	exitUsage           = 2 // A missing, invalid, or conflicting flag, as the flag package exits with
+	exitBreakingChanges = 3 // The --diffAgainst report contains breaking changes
+	exitScanFailed      = 4 // The input path could not be scanned
+	exitWriteFailed     = 5 // An output could not be written
+	exitCompileFailed   = 6 // The --compile jar could not be built
+	exitNoSSOs          = 7 // Fewer SSOs were found than --failOnEmpty or --minSSOs require
+)
+
+// usageError reports a problem with the flags of the run, followed by the help message, on stderr, and exits with
+// exitUsage.
+func usageError(format string, args ...any) {
+	errorf(format, args...)
+	usage()
+	os.Exit(exitUsage)
 }
 
-func main() {
-	// If no arguments or flags are provided, behave as if the user entered the help flag
-	if len(os.Args) == 1 {
-		printHelp()
-		os.Exit(0)
+// loadBaseline returns the SSOs to compare with for --diffAgainst: those of a manifest, or those found by scanning a
+// directory with the same detection options, test skipping, and filter as the main scan.
+func loadBaseline(path string, detectionOptions []utils.ScanOption, filter *utils.SSOFilter, skipTests bool) ([]utils.ServerSideObject, error) {
+	if strings.HasSuffix(path, ".json") {
+		manifest, err := utils.ReadManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		baseline := make([]utils.ServerSideObject, len(manifest.SSOs))
+		for i, entry := range manifest.SSOs {
+			baseline[i] = entry.ServerSideObject
+		}
+		return baseline, nil
 	}
 
-	// Define command-line flags
-	help := flag.Bool("help", false, "Display help information.")
-	inputPath := flag.String("inputPath", "", "Path to search for ServerSideObjects (SSOs) to simplify.")
-	outputPath := flag.String("outputPath", "", "Path to save simplified SSOs.")
-	compile := flag.String("compile", "", "Compile simplified SSOs into a single Java archive.")
+	ssos, err := utils.ScanForSSOs(path, append(detectionOptions, utils.WithFilter(filter))...)
+	if err != nil {
+		return nil, err
+	}
+	var baseline []utils.ServerSideObject
+	for i := range ssos {
+		if !skipTests || !utils.IsTestSource(&ssos[i]) {
+			baseline = append(baseline, ssos[i])
+		}
+	}
+	return baseline, nil
+}
 
-	flag.Parse()
+// stringList is a repeatable string flag that collects every value given for it.
+type stringList []string
 
-	// After parsing flags, check if inputPath and outputPath are provided
-	if *inputPath == "" || *outputPath == "" {
-		fmt.Println("Error: Both --inputPath and --outputPath flags are required.")
-		os.Exit(1)
-	}
+// String returns the collected values separated by commas.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
 
-	if *help {
-		printHelp()
-		os.Exit(0)
+// Set adds a value given for the flag.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// outputSubtree returns the path of the output directory relative to the input directory when it lies inside it,
+// so a later run does not scan the simplified files written by an earlier one. Both paths are resolved to absolute
+// paths with symlinks evaluated, so relative and symlinked spellings of the same directory compare equal.
+func outputSubtree(inputPath string, outputPath string) (string, bool) {
+	input, err := resolvePath(inputPath)
+	if err != nil {
+		return "", false
+	}
+	output, err := resolvePath(outputPath)
+	if err != nil {
+		return "", false
+	}
+	relative, err := filepath.Rel(input, output)
+	if err != nil || relative == "." || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", false
 	}
+	return filepath.ToSlash(relative), true
+}
 
-	// Retrieve a list of ServerSideObjects from the specified directory
-	serverSideObjects, err := utils.ScanForSSOs(*inputPath)
+// resolvePath returns the absolute path with symlinks evaluated. A path that does not exist yet is resolved
+// through its nearest existing parent.
+func resolvePath(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(absolute)
+	if err == nil {
+		return resolved, nil
+	}
+	parent := filepath.Dir(absolute)
+	if parent == absolute {
+		return absolute, nil
+	}
+	resolvedParent, err := resolvePath(parent)
 	if err != nil {
-		fmt.Printf("Error parsing directory: %v\n", err)
-		os.Exit(1)
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(absolute)), nil
+}
+
+// printDeclarations lists a class and its extracted members with their source lines. Members merged from a
+// superclass carry the line from the file that declares them.
+func printDeclarations(sso *utils.ServerSideObject, indent string) {
+	infof("%s%s:%d: class %s\n", indent, sso.FilePath, sso.Line, sso.ClassName)
+	for _, field := range sso.DeclaredFields {
+		infof("%s  line %d: field %s\n", indent, field.Line, field.Name)
+	}
+	for _, method := range sso.DeclaredMethods {
+		if method.Line == 0 {
+			infof("%s  method %s (from the ServerSideObject superclass)\n", indent, method.MethodName)
+			continue
+		}
+		infof("%s  line %d: method %s\n", indent, method.Line, method.MethodName)
+	}
+	for i := range sso.NestedClasses {
+		nested := sso.NestedClasses[i]
+		nested.FilePath = sso.FilePath
+		printDeclarations(&nested, indent+"  ")
 	}
+}
 
-	// Check if there are any matching ServerSideObjects and print the result
-	if len(serverSideObjects) == 0 {
-		fmt.Println("No matching files found.")
+// progressPrinter prints scan progress every progressEvery files or once a second, whichever comes first. On a
+// terminal the counters are redrawn in place; when output is piped each update is its own line.
+type progressPrinter struct {
+	terminal    bool               // Whether stdout is a terminal
+	lastPrinted time.Time          // When the counters were last printed
+	last        utils.ScanProgress // The most recent counters
+}
+
+// progressEvery is the number of scanned files between progress updates.
+const progressEvery = 500
+
+// newProgressPrinter returns a progressPrinter for stdout.
+func newProgressPrinter() *progressPrinter {
+	return &progressPrinter{
+		terminal:    isTerminal(os.Stdout),
+		lastPrinted: time.Now(),
+	}
+}
+
+// update records the latest counters and prints them when an update is due.
+func (p *progressPrinter) update(scanProgress utils.ScanProgress) {
+	p.last = scanProgress
+	if scanProgress.FilesScanned%progressEvery != 0 && time.Since(p.lastPrinted) < time.Second {
+		return
+	}
+	p.lastPrinted = time.Now()
+	line := fmt.Sprintf("Scanned %d files, skipped %d, found %d SSOs so far", scanProgress.FilesScanned, scanProgress.FilesSkipped, scanProgress.SSOsFound)
+	if p.terminal {
+		fmt.Printf("\r%s", line)
 	} else {
-		fmt.Printf("Parsed %d matching files.\n", len(serverSideObjects))
+		fmt.Println(line)
 	}
+}
 
-	// Write each ServerSideObject to the determined output directory
-	for _, sso := range serverSideObjects {
-		err := utils.WriteSimplifiedSSO(*outputPath, &sso)
-		if err != nil {
-			fmt.Printf("Error writing simplified SSO for %s: %v\n", sso.ClassName, err)
+// finish ends the in-place progress line and prints the final summary of the scan.
+func (p *progressPrinter) finish(elapsed time.Duration, ssosFound int) {
+	if p.terminal {
+		fmt.Println()
+	}
+	infof("Scan finished in %s: %d files scanned, %d skipped, %d could not be parsed, %d SSOs found.\n", elapsed.Round(time.Millisecond), p.last.FilesScanned, p.last.FilesSkipped, p.last.FilesFailed, ssosFound)
+}
+
+// streamWriter writes SSOs one at a time as a streaming scan finds them, applying the same test, filter, nested
+// class, enum, and collision handling as the batch path. Collisions are resolved in discovery order, so the SSO found
+// first keeps its path.
+type streamWriter struct {
+	outputPath   string
+	writeOptions utils.WriteOptions
+	filter       *utils.SSOFilter
+	skipTests    bool
+	dropNested   bool
+	emitEnums    bool
+	onCollision  string
+	stubPackage  string // The package base class stubs are relocated to, if any
+	accessors    utils.AccessorMode
+	failFast     bool
+
+	owners       map[string]*utils.ServerSideObject // The SSO written to each output path
+	accepted     utils.ServerSideObjectList         // The SSOs that passed the test and filter checks
+	found        int                                // The SSOs that passed the test check
+	skippedTests int                                // The SSOs skipped because they looked like tests
+	written      int                                // The simplified files written
+	unchanged    int                                // The simplified files left alone because they had not changed
+	failed       int                                // The simplified files that could not be written
+	skipped      int                                // The SSOs skipped due to collisions
+	protected    []string                           // The existing files left alone because they were not generated
+	resolved     []utils.ServerSideObject           // The SSOs left after collision handling, as they are written
+	results      map[string]writeResult             // The outcome of writing each output path, for the reports
+	passedOver   []passedOverSSO                    // The SSOs left out by the test, filter, or collision checks
+	collided     bool                               // Whether a collision stopped the run under the fail policy
+	aborted      bool                               // Whether a failed write stopped the run under --failFast
+}
+
+// write handles a single SSO from the scan, reporting false once a collision or, with failFast, a failed write should
+// stop the run.
+func (w *streamWriter) write(sso utils.ServerSideObject) bool {
+	if w.skipTests && utils.IsTestSource(&sso) {
+		w.skippedTests++
+		w.passedOver = append(w.passedOver, passedOverSSO{sso, "looks like a test"})
+		return true
+	}
+	w.found++
+	if !w.filter.Matches(&sso) {
+		w.passedOver = append(w.passedOver, passedOverSSO{sso, "excluded by the class and package filters"})
+		return true
+	}
+	w.accepted = append(w.accepted, sso)
+
+	if w.dropNested {
+		for _, nested := range sso.NestedClasses {
+			warnf("Warning: dropping nested class %s.%s.\n", sso.ClassName, nested.ClassName)
 		}
+		sso.NestedClasses = nil
+	}
+	if !w.emitEnums {
+		dropEnums(&sso)
 	}
-	fmt.Printf("Simplified SSOs have been written to the output directory: %s\n", *outputPath)
+	if w.stubPackage != "" {
+		utils.RelocateBaseClass(&sso, w.stubPackage)
+	}
+	applyAccessors(&sso, w.accessors)
 
-	// Handle the compile flag
-	if *compile != "" {
-		compiledJarName := *compile
-		if !strings.HasSuffix(compiledJarName, ".jar") {
-			compiledJarName += ".jar"
+	// Handle an SSO whose simplified file would overwrite one already written according to the collision policy
+	outputFilePath := utils.SimplifiedSSOPath(w.outputPath, &sso, w.writeOptions)
+	if kept, ok := w.owners[outputFilePath]; ok {
+		switch w.onCollision {
+		case "fail":
+			errorf("Error: %s (%s) and %s (%s) would both be written to %s.\n", kept.ClassName, kept.FilePath, sso.ClassName, sso.FilePath, outputFilePath)
+			w.collided = true
+			return false
+		case "skip":
+			warnf("Warning: skipping %s (%s), which would overwrite %s (%s) at %s.\n", sso.ClassName, sso.FilePath, kept.ClassName, kept.FilePath, outputFilePath)
+			w.skipped++
+			w.passedOver = append(w.passedOver, passedOverSSO{sso, "collides with " + kept.ClassName + " at " + outputFilePath})
+			return true
+		case "suffix":
+			baseName := sso.ClassName
+			for n := 2; ; n++ {
+				sso.ClassName = fmt.Sprintf("%s_%d", baseName, n)
+				outputFilePath = utils.SimplifiedSSOPath(w.outputPath, &sso, w.writeOptions)
+				if _, taken := w.owners[outputFilePath]; !taken {
+					break
+				}
+			}
+			warnf("Warning: renamed %s (%s) to %s, since it would overwrite %s (%s).\n", baseName, sso.FilePath, sso.ClassName, kept.ClassName, kept.FilePath)
+		}
+	}
+	w.owners[outputFilePath] = &sso
+	w.resolved = append(w.resolved, sso)
+
+	writeStart := time.Now()
+	changed, err := utils.UpdateSimplifiedSSO(w.outputPath, &sso, w.writeOptions)
+	w.results[outputFilePath] = newWriteResult(changed, err, time.Since(writeStart))
+	switch {
+	case errors.Is(err, utils.ErrNotGenerated):
+		w.protected = append(w.protected, outputFilePath)
+	case err != nil:
+		w.failed++
+		if w.failFast {
+			w.aborted = true
+			return false
 		}
+	case changed:
+		w.written++
+	default:
+		w.unchanged++
+	}
+	return true
+}
 
-		// Output statement to indicate the start of the compilation process
-		fmt.Printf("Compiling the simplified SSOs into: %s\n", compiledJarName)
+// writeResult is the outcome of writing the simplified file of an SSO, for the --csv, --json, and --junitReport
+// reports.
+type writeResult struct {
+	status  string        // One of written, unchanged, protected, failed, or aborted
+	err     error         // Why the file was not written, if it was not
+	elapsed time.Duration // The time spent rendering and writing the file
+}
+
+// errAborted is why the simplified SSOs after a failed write were not written when --failFast stopped the run there.
+var errAborted = errors.New("not written (aborted by --failFast)")
+
+// newWriteResult describes the outcome of a call to UpdateSimplifiedSSO.
+func newWriteResult(changed bool, err error, elapsed time.Duration) writeResult {
+	result := writeResult{err: err, elapsed: elapsed}
+	switch {
+	case errors.Is(err, utils.ErrNotGenerated):
+		result.status = "protected"
+	case err != nil:
+		result.status = "failed"
+	case changed:
+		result.status = "written"
+	default:
+		result.status = "unchanged"
+	}
+	return result
+}
 
-		// Path to the compiled JAR file
-		compiledJarPath := filepath.Join(*outputPath, compiledJarName)
+// passedOverSSO is an SSO that was found but not written, and why, for the --junitReport report.
+type passedOverSSO struct {
+	sso    utils.ServerSideObject
+	reason string
+}
 
-		// Compile .java files into .class files
-		javaFiles := []string{}
-		err := filepath.Walk(*outputPath, func(path string, info os.FileInfo, err error) error {
+// writeCSV writes the --csv summary, or with perMethod a row per method, to path.
+func writeCSV(path string, ssos []utils.ServerSideObject, perMethod bool, status func(sso *utils.ServerSideObject) string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if perMethod {
+		err = utils.WriteCSVMethods(file, ssos, status)
+	} else {
+		err = utils.WriteCSVSummary(file, ssos, status)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// compileJar compiles the simplified SSOs under outputPath, and the sources under any further sourceDirs, with javac
+// and packages the classes into a jar at jarPath, leaving out the base class stubs if excludeBaseStub is set, for
+// runtimes that provide the real base classes.
+func compileJar(outputPath string, sourceDirs []string, jarPath string, baseStubs utils.ServerSideObjectList, excludeBaseStub bool) error {
+	// Compile .java files into .class files, once each even when the source directories overlap
+	javaFiles := []string{}
+	seen := make(map[string]bool)
+	for _, dir := range append([]string{outputPath}, sourceDirs...) {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && strings.HasSuffix(info.Name(), ".java") {
+			if absolute, _ := filepath.Abs(path); !info.IsDir() && strings.HasSuffix(info.Name(), ".java") && !seen[absolute] {
+				seen[absolute] = true
 				javaFiles = append(javaFiles, path)
 			}
 			return nil
 		})
 		if err != nil {
-			fmt.Printf("Error finding .java files: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("finding .java files: %w", err)
+		}
+	}
+	if len(javaFiles) == 0 {
+		return errors.New("finding .java files: none found to compile")
+	}
+
+	// Compile the .java files into a separate classes directory so the jar entries follow the package structure
+	classesPath := filepath.Join(outputPath, "classes")
+	cmd := exec.Command("javac", append([]string{"-d", classesPath}, javaFiles...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("compiling .java files: %w", err)
+	}
+
+	// Create the .jar file, leaving out the base class stubs if the runtime provides the real base classes
+	jarArgs := []string{"cf", jarPath, "-C", classesPath, "."}
+	if excludeBaseStub && len(baseStubs) > 0 {
+		entries, err := jarEntries(classesPath, func(entry string) bool { return !isBaseStubEntry(entry, baseStubs) })
+		if err != nil {
+			return fmt.Errorf("listing compiled classes: %w", err)
+		}
+		jarArgs = []string{"cf", jarPath}
+		for _, entry := range entries {
+			jarArgs = append(jarArgs, "-C", classesPath, entry)
+		}
+	}
+	cmd = exec.Command("jar", jarArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("creating .jar file: %w", err)
+	}
+	return nil
+}
+
+// verifyOutput reports how each simplified file in outputPath compares with what would be written for the SSOs,
+// printing the diff of each stale file, and returns the exit status of the --verify run.
+func verifyOutput(outputPath string, ssos []utils.ServerSideObject, writeOptions utils.WriteOptions) int {
+	results, err := utils.VerifySimplifiedSSOs(outputPath, ssos, writeOptions)
+	if err != nil {
+		errorf("Error verifying simplified SSOs: %v\n", err)
+		return exitFailure
+	}
+	outdated := 0
+	for _, result := range results {
+		if result.Status == utils.VerifyUpToDate {
+			infof("%-10s %s (%s)\n", result.Status, result.ClassName, result.Path)
+			continue
+		}
+		outdated++
+		errorf("%-10s %s (%s)\n", result.Status, result.ClassName, result.Path)
+		if result.Diff != "" {
+			errorf("%s", result.Diff)
+		}
+	}
+	if outdated > 0 {
+		errorf("Error: %d of %d simplified files in %s are out of date; rerun sso_simplifier without --verify to regenerate them.\n", outdated, len(results), outputPath)
+		return exitFailure
+	}
+	summaryf("All %d simplified files in %s are up to date.\n", len(results), outputPath)
+	return exitSuccess
+}
+
+// writeJUnitReport writes the --junitReport report to path.
+func writeJUnitReport(path string, cases []utils.JUnitCase) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = utils.WriteJUnitReport(file, cases)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// reportResults lists each SSO with the outcome of writing it, in aligned columns of outcome, class name, package,
+// and method count, the outcome coloring the line on terminals.
+func reportResults(ssos []utils.ServerSideObject, results map[string]writeResult, outputPath string, writeOptions utils.WriteOptions) {
+	if !logger.Enabled(context.Background(), slog.LevelInfo) {
+		return
+	}
+	statuses := make([]string, len(ssos))
+	statusWidth, classWidth, packageWidth := 0, 0, 0
+	for i := range ssos {
+		statuses[i] = results[utils.SimplifiedSSOPath(outputPath, &ssos[i], writeOptions)].status
+		statusWidth = max(statusWidth, len(statuses[i]))
+		classWidth = max(classWidth, len(ssos[i].ClassName))
+		packageWidth = max(packageWidth, len(packageName(ssos[i].PackageLine)))
+	}
+	for i := range ssos {
+		line := fmt.Sprintf("%-*s  %-*s  %-*s  %d methods", statusWidth, statuses[i], classWidth, ssos[i].ClassName, packageWidth, packageName(ssos[i].PackageLine), len(ssos[i].DeclaredMethods))
+		logger.Info(line, outcomeKey, statuses[i])
+	}
+}
+
+// packageName returns the name of a package for display, naming the default package as such.
+func packageName(packageLine string) string {
+	if packageLine == "" {
+		return "(default package)"
+	}
+	return packageLine
+}
+
+// listSSOs prints a line per SSO found by the scan command to stdout, in aligned columns of class name, package, method
+// and field counts, and source path.
+func listSSOs(ssos []utils.ServerSideObject) {
+	classWidth, packageWidth := 0, 0
+	for i := range ssos {
+		classWidth = max(classWidth, len(ssos[i].ClassName))
+		packageWidth = max(packageWidth, len(packageName(ssos[i].PackageLine)))
+	}
+	for i := range ssos {
+		counts := fmt.Sprintf("%d methods, %d fields", len(ssos[i].DeclaredMethods), len(ssos[i].DeclaredFields))
+		fmt.Printf("%-*s  %-*s  %-22s  %s\n", classWidth, ssos[i].ClassName, packageWidth, packageName(ssos[i].PackageLine), counts, ssos[i].FilePath)
+	}
+}
+
+// reportFailures lists the simplified SSOs that could not be written and why, after the summary so that they are not
+// lost among the results, or says where the output went when everything was written. With aborted, it also says that
+// --failFast stopped the run before the rest were written.
+func reportFailures(ssos []utils.ServerSideObject, results map[string]writeResult, outputPath string, writeOptions utils.WriteOptions, aborted bool) {
+	var failures []string
+	for i := range ssos {
+		outputFilePath := utils.SimplifiedSSOPath(outputPath, &ssos[i], writeOptions)
+		if result := results[outputFilePath]; result.status == "failed" {
+			failures = append(failures, fmt.Sprintf("%s (%s): %v", ssos[i].ClassName, outputFilePath, result.err))
+		}
+	}
+	if len(failures) == 0 {
+		infof("Simplified SSOs have been written to the output directory: %s\n", outputPath)
+		return
+	}
+	errorf("Error: could not write %d simplified SSOs:\n", len(failures))
+	for _, failure := range failures {
+		errorf("  %s\n", failure)
+	}
+	if aborted {
+		errorf("Error: stopped at the first failure because of --failFast, leaving the rest unwritten.\n")
+	}
+}
+
+// reportProtected lists the existing files that were left alone because they were not generated by this tool.
+func reportProtected(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	warnf("Warning: did not overwrite %d existing files that were not generated by sso_simplifier (use --force to overwrite them):\n", len(paths))
+	for _, path := range paths {
+		infof("  %s\n", path)
+	}
+}
+
+// jarEntries returns the paths of the files under the classes directory, relative to it, that keep accepts.
+func jarEntries(classesPath string, keep func(string) bool) ([]string, error) {
+	var entries []string
+	err := filepath.WalkDir(classesPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		entry, err := filepath.Rel(classesPath, path)
+		if err != nil {
+			return err
+		}
+		if keep(filepath.ToSlash(entry)) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// isBaseStubEntry reports whether a jar entry is the class file of a base class stub or of a class nested in one.
+func isBaseStubEntry(entry string, baseStubs utils.ServerSideObjectList) bool {
+	for _, stub := range baseStubs {
+		stubPath := strings.ReplaceAll(stub.PackageLine, ".", "/") + "/" + stub.ClassName
+		stubPath = strings.TrimPrefix(stubPath, "/")
+		if entry == stubPath+".class" || strings.HasPrefix(entry, stubPath+"$") {
+			return true
+		}
+	}
+	return false
+}
+
+// countLenientMethods returns the number of methods kept by lenient mode in the SSOs and their nested classes.
+func countLenientMethods(serverSideObjects []utils.ServerSideObject) int {
+	count := 0
+	for _, sso := range serverSideObjects {
+		for _, method := range sso.DeclaredMethods {
+			if method.IsLenient {
+				count++
+			}
+		}
+		count += countLenientMethods(sso.NestedClasses)
+	}
+	return count
+}
+
+// applyAccessors adds the accessors of the mode to an SSO, warning about each one a declared method already provides.
+func applyAccessors(sso *utils.ServerSideObject, mode utils.AccessorMode) {
+	for _, warning := range utils.AddAccessors(sso, mode) {
+		warnf("Warning: %s:%d: %s\n", warning.Path, warning.Line, warning)
+	}
+}
+
+// dropEnums removes the enums recorded on an SSO and its nested classes so they are not written.
+func dropEnums(sso *utils.ServerSideObject) {
+	sso.NestedEnums = nil
+	sso.FileEnums = nil
+	for i := range sso.NestedClasses {
+		dropEnums(&sso.NestedClasses[i])
+	}
+}
+
+// pipelineResult is the outcome of a run of the pipeline.
+type pipelineResult struct {
+	status     int               // The exit status of the run
+	written    int               // The simplified SSOs written because they changed
+	failed     int               // The simplified SSOs that could not be written
+	removed    int               // The generated files removed by pruning
+	parseCache *utils.ParseCache // The parse results of the scan, for the next rebuild in watch mode
+}
+
+// newPipelineResult returns the outcome of a run with the status, counting the simplified SSOs written and failed.
+func newPipelineResult(status int, results map[string]writeResult, removed int, parseCache *utils.ParseCache) pipelineResult {
+	result := pipelineResult{status: status, removed: removed, parseCache: parseCache}
+	for _, written := range results {
+		switch written.status {
+		case "written":
+			result.written++
+		case "failed":
+			result.failed++
+		}
+	}
+	return result
+}
+
+// runPipeline scans the input path and, as far as the stage goes, compares the API of the SSOs found with a
+// --diffAgainst baseline and writes the simplified SSOs and the other outputs, returning the outcome of the run.
+// Problems that stop a run early, such as an input path that cannot be scanned, return their status, so that watch
+// mode can carry on after a failed rebuild; invalid options exit with the usage status.
+func runPipeline(o *options, stage stage) pipelineResult {
+	// Check a delivered output directory against its checksums without scanning anything
+	if o.verifyChecksums {
+		if o.outputPath == "" {
+			usageError("Error: --verifyChecksums needs --outputPath.")
+		}
+		checksumsPath := filepath.Join(o.outputPath, utils.ChecksumsFileName)
+		mismatches, err := utils.VerifyChecksums(o.outputPath, checksumsPath)
+		if err != nil {
+			errorf("Error verifying checksums: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		}
+		for _, mismatch := range mismatches {
+			errorf("%s: %s\n", mismatch.Path, mismatch.Reason)
+		}
+		if len(mismatches) > 0 {
+			errorf("Error: %d files do not match %s.\n", len(mismatches), checksumsPath)
+			return pipelineResult{status: exitFailure}
+		}
+		summaryf("Every file matches %s.\n", checksumsPath)
+		return pipelineResult{status: exitSuccess}
+	}
+
+	// Keep stdout for the --json summary, sending everything else to stderr
+	runStart := time.Now()
+	var summaryOut io.Writer
+	if o.jsonSummary {
+		summaryOut = os.Stdout
+		os.Stdout = os.Stderr
+	}
+
+	// Check that the paths the stage needs are provided
+	if stage == stageWrite && (o.inputPath == "" || o.outputPath == "") {
+		usageError("Error: Both --inputPath and --outputPath flags are required.")
+	}
+	if o.inputPath == "" {
+		usageError("Error: the --inputPath flag is required.")
+	}
+
+	// Compile the SSO filters up front so an invalid pattern is rejected before scanning
+	filter, err := utils.NewSSOFilter(o.includeClass, o.excludeClass, o.includePackage, o.excludePackage)
+	if err != nil {
+		usageError("Error: %v\n", err)
+	}
+
+	// Merge the allowed types from the file and the flags, so an invalid entry is rejected before scanning
+	allowedTypes := make(map[string]string)
+	if o.allowTypesFile != "" {
+		allowedTypes, err = utils.LoadAllowedTypes(o.allowTypesFile)
+		if err != nil {
+			errorf("Error reading allowed types: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		}
+	}
+	for _, entry := range o.allowTypeEntries {
+		typeName, defaultValue, err := utils.ParseAllowedType(entry)
+		if err != nil {
+			usageError("Error: %v\n", err)
+		}
+		allowedTypes[typeName] = defaultValue
+	}
+	typescriptOptions := utils.TypeScriptOptions{Types: make(map[string]string), Combined: o.typescriptCombined}
+	for _, entry := range o.typescriptTypes {
+		javaType, typeScriptType, err := utils.ParseTypeScriptType(entry)
+		if err != nil {
+			usageError("Error: %v\n", err)
+		}
+		typescriptOptions.Types[javaType] = typeScriptType
+	}
+
+	// Check the formatting options up front too, so a typo does not surface after the scan
+	formatOptions := utils.WriteOptions{BraceStyle: utils.BraceStyle(o.braceStyle)}
+	if formatOptions.BraceStyle != utils.BraceSameLine && formatOptions.BraceStyle != utils.BraceNextLine {
+		usageError("Error: unknown --braceStyle %q, expected same-line or next-line.\n", o.braceStyle)
+	}
+	formatOptions.StubBody = utils.StubBody(o.stubBody)
+	if formatOptions.StubBody != utils.StubBodyDefault && formatOptions.StubBody != utils.StubBodyThrow && formatOptions.StubBody != utils.StubBodyTODO {
+		usageError("Error: unknown --stubBody %q, expected default, throw, or todo.\n", o.stubBody)
+	}
+	formatOptions.Language = utils.Language(o.lang)
+	if formatOptions.Language != utils.LanguageJava && formatOptions.Language != utils.LanguageKotlin {
+		usageError("Error: unknown --lang %q, expected java or kotlin.\n", o.lang)
+	}
+	if formatOptions.Language == utils.LanguageKotlin && o.compile != "" {
+		usageError("Error: --compile only supports --lang java, since compiling Kotlin stubs needs kotlinc, which is not supported yet.")
+	}
+	if o.verify && (o.stream || o.combined) {
+		usageError("Error: --verify checks a file per SSO, and cannot be used with --stream or --combined.")
+	}
+	if o.implementInterfaces && o.interfacesDir == "" {
+		usageError("Error: --implementInterfaces needs --interfaces to write the interfaces to.")
+	}
+	if o.implementInterfaces && formatOptions.Language != utils.LanguageJava {
+		usageError("Error: --implementInterfaces only supports --lang java.")
+	}
+	if o.interfacesDir != "" && o.interfacePrefix == "" && o.interfaceSuffix == "" {
+		usageError("Error: --interfacePrefix and --interfaceSuffix cannot both be empty, or the interfaces would be named after their classes.")
+	}
+	formatOptions.LineEnding = utils.LineEnding(o.lineEndings)
+	if formatOptions.LineEnding != utils.LineEndingLF && formatOptions.LineEnding != utils.LineEndingCRLF && formatOptions.LineEnding != utils.LineEndingNative {
+		usageError("Error: unknown --lineEndings %q, expected lf, crlf, or native.\n", o.lineEndings)
+	}
+	if o.headerFile != "" {
+		license, err := os.ReadFile(o.headerFile)
+		if err != nil {
+			errorf("Error: cannot read --headerFile: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		}
+		formatOptions.License = string(license)
+	}
+	if o.templatePath != "" {
+		if formatOptions.Template, err = utils.ParseTemplate(o.templatePath); err != nil {
+			errorf("Error: invalid --template: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		}
+	}
+	if o.indent == "tab" {
+		formatOptions.UseTabs = true
+	} else if formatOptions.IndentWidth, err = strconv.Atoi(o.indent); err != nil || formatOptions.IndentWidth < 1 {
+		usageError("Error: invalid --indent %q, expected a positive number of spaces or tab.\n", o.indent)
+	}
+
+	// A digest needs every SSO at once and holds no compilable sources
+	if o.combined && (o.stream || o.compile != "" || o.emitBaseStub) {
+		usageError("Error: --combined cannot be used with --stream, --compile, or --emitBaseStub.")
+	}
+
+	if o.dryRun && !o.prune {
+		usageError("Error: --dryRun only applies to --prune.")
+	}
+	if o.minSSOs < 0 {
+		usageError("Error: --minSSOs cannot be negative.")
+	}
+
+	// Check the accessor mode up front too
+	if mode := utils.AccessorMode(o.accessors); mode != utils.AccessorsNone && mode != utils.AccessorsAdd && mode != utils.AccessorsReplace {
+		usageError("Error: unknown --accessors mode %q, expected none, add, or replace.\n", o.accessors)
+	}
+
+	// Base class stubs are only relocated when they are written
+	stubPackage := ""
+	if o.emitBaseStub {
+		stubPackage = o.baseStubPackage
+	}
+
+	// Check the collision policy up front too, since a streaming run starts writing before the scan finishes
+	if o.onCollision != "fail" && o.onCollision != "skip" && o.onCollision != "suffix" {
+		usageError("Error: unknown --onCollision policy %q, expected fail, skip, or suffix.\n", o.onCollision)
+	}
+
+	// Note whether a single .java file was given, so the summary can speak about that file
+	singleFile := false
+	if info, err := os.Stat(o.inputPath); err == nil {
+		singleFile = utils.IsJavaSourceFile(o.inputPath, info)
+	}
+
+	// Leave the output directory out of the scan when it lies inside the input directory
+	if info, err := os.Stat(o.inputPath); err == nil && info.IsDir() && o.outputPath != "" {
+		if subtree, ok := outputSubtree(o.inputPath, o.outputPath); ok {
+			infof("Note: excluding the output directory %s from the scan, since it is inside the input path.\n", o.outputPath)
+			o.excludes = append(o.excludes, utils.EscapeGlob(subtree))
+		}
+	}
+
+	// Retrieve a list of ServerSideObjects from the specified input path
+	var warnings []utils.Warning
+	scanOptions := []utils.ScanOption{utils.WithWarnings(&warnings), utils.WithSlogLogger(logger)}
+	var printer *progressPrinter
+	if o.showProgress {
+		printer = newProgressPrinter()
+	}
+	var lastProgress utils.ScanProgress // The final counters of the scan, for the --json summary
+	scanOptions = append(scanOptions, utils.WithProgress(func(progress utils.ScanProgress) {
+		lastProgress = progress
+		if printer != nil {
+			printer.update(progress)
+		}
+	}))
+	// The options that decide which files and SSOs are found, shared with the scan of a --diffAgainst directory
+	detectionOptions := []utils.ScanOption{utils.WithParallelism(o.parallel), utils.WithExclude(o.excludes...), utils.WithRespectGitignore(o.respectGitignore), utils.WithSourceEncoding(o.sourceEncoding), utils.WithMaxFileSize(o.maxFileSizeMB * 1024 * 1024), utils.WithFollowSymlinks(o.followSymlinks), utils.WithBaseClasses(o.baseClasses...), utils.WithBaseInterfaces(o.baseInterfaces...), utils.WithAllowedTypes(allowedTypes), utils.WithLenient(o.lenient), utils.WithIncludeProtected(o.includeProtected)}
+	scanOptions = append(scanOptions, utils.WithFailFast(o.strict))
+	scanOptions = append(scanOptions, detectionOptions...)
+	writeOptions := utils.WriteOptions{FlatOutput: o.flatOutput, OmitExtends: o.noExtends, EmitImplements: o.emitImplements, AllowedTypes: allowedTypes, StripJavadoc: o.stripJavadoc, Generator: "sso_simplifier " + version.Version(), IndentWidth: formatOptions.IndentWidth, UseTabs: formatOptions.UseTabs, BraceStyle: formatOptions.BraceStyle, StubBody: formatOptions.StubBody, SortMembers: o.sortMembers, Template: formatOptions.Template, License: formatOptions.License, LineEnding: formatOptions.LineEnding, Language: formatOptions.Language, InterfaceNaming: utils.InterfaceNaming{Prefix: o.interfacePrefix, Suffix: o.interfaceSuffix}, ImplementInterfaces: o.implementInterfaces, Force: o.force, Touch: o.touch}
+	if !o.reproducible {
+		writeOptions.Timestamp = time.Now()
+	}
+
+	// Reuse the parse results of an earlier run by the same version of the tool, and record this run's for the next
+	var previousManifest *utils.Manifest
+	if o.previousManifestPath != "" {
+		manifest, err := utils.ReadManifest(o.previousManifestPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			infof("Previous manifest %s not found, parsing every file.\n", o.previousManifestPath)
+		case err != nil:
+			errorf("Error reading previous manifest: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		case manifest.SchemaVersion != utils.ManifestSchemaVersion || manifest.Generator != writeOptions.Generator:
+			infof("Previous manifest %s was written by %s, parsing every file.\n", o.previousManifestPath, manifest.Generator)
+			previousManifest = &manifest
+			previousManifest.ParseCache = nil
+		default:
+			previousManifest = &manifest
+		}
+	}
+	var parseCache utils.ParseCache
+	if previousManifest != nil || o.manifestPath != "" || o.watch {
+		var previousCache *utils.ParseCache
+		switch {
+		case o.watchParseCache != nil:
+			previousCache = o.watchParseCache // Only the files changed since the last rebuild are parsed again
+		case previousManifest != nil:
+			previousCache = previousManifest.ParseCache
+		}
+		scanOptions = append(scanOptions, utils.WithParseCache(previousCache, &parseCache))
+	}
+	scanStart := time.Now()
+	var serverSideObjects utils.ServerSideObjectList
+	var writer *streamWriter
+	if o.stream {
+		// Write each SSO as it arrives, stopping the scan early if a collision or, with --failFast, a failed write fails the run
+		writer = &streamWriter{
+			outputPath:   o.outputPath,
+			writeOptions: writeOptions,
+			filter:       filter,
+			skipTests:    o.skipTests && !o.includeTests,
+			dropNested:   o.dropNested,
+			emitEnums:    o.emitEnums,
+			onCollision:  o.onCollision,
+			stubPackage:  stubPackage,
+			accessors:    utils.AccessorMode(o.accessors),
+			failFast:     o.failFast,
+			owners:       make(map[string]*utils.ServerSideObject),
+			results:      make(map[string]writeResult),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		ssos, errs := utils.ScanForSSOsStream(ctx, o.inputPath, scanOptions...)
+		stopped := false
+		for sso := range ssos {
+			if !stopped && !writer.write(sso) {
+				stopped = true
+				cancel()
+			}
+		}
+		err = <-errs
+		cancel()
+		if writer.collided {
+			return pipelineResult{status: exitWriteFailed}
+		}
+		if writer.aborted {
+			err = nil // The scan was cancelled, so its errors say nothing about the input path
+		}
+		serverSideObjects = writer.accepted
+	} else {
+		serverSideObjects, err = utils.ScanForSSOs(o.inputPath, scanOptions...)
+	}
+	scanElapsed := time.Since(scanStart)
+	debugf("Scanning took %s.", scanElapsed.Round(time.Millisecond))
+	if printer != nil {
+		printer.finish(scanElapsed, len(serverSideObjects))
+	}
+	var scanErrors utils.ScanErrors
+	var tooLarge []string
+	var skippedFiles []utils.Warning // The files that could not be scanned, for --sarif
+	if errors.As(err, &scanErrors) {
+		// Files that could not be scanned are reported, but the SSOs found elsewhere are still written
+		for _, scanErr := range scanErrors {
+			skippedFiles = append(skippedFiles, utils.Warning{Path: scanErr.Path, Reason: scanErr.Err.Error(), Rule: utils.RuleSkippedFile})
+			if errors.Is(scanErr.Err, utils.ErrFileTooLarge) {
+				warnf("Warning: skipping %s: %v\n", scanErr.Path, scanErr.Err)
+				tooLarge = append(tooLarge, scanErr.Path)
+				continue
+			}
+			warnf("Warning: could not scan %s: %v\n", scanErr.Path, scanErr.Err)
+		}
+	} else if err != nil {
+		errorf("Error scanning input path: %v\n", err)
+		return pipelineResult{status: exitScanFailed}
+	}
+
+	if o.previousManifestPath != "" {
+		infof("Reused %d files from the previous manifest, reparsed %d.\n", parseCache.Reused, parseCache.Reparsed)
+	}
+
+	// Leave out SSO-like classes that look like tests, counting them so nothing disappears silently
+	var passedOver []passedOverSSO // The SSOs left out by the test, filter, or collision checks
+	if writer != nil {
+		passedOver = writer.passedOver
+	}
+	if writer != nil && writer.skippedTests > 0 {
+		infof("Skipped %d SSO-like classes that looked like tests (use --includeTests to keep them).\n", writer.skippedTests)
+	} else if writer == nil && o.skipTests && !o.includeTests {
+		var kept []utils.ServerSideObject
+		for i := range serverSideObjects {
+			if !utils.IsTestSource(&serverSideObjects[i]) {
+				kept = append(kept, serverSideObjects[i])
+			} else {
+				passedOver = append(passedOver, passedOverSSO{serverSideObjects[i], "looks like a test"})
+			}
+		}
+		if skippedTests := len(serverSideObjects) - len(kept); skippedTests > 0 {
+			infof("Skipped %d SSO-like classes that looked like tests (use --includeTests to keep them).\n", skippedTests)
+		}
+		serverSideObjects = kept
+	}
+
+	// Keep the SSOs that pass the class and package filters
+	if writer != nil && (filter.IncludeClass != nil || filter.ExcludeClass != nil || filter.IncludePackage != nil || filter.ExcludePackage != nil) {
+		infof("Matched %d of %d SSOs.\n", len(serverSideObjects), writer.found)
+	} else if filter.IncludeClass != nil || filter.ExcludeClass != nil || filter.IncludePackage != nil || filter.ExcludePackage != nil {
+		matched := filter.Apply(serverSideObjects)
+		for i := range serverSideObjects {
+			if !filter.Matches(&serverSideObjects[i]) {
+				passedOver = append(passedOver, passedOverSSO{serverSideObjects[i], "excluded by the class and package filters"})
+			}
+		}
+		infof("Matched %d of %d SSOs.\n", len(matched), len(serverSideObjects))
+		serverSideObjects = matched
+	}
+
+	// Check if there are any matching ServerSideObjects and print the result
+	if len(serverSideObjects) == 0 && singleFile {
+		infof("%s did not contain an SSO.\n", o.inputPath)
+	} else if len(serverSideObjects) == 0 {
+		infof("No matching files found.")
+	} else {
+		infof("Parsed %d matching files.\n", len(serverSideObjects))
+	}
+	// Fail a run that found too few SSOs, saying where it looked and how much it saw, so that a mistyped input path
+	// can be told apart from a tree without SSOs
+	minSSOs := o.minSSOs
+	if o.failOnEmpty {
+		minSSOs = max(minSSOs, 1)
+	}
+	if len(serverSideObjects) < minSSOs {
+		inputPath, err := filepath.Abs(o.inputPath)
+		if err != nil {
+			inputPath = o.inputPath
+		}
+		if len(serverSideObjects) == 0 && o.failOnEmpty && o.minSSOs <= 1 {
+			errorf("Error: no SSOs were found in %s, and --failOnEmpty is set.\n", inputPath)
+		} else {
+			errorf("Error: found %d SSOs in %s, fewer than the %d --minSSOs requires.\n", len(serverSideObjects), inputPath, minSSOs)
+		}
+		errorf("Visited %d .java files, of which %d could not be parsed, and skipped %d excluded, ignored, or too large.\n", lastProgress.FilesScanned, lastProgress.FilesFailed, lastProgress.FilesSkipped)
+		return pipelineResult{status: exitNoSSOs}
+	}
+
+	// List every extracted declaration with its location when requested
+	if o.verbose {
+		for i := range serverSideObjects {
+			printDeclarations(&serverSideObjects[i], "")
+		}
+	}
+
+	// Print the parse warnings grouped by file, failing on them in strict mode
+	files, warningsByFile := utils.GroupWarningsByFile(warnings)
+	for _, file := range files {
+		warnf("Warnings in %s:\n", file)
+		for _, warning := range warningsByFile[file] {
+			if warning.Line > 0 {
+				warnf("  %s:%d: %s\n", file, warning.Line, warning)
+			} else {
+				warnf("  %s: %s\n", file, warning)
+			}
+
+			// Give the skipped members structured attributes too, leaving file-level warnings such as encoding problems
+			// to the line above
+			if warning.Member != "" {
+				logger.Debug("Skipped member", "path", warning.Path, "line", warning.Line, "class", warning.Class, "member", warning.Member, "rule", warning.Rule, "reason", warning.Reason)
+			}
+		}
+	}
+	if o.lenient {
+		warnf("Kept %d methods with unsupported types leniently, skipped %d methods.\n", countLenientMethods(serverSideObjects), utils.CountSkippedMethods(warnings))
+	}
+	// Log the warnings for code review tooling before strict mode can fail the run
+	if o.sarifPath != "" {
+		if err := utils.WriteSARIF(o.sarifPath, append(skippedFiles, warnings...), "sso_simplifier", version.Version()); err != nil {
+			errorf("Error writing SARIF log: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("SARIF log written to: %s\n", o.sarifPath)
+	}
+	if o.strict && len(warnings) > 0 {
+		errorf("Error: %d parse warnings in strict mode.\n", len(warnings))
+		return pipelineResult{status: exitScanFailed}
+	}
+
+	if len(tooLarge) > 0 {
+		warnf("Skipped %d files over the size limit:\n", len(tooLarge))
+		for _, path := range tooLarge {
+			warnf("  %s\n", path)
+		}
+	}
+
+	// Compare the API of the SSOs found with a baseline before any of them are adjusted for writing
+	breakingChanges := false
+	if o.diffAgainst != "" {
+		baseline, err := loadBaseline(o.diffAgainst, detectionOptions, filter, o.skipTests && !o.includeTests)
+		if err != nil {
+			errorf("Error loading the --diffAgainst baseline: %v\n", err)
+			return pipelineResult{status: exitFailure}
+		}
+		diff := utils.DiffAPIs(baseline, serverSideObjects)
+		level := slog.LevelInfo
+		if stage == stageDiff {
+			level = levelSummary // The report is what the diff command is run for
+		}
+		logf(level, "API changes since %s:\n%s", o.diffAgainst, diff)
+		if o.diffJSON != "" {
+			// Keep the "->" of changed members readable rather than HTML-escaped
+			var data bytes.Buffer
+			encoder := json.NewEncoder(&data)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			err := encoder.Encode(diff)
+			if err == nil {
+				err = os.WriteFile(o.diffJSON, data.Bytes(), 0o644)
+			}
+			if err != nil {
+				errorf("Error writing API diff: %v\n", err)
+				return pipelineResult{status: exitWriteFailed}
+			}
+		}
+		breakingChanges = diff.IsBreaking()
+	}
+
+	writePhaseStart := time.Now()
+	var resolved []utils.ServerSideObject   // The SSOs left after collision handling, for the sources jar and manifest
+	results := make(map[string]writeResult) // The outcome of writing each output path, for the reports
+	skippedCollisions := 0
+	aborted := false // Whether --failFast stopped writing at a failure
+	if stage != stageWrite {
+		// Scanning and comparing write nothing, and a scan lists the SSOs found instead
+		resolved = serverSideObjects
+		if stage == stageScan {
+			listSSOs(resolved)
+		}
+	} else if writer != nil {
+		// A streaming run has already written its SSOs
+		resolved = writer.resolved
+		results = writer.results
+		skippedCollisions = writer.skipped
+		aborted = writer.aborted
+		reportResults(resolved, results, o.outputPath, writeOptions)
+		summaryf("Wrote %d simplified SSOs, %d unchanged, %d failed, skipped %d due to collisions.\n", writer.written, writer.unchanged, writer.failed, writer.skipped)
+		reportFailures(resolved, results, o.outputPath, writeOptions, aborted)
+		reportProtected(writer.protected)
+	} else {
+		// Drop nested classes if requested, warning about each one so nothing disappears silently
+		if o.dropNested {
+			for i := range serverSideObjects {
+				for _, nested := range serverSideObjects[i].NestedClasses {
+					warnf("Warning: dropping nested class %s.%s.\n", serverSideObjects[i].ClassName, nested.ClassName)
+				}
+				serverSideObjects[i].NestedClasses = nil
+			}
+		}
+
+		// Leave enums out of the output unless requested
+		if !o.emitEnums {
+			for i := range serverSideObjects {
+				dropEnums(&serverSideObjects[i])
+			}
+		}
+
+		// Import the base class from the package of its stub
+		if stubPackage != "" {
+			for i := range serverSideObjects {
+				utils.RelocateBaseClass(&serverSideObjects[i], stubPackage)
+			}
+		}
+
+		// Expose the instance fields through accessors if requested
+		for i := range serverSideObjects {
+			applyAccessors(&serverSideObjects[i], utils.AccessorMode(o.accessors))
+		}
+
+		// Handle SSOs whose simplified files would overwrite each other according to the collision policy
+		skipped := make(map[*utils.ServerSideObject]bool)
+		switch o.onCollision {
+		case "fail":
+			collisions := utils.FindCollisions(serverSideObjects, o.outputPath, writeOptions)
+			for _, collision := range collisions {
+				errorf("Error: %s (%s) and %s (%s) would both be written to %s.\n", collision.Kept.ClassName, collision.Kept.FilePath, collision.Colliding.ClassName, collision.Colliding.FilePath, collision.OutputPath)
+			}
+			if len(collisions) > 0 {
+				return pipelineResult{status: exitWriteFailed}
+			}
+		case "skip":
+			for _, collision := range utils.FindCollisions(serverSideObjects, o.outputPath, writeOptions) {
+				warnf("Warning: skipping %s (%s), which would overwrite %s (%s) at %s.\n", collision.Colliding.ClassName, collision.Colliding.FilePath, collision.Kept.ClassName, collision.Kept.FilePath, collision.OutputPath)
+				skipped[collision.Colliding] = true
+				passedOver = append(passedOver, passedOverSSO{*collision.Colliding, "collides with " + collision.Kept.ClassName + " at " + collision.OutputPath})
+			}
+		case "suffix":
+			for _, collision := range utils.RenameCollisions(serverSideObjects, o.outputPath, writeOptions) {
+				warnf("Warning: renamed %s (%s) to %s, since it would overwrite %s (%s).\n", collision.Kept.ClassName, collision.Colliding.FilePath, collision.Colliding.ClassName, collision.Kept.ClassName, collision.Kept.FilePath)
+			}
+		default:
+			usageError("Error: unknown --onCollision policy %q, expected fail, skip, or suffix.\n", o.onCollision)
+		}
+
+		for i := range serverSideObjects {
+			if !skipped[&serverSideObjects[i]] {
+				resolved = append(resolved, serverSideObjects[i])
+			}
+		}
+		skippedCollisions = len(skipped)
+
+		// Compare the existing output with what would be written, including any base class stubs, and stop there
+		if o.verify {
+			expected := slices.Clone(resolved)
+			if o.emitBaseStub {
+				expected = append(expected, utils.BaseClassStubs(serverSideObjects)...)
+			}
+			return pipelineResult{status: verifyOutput(o.outputPath, expected, writeOptions)}
+		}
+
+		if o.combined {
+			// Write a single digest for review instead of a file per SSO
+			combinedPath := filepath.Join(o.outputPath, utils.CombinedSSOsFileName)
+			writeStart := time.Now()
+			changed, err := utils.UpdateCombinedSSOs(o.outputPath, resolved, writeOptions)
+			if err != nil {
+				errorf("Error writing combined SSOs: %v\n", err)
+				return pipelineResult{status: exitWriteFailed}
+			}
+			for i := range resolved {
+				results[utils.SimplifiedSSOPath(o.outputPath, &resolved[i], writeOptions)] = newWriteResult(changed, nil, time.Since(writeStart)/time.Duration(len(resolved)))
+			}
+			if changed {
+				summaryf("Wrote %d simplified SSOs to %s, skipped %d due to collisions.\n", len(resolved), combinedPath, len(skipped))
+			} else {
+				summaryf("%s is unchanged, with %d simplified SSOs.\n", combinedPath, len(resolved))
+			}
+		} else {
+			// Write each ServerSideObject to the determined output directory
+			written, unchanged, failed := 0, 0, 0
+			var protected []string
+			for i := range serverSideObjects {
+				sso := &serverSideObjects[i]
+				if skipped[sso] {
+					continue
+				}
+				if aborted {
+					// Record the SSOs left unwritten, so the reports do not count them as written
+					results[utils.SimplifiedSSOPath(o.outputPath, sso, writeOptions)] = writeResult{status: "aborted", err: errAborted}
+					continue
+				}
+				writeStart := time.Now()
+				changed, err := utils.UpdateSimplifiedSSO(o.outputPath, sso, writeOptions)
+				results[utils.SimplifiedSSOPath(o.outputPath, sso, writeOptions)] = newWriteResult(changed, err, time.Since(writeStart))
+				switch {
+				case errors.Is(err, utils.ErrNotGenerated):
+					protected = append(protected, utils.SimplifiedSSOPath(o.outputPath, sso, writeOptions))
+				case err != nil:
+					failed++
+					aborted = o.failFast
+				case changed:
+					written++
+				default:
+					unchanged++
+				}
+			}
+			reportResults(resolved, results, o.outputPath, writeOptions)
+			summaryf("Wrote %d simplified SSOs, %d unchanged, %d failed, skipped %d due to collisions.\n", written, unchanged, failed, len(skipped))
+			reportFailures(resolved, results, o.outputPath, writeOptions, aborted)
+			reportProtected(protected)
+		}
+	}
+
+	if stage == stageWrite {
+		debugf("Writing took %s.", time.Since(writePhaseStart).Round(time.Millisecond))
+	}
+
+	// Fail the run once everything else is written if any simplified SSO could not be
+	writeFailed := false
+	for _, result := range results {
+		writeFailed = writeFailed || result.status == "failed"
+	}
+
+	// Collect the files written this run for --checksums, starting with the simplified SSOs
+	var checksummed []string
+	if o.combined && len(resolved) > 0 {
+		checksummed = append(checksummed, filepath.Join(o.outputPath, utils.CombinedSSOsFileName))
+	} else {
+		for i := range resolved {
+			outputFilePath := utils.SimplifiedSSOPath(o.outputPath, &resolved[i], writeOptions)
+			if status := results[outputFilePath].status; status == "written" || status == "unchanged" {
+				checksummed = append(checksummed, outputFilePath)
+			}
+		}
+	}
+
+	// Write a stub of each base class once, however many SSOs extend it, so the extends clauses resolve
+	var baseStubs utils.ServerSideObjectList
+	if o.emitBaseStub {
+		if stubPackage != "" {
+			for i := range serverSideObjects {
+				utils.RelocateBaseClass(&serverSideObjects[i], stubPackage) // Streamed SSOs were relocated as they were written
+			}
+		}
+		baseStubs = utils.BaseClassStubs(serverSideObjects)
+		for _, stub := range baseStubs {
+			if _, err := utils.UpdateSimplifiedSSO(o.outputPath, &stub, writeOptions); err != nil {
+				errorf("Error writing base class stub %s: %v\n", stub.ClassName, err)
+				writeFailed = true
+				continue
+			}
+			infof("Wrote base class stub %s.\n", utils.SimplifiedSSOPath(o.outputPath, &stub, writeOptions))
+			checksummed = append(checksummed, utils.SimplifiedSSOPath(o.outputPath, &stub, writeOptions))
+		}
+	}
+
+	// Draw the SSOs and their inheritance for onboarding
+	if o.graphPath != "" {
+		if _, err := utils.WriteGraph(o.graphPath, resolved); err != nil {
+			errorf("Error writing graph: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("Graph written to: %s\n", o.graphPath)
+	}
+
+	// Extract an interface from each SSO for consumers that mock them
+	if o.interfacesDir != "" {
+		written, err := utils.WriteInterfaces(o.interfacesDir, resolved, writeOptions)
+		if err != nil {
+			errorf("Error writing interfaces: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
 		}
+		infof("Interfaces written to: %s (%d files changed)\n", o.interfacesDir, written)
+	}
 
-		if len(javaFiles) == 0 {
-			fmt.Println("No .java files found to compile.")
-			os.Exit(1)
+	// Package the simplified sources, and any base class stubs, into a sources jar
+	if o.sourcesJar != "" {
+		if err := utils.WriteSourcesJar(o.sourcesJar, append(resolved, baseStubs...), writeOptions); err != nil {
+			errorf("Error writing sources jar: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
 		}
+		infof("Sources jar created at: %s\n", o.sourcesJar)
+		checksummed = append(checksummed, o.sourcesJar)
+	}
 
-		// Compile the .java files
-		cmd := exec.Command("javac", append([]string{"-d", *outputPath}, javaFiles...)...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Error compiling .java files: %v\n", err)
-			os.Exit(1)
+	// Summarize the SSOs and how they were written for spreadsheets
+	if o.csvPath != "" {
+		status := func(sso *utils.ServerSideObject) string {
+			return results[utils.SimplifiedSSOPath(o.outputPath, sso, writeOptions)].status
 		}
+		if err := writeCSV(o.csvPath, resolved, o.csvMethods, status); err != nil {
+			errorf("Error writing CSV summary: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("CSV summary written to: %s\n", o.csvPath)
+	}
 
-		// Create the .jar file
-		cmd = exec.Command("jar", "cf", compiledJarPath, "-C", *outputPath, ".")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Error creating .jar file: %v\n", err)
-			os.Exit(1)
+	// Declare the SSOs for TypeScript consumers of the JS bridge
+	if o.typescriptDir != "" {
+		written, err := utils.WriteTypeScript(o.typescriptDir, resolved, writeOptions, typescriptOptions)
+		if err != nil {
+			errorf("Error writing TypeScript declarations: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
 		}
+		infof("TypeScript declarations written to: %s (%d files changed)\n", o.typescriptDir, written)
+	}
+
+	// Document the SSOs for the wiki
+	if o.docsDir != "" {
+		written, err := utils.WriteMarkdownDocs(o.docsDir, resolved)
+		if err != nil {
+			errorf("Error writing Markdown documentation: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("Markdown documentation written to: %s (%d files changed)\n", o.docsDir, written)
+	}
+
+	// Publish the SSOs as a browsable gallery
+	if o.htmlDir != "" {
+		written, err := utils.WriteHTMLGallery(o.htmlDir, resolved, writeOptions)
+		if err != nil {
+			errorf("Error writing HTML gallery: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("HTML gallery written to: %s (%d files changed)\n", o.htmlDir, written)
+	}
+
+	// Remove the generated files of SSOs that were deleted or renamed upstream, keeping the stubs and interfaces
+	removedFiles := 0
+	if o.prune {
+		var keep []string
+		for i := range baseStubs {
+			keep = append(keep, utils.SimplifiedSSOPath(o.outputPath, &baseStubs[i], writeOptions))
+		}
+		if o.interfacesDir != "" {
+			for i := range resolved {
+				keep = append(keep, utils.InterfacePath(o.interfacesDir, &resolved[i], writeOptions))
+			}
+		}
+		removed, err := utils.PruneOrphanedFiles(o.outputPath, resolved, keep, writeOptions, o.dryRun)
+		for _, path := range removed {
+			if o.dryRun {
+				infof("Would remove %s, whose SSO no longer exists.\n", path)
+			} else {
+				infof("Removed %s, whose SSO no longer exists.\n", path)
+				removedFiles++
+			}
+		}
+		if err != nil {
+			errorf("Error pruning simplified SSOs: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+	}
+
+	// Describe the simplified SSOs for downstream tooling, even when none were found
+	if o.manifestPath != "" {
+		manifest := utils.NewManifest(o.inputPath, resolved, writeOptions)
+		manifest.ParseCache = &parseCache
+		if err := utils.WriteManifest(o.manifestPath, manifest); err != nil {
+			errorf("Error writing manifest: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("Manifest written to: %s\n", o.manifestPath)
+		checksummed = append(checksummed, o.manifestPath)
+	}
+
+	// Compile the simplified SSOs into a jar, reporting the outcome in the --json summary before failing, unless some
+	// could not be written, since a jar missing them would look complete
+	var compileResult *utils.CompileResult
+	if o.compile != "" && writeFailed && !o.forceCompile {
+		compiledJarName := o.compile
+		if !strings.HasSuffix(compiledJarName, ".jar") {
+			compiledJarName += ".jar"
+		}
+		warnf("Warning: skipping --compile, since some simplified SSOs could not be written (use --forceCompile to compile anyway).\n")
+		compileResult = &utils.CompileResult{JarPath: filepath.Join(o.outputPath, compiledJarName), Skipped: true}
+	} else if o.compile != "" {
+		compiledJarName := o.compile
+		if !strings.HasSuffix(compiledJarName, ".jar") {
+			compiledJarName += ".jar"
+		}
+		compiledJarPath := filepath.Join(o.outputPath, compiledJarName)
+		infof("Compiling the simplified SSOs into: %s\n", compiledJarName)
+		compileResult = &utils.CompileResult{JarPath: compiledJarPath, Succeeded: true}
+		var sourceDirs []string
+		if o.implementInterfaces {
+			sourceDirs = append(sourceDirs, o.interfacesDir)
+		}
+		compileStart := time.Now()
+		err := compileJar(o.outputPath, sourceDirs, compiledJarPath, baseStubs, o.excludeBaseStub)
+		debugf("Compiling took %s.", time.Since(compileStart).Round(time.Millisecond))
+		if err != nil {
+			errorf("Error %v\n", err)
+			compileResult.Succeeded, compileResult.Error = false, err.Error()
+		} else {
+			infof("Compiled .jar file created at: %s\n", compiledJarPath)
+			checksummed = append(checksummed, compiledJarPath)
+		}
+	}
+
+	// List the checksums of the files written this run, so a delivery can be verified with --verifyChecksums
+	if o.checksums {
+		checksumsPath, err := utils.WriteChecksums(o.outputPath, checksummed)
+		if err != nil {
+			errorf("Error writing checksums: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("Checksums of %d files written to: %s\n", len(checksummed), checksumsPath)
+	}
+
+	// Report each SSO as a test case for CI dashboards, with compilation as a test case of its own
+	if o.junitReport != "" {
+		var cases []utils.JUnitCase
+		for i := range resolved {
+			result := results[utils.SimplifiedSSOPath(o.outputPath, &resolved[i], writeOptions)]
+			testCase := utils.JUnitCase{ClassName: resolved[i].ClassName, Package: resolved[i].PackageLine, Result: utils.JUnitPassed, Duration: result.elapsed}
+			switch result.status {
+			case "failed", "protected":
+				testCase.Result, testCase.Message = utils.JUnitFailed, result.err.Error()
+			case "aborted":
+				testCase.Result, testCase.Message = utils.JUnitSkipped, result.err.Error()
+			}
+			cases = append(cases, testCase)
+		}
+		for _, skipped := range passedOver {
+			cases = append(cases, utils.JUnitCase{ClassName: skipped.sso.ClassName, Package: skipped.sso.PackageLine, Result: utils.JUnitSkipped, Message: skipped.reason})
+		}
+		if compileResult != nil {
+			testCase := utils.JUnitCase{ClassName: filepath.Base(compileResult.JarPath), Package: "compile", Result: utils.JUnitPassed}
+			switch {
+			case compileResult.Skipped:
+				testCase.Result, testCase.Message = utils.JUnitSkipped, "some simplified SSOs could not be written"
+			case !compileResult.Succeeded:
+				testCase.Result, testCase.Message = utils.JUnitFailed, compileResult.Error
+			}
+			cases = append(cases, testCase)
+		}
+		if err := writeJUnitReport(o.junitReport, cases); err != nil {
+			errorf("Error writing JUnit report: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+		infof("JUnit report written to: %s\n", o.junitReport)
+	}
+
+	// Report the run to the program that invoked it
+	if summaryOut != nil {
+		summary := utils.RunSummary{
+			Counts:   utils.RunCounts{Scanned: lastProgress.FilesScanned, Matched: len(serverSideObjects), Skipped: skippedCollisions},
+			Classes:  []utils.ClassResult{},
+			Warnings: append([]utils.Warning{}, warnings...),
+			Timing:   utils.RunTiming{ScanMillis: scanElapsed.Milliseconds(), TotalMillis: time.Since(runStart).Milliseconds()},
+			Compile:  compileResult,
+		}
+		for i := range resolved {
+			outputFilePath := utils.SimplifiedSSOPath(o.outputPath, &resolved[i], writeOptions)
+			status := results[outputFilePath].status
+			switch status {
+			case "written":
+				summary.Counts.Written++
+			case "unchanged":
+				summary.Counts.Unchanged++
+			case "protected":
+				summary.Counts.Protected++
+			case "failed":
+				summary.Counts.Failed++
+			case "aborted":
+				summary.Counts.Aborted++
+			}
+			summary.Classes = append(summary.Classes, utils.ClassResult{ClassName: resolved[i].ClassName, Package: resolved[i].PackageLine, SourcePath: resolved[i].FilePath, OutputPath: outputFilePath, Status: status})
+		}
+		encoder := json.NewEncoder(summaryOut)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summary); err != nil {
+			errorf("Error writing JSON summary: %v\n", err)
+			return pipelineResult{status: exitWriteFailed}
+		}
+	}
 
-		fmt.Printf("Compiled .jar file created at: %s\n", compiledJarPath)
+	// Report the status of the first phase that failed, now that the reports describe the run
+	status := exitSuccess
+	switch {
+	case writeFailed:
+		status = exitWriteFailed
+	case compileResult != nil && !compileResult.Succeeded:
+		status = exitCompileFailed
+	case breakingChanges:
+		// Fail the run only after everything is written, so the outputs are there to inspect
+		errorf("Error: the API has breaking changes since the --diffAgainst baseline.")
+		status = exitBreakingChanges
 	}
+	return newPipelineResult(status, results, removedFiles, &parseCache)
 }
@@ -0,0 +1,101 @@
+package utils
+
+import "strings"
+
+// AccessorMode is whether simplified SSOs expose their instance fields through JavaBeans getters and setters.
+type AccessorMode string
+
+const (
+	AccessorsNone    AccessorMode = "none"    // Keep the fields only (the default)
+	AccessorsAdd     AccessorMode = "add"     // Keep the fields and add accessors for them
+	AccessorsReplace AccessorMode = "replace" // Replace the instance fields with accessors
+)
+
+// AddAccessors adds a getter and, unless the field is final, a setter for each instance field of the SSO and its
+// nested classes, following the JavaBeans naming conventions, and removes the instance fields under AccessorsReplace.
+// Static fields are left alone. An accessor whose signature matches a declared method is skipped with a warning,
+// since the declared method already provides it.
+func AddAccessors(sso *ServerSideObject, mode AccessorMode) []Warning {
+	if mode != AccessorsAdd && mode != AccessorsReplace {
+		return nil
+	}
+	return addAccessors(sso, mode, sso.FilePath)
+}
+
+// addAccessors adds the accessors of a class declared in the file at path, which nested classes do not record.
+func addAccessors(sso *ServerSideObject, mode AccessorMode, path string) []Warning {
+	var warnings []Warning
+	var kept []PublicField
+	var accessors []PublicMethod
+	for _, field := range sso.DeclaredFields {
+		if field.IsStatic {
+			kept = append(kept, field)
+			continue
+		}
+		if mode == AccessorsAdd {
+			kept = append(kept, field)
+		}
+
+		// Build the getter, and a setter for fields that can be assigned
+		fieldAccessors := []PublicMethod{{
+			AccessModifier: field.AccessModifier,
+			ReturnType:     field.Type,
+			MethodName:     getterName(field),
+			Parameters:     []Parameter{},
+			IsDeprecated:   field.IsDeprecated,
+		}}
+		if !field.IsFinal {
+			fieldAccessors = append(fieldAccessors, PublicMethod{
+				AccessModifier: field.AccessModifier,
+				ReturnType:     "void",
+				MethodName:     "set" + propertyName(field.Name),
+				Parameters:     []Parameter{{Type: field.Type, Name: field.Name}},
+				IsDeprecated:   field.IsDeprecated,
+			})
+		}
+
+		// Leave out accessors the class declares itself
+		for _, accessor := range fieldAccessors {
+			if declaresSignature(sso.DeclaredMethods, accessor) {
+				warnings = append(warnings, Warning{Path: path, Line: field.Line, Class: sso.ClassName, Member: accessor.MethodName, Reason: "accessor for field " + field.Name + " collides with a declared method, skipped", Rule: RuleAccessorCollision})
+				continue
+			}
+			accessors = append(accessors, accessor)
+		}
+	}
+	sso.DeclaredFields = kept
+	sso.DeclaredMethods = append(sso.DeclaredMethods, accessors...)
+
+	for i := range sso.NestedClasses {
+		warnings = append(warnings, addAccessors(&sso.NestedClasses[i], mode, path)...)
+	}
+	return warnings
+}
+
+// getterName returns the JavaBeans getter name of a field, which starts with is for primitive booleans.
+func getterName(field PublicField) string {
+	if field.Type == "boolean" {
+		return "is" + propertyName(field.Name)
+	}
+	return "get" + propertyName(field.Name)
+}
+
+// propertyName returns the field name as it appears in accessor names. The first letter is capitalized unless the
+// second one already is, so that fields such as URL or xValue keep their spelling, as the JavaBeans Introspector
+// expects.
+func propertyName(fieldName string) string {
+	if len(fieldName) > 1 && strings.ToUpper(fieldName[1:2]) == fieldName[1:2] && strings.ToLower(fieldName[1:2]) != fieldName[1:2] {
+		return fieldName
+	}
+	return strings.ToUpper(fieldName[:1]) + fieldName[1:]
+}
+
+// declaresSignature reports whether one of the methods has the signature of the given method.
+func declaresSignature(methods []PublicMethod, method PublicMethod) bool {
+	for _, declared := range methods {
+		if methodSignature(declared) == methodSignature(method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// allowedTypeNamePattern matches a simple or package-qualified Java type name
+var allowedTypeNamePattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(?:\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// ParseAllowedType parses an allowed type entry of the form TypeName=defaultReturnExpr, such as "BigDecimal=null".
+// An entry without a default, with or without the equals sign, defaults to null.
+func ParseAllowedType(entry string) (typeName string, defaultValue string, err error) {
+	typeName, defaultValue, _ = strings.Cut(entry, "=")
+	typeName = strings.TrimSpace(typeName)
+	defaultValue = strings.TrimSpace(defaultValue)
+	if !allowedTypeNamePattern.MatchString(typeName) {
+		return "", "", fmt.Errorf("invalid allowed type %q: expected TypeName or TypeName=defaultReturnExpr", entry)
+	}
+	if defaultValue == "" {
+		defaultValue = "null"
+	}
+	return typeName, defaultValue, nil
+}
+
+// LoadAllowedTypes reads allowed type entries from a file, one TypeName=defaultReturnExpr entry per line. Blank
+// lines and lines starting with # are ignored.
+func LoadAllowedTypes(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		typeName, defaultValue, err := ParseAllowedType(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		types[typeName] = defaultValue
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// APIDiff lists the differences between the public API of two sets of SSOs, such as two scans or a scan and a
+// manifest. Classes are identified by their qualified name, with nested classes written Outer.Inner, methods by their
+// name and parameter types, so overloads are told apart, and fields by their name.
+type APIDiff struct {
+	AddedClasses   []string    `json:"addedClasses"`   // The classes only in the new set
+	RemovedClasses []string    `json:"removedClasses"` // The classes only in the old set
+	ChangedClasses []ClassDiff `json:"changedClasses"` // The classes in both sets whose members differ
+}
+
+// ClassDiff lists the differences between the members of a class in two sets of SSOs.
+type ClassDiff struct {
+	ClassName      string   `json:"className"`      // The qualified name of the class
+	AddedMethods   []string `json:"addedMethods"`   // The signatures of the methods only in the new class
+	RemovedMethods []string `json:"removedMethods"` // The signatures of the methods only in the old class
+	ChangedMethods []string `json:"changedMethods"` // The methods whose return type, parameters, or modifiers changed
+	AddedFields    []string `json:"addedFields"`    // The declarations of the fields only in the new class
+	RemovedFields  []string `json:"removedFields"`  // The declarations of the fields only in the old class
+	ChangedFields  []string `json:"changedFields"`  // The fields whose type or modifiers changed
+}
+
+// IsBreaking reports whether the diff removes or changes any part of the API that consumers may rely on.
+func (d APIDiff) IsBreaking() bool {
+	if len(d.RemovedClasses) > 0 {
+		return true
+	}
+	for _, class := range d.ChangedClasses {
+		if len(class.RemovedMethods) > 0 || len(class.ChangedMethods) > 0 || len(class.RemovedFields) > 0 || len(class.ChangedFields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether the two sets of SSOs have the same API.
+func (d APIDiff) IsEmpty() bool {
+	return len(d.AddedClasses) == 0 && len(d.RemovedClasses) == 0 && len(d.ChangedClasses) == 0
+}
+
+// String formats the diff as a human-readable report, one change per line.
+func (d APIDiff) String() string {
+	if d.IsEmpty() {
+		return "No API changes.\n"
+	}
+	var builder strings.Builder
+	for _, class := range d.AddedClasses {
+		builder.WriteString("+ class " + class + "\n")
+	}
+	for _, class := range d.RemovedClasses {
+		builder.WriteString("- class " + class + "\n")
+	}
+	for _, class := range d.ChangedClasses {
+		builder.WriteString("~ class " + class.ClassName + "\n")
+		for _, lines := range []struct {
+			marker  string
+			changes []string
+		}{
+			{"+ ", class.AddedMethods}, {"- ", class.RemovedMethods}, {"~ ", class.ChangedMethods},
+			{"+ ", class.AddedFields}, {"- ", class.RemovedFields}, {"~ ", class.ChangedFields},
+		} {
+			for _, change := range lines.changes {
+				builder.WriteString("    " + lines.marker + change + "\n")
+			}
+		}
+	}
+	return builder.String()
+}
+
+// DiffAPIs compares the public API of an old and a new set of SSOs, including their nested classes.
+func DiffAPIs(oldSSOs []ServerSideObject, newSSOs []ServerSideObject) APIDiff {
+	oldClasses, newClasses := indexAPIClasses(oldSSOs), indexAPIClasses(newSSOs)
+
+	var diff APIDiff
+	for _, name := range sortedKeys(newClasses) {
+		if _, ok := oldClasses[name]; !ok {
+			diff.AddedClasses = append(diff.AddedClasses, name)
+		}
+	}
+	for _, name := range sortedKeys(oldClasses) {
+		newClass, ok := newClasses[name]
+		if !ok {
+			diff.RemovedClasses = append(diff.RemovedClasses, name)
+			continue
+		}
+		classDiff := diffClass(name, oldClasses[name], newClass)
+		if len(classDiff.AddedMethods)+len(classDiff.RemovedMethods)+len(classDiff.ChangedMethods)+len(classDiff.AddedFields)+len(classDiff.RemovedFields)+len(classDiff.ChangedFields) > 0 {
+			diff.ChangedClasses = append(diff.ChangedClasses, classDiff)
+		}
+	}
+	return diff
+}
+
+// indexAPIClasses maps the qualified names of the SSOs and their nested classes to their declarations. When several
+// SSOs share a qualified name, the first one wins.
+func indexAPIClasses(ssos []ServerSideObject) map[string]*ServerSideObject {
+	index := make(map[string]*ServerSideObject)
+	var add func(sso *ServerSideObject, name string)
+	add = func(sso *ServerSideObject, name string) {
+		if _, ok := index[name]; !ok {
+			index[name] = sso
+		}
+		for i := range sso.NestedClasses {
+			add(&sso.NestedClasses[i], name+"."+sso.NestedClasses[i].ClassName)
+		}
+	}
+	for i := range ssos {
+		add(&ssos[i], qualifiedName(ssos[i].PackageLine, ssos[i].ClassName))
+	}
+	return index
+}
+
+// diffClass compares the methods and fields of a class in the old and new sets. A method whose only overload has
+// different parameters on each side is reported as changed rather than as removed and added.
+func diffClass(name string, oldClass *ServerSideObject, newClass *ServerSideObject) ClassDiff {
+	diff := ClassDiff{ClassName: name}
+
+	// Match methods by signature, so that overloads are compared with their counterparts
+	oldMethods, newMethods := indexMethods(oldClass.DeclaredMethods), indexMethods(newClass.DeclaredMethods)
+	var removed, added []PublicMethod
+	for _, signature := range sortedKeys(oldMethods) {
+		oldMethod := oldMethods[signature]
+		newMethod, ok := newMethods[signature]
+		if !ok {
+			removed = append(removed, oldMethod)
+		} else if methodShape(oldMethod) != methodShape(newMethod) {
+			diff.ChangedMethods = append(diff.ChangedMethods, describeMethod(oldMethod)+" -> "+describeMethod(newMethod))
+		}
+	}
+	for _, signature := range sortedKeys(newMethods) {
+		if _, ok := oldMethods[signature]; !ok {
+			added = append(added, newMethods[signature])
+		}
+	}
+	for _, oldMethod := range removed {
+		if i := slices.IndexFunc(added, func(m PublicMethod) bool { return m.MethodName == oldMethod.MethodName }); i != -1 &&
+			countOverloads(oldClass.DeclaredMethods, oldMethod.MethodName) == 1 && countOverloads(newClass.DeclaredMethods, oldMethod.MethodName) == 1 {
+			diff.ChangedMethods = append(diff.ChangedMethods, describeMethod(oldMethod)+" -> "+describeMethod(added[i]))
+			added = slices.Delete(added, i, i+1)
+			continue
+		}
+		diff.RemovedMethods = append(diff.RemovedMethods, describeMethod(oldMethod))
+	}
+	for _, newMethod := range added {
+		diff.AddedMethods = append(diff.AddedMethods, describeMethod(newMethod))
+	}
+
+	// Match fields by name
+	oldFields, newFields := indexFields(oldClass.DeclaredFields), indexFields(newClass.DeclaredFields)
+	for _, fieldName := range sortedKeys(oldFields) {
+		newField, ok := newFields[fieldName]
+		if !ok {
+			diff.RemovedFields = append(diff.RemovedFields, describeField(oldFields[fieldName]))
+		} else if describeField(oldFields[fieldName]) != describeField(newField) {
+			diff.ChangedFields = append(diff.ChangedFields, describeField(oldFields[fieldName])+" -> "+describeField(newField))
+		}
+	}
+	for _, fieldName := range sortedKeys(newFields) {
+		if _, ok := oldFields[fieldName]; !ok {
+			diff.AddedFields = append(diff.AddedFields, describeField(newFields[fieldName]))
+		}
+	}
+	return diff
+}
+
+// indexMethods maps the signatures of the methods to the methods.
+func indexMethods(methods []PublicMethod) map[string]PublicMethod {
+	index := make(map[string]PublicMethod, len(methods))
+	for _, method := range methods {
+		index[methodSignature(method)] = method
+	}
+	return index
+}
+
+// indexFields maps the names of the fields to the fields.
+func indexFields(fields []PublicField) map[string]PublicField {
+	index := make(map[string]PublicField, len(fields))
+	for _, field := range fields {
+		index[field.Name] = field
+	}
+	return index
+}
+
+// countOverloads returns the number of methods with the given name.
+func countOverloads(methods []PublicMethod, name string) int {
+	count := 0
+	for _, method := range methods {
+		if method.MethodName == name {
+			count++
+		}
+	}
+	return count
+}
+
+// describeMethod renders the parts of a method declaration that consumers compile against.
+func describeMethod(method PublicMethod) string {
+	return fmt.Sprintf("%s %s%s %s(%s)", accessModifier(method.AccessModifier), memberModifiers(method.IsStatic, method.IsFinal), method.ReturnType, method.MethodName, joinParameters(method.Parameters))
+}
+
+// methodShape renders a method declaration without its parameter names, which can change without affecting consumers.
+func methodShape(method PublicMethod) string {
+	unnamed := method
+	unnamed.Parameters = make([]Parameter, len(method.Parameters))
+	for i, param := range method.Parameters {
+		unnamed.Parameters[i] = Parameter{Type: param.Type, IsVarargs: param.IsVarargs}
+	}
+	return describeMethod(unnamed)
+}
+
+// describeField renders the parts of a field declaration that consumers compile against.
+func describeField(field PublicField) string {
+	return fmt.Sprintf("%s %s%s %s", accessModifier(field.AccessModifier), memberModifiers(field.IsStatic, field.IsFinal), field.Type, field.Name)
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
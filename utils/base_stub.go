@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BaseClassStubs returns a stub for each base class that the SSOs extend directly, declaring the public methods the
+// SSOs inherit from it, so that the simplified SSOs compile without the real base class. Each base class gets a single
+// stub however many SSOs extend it. A stub is placed in the package the base class was imported from or qualified
+// with, and otherwise in the package of the extending SSO. A base class extended with type arguments, such as
+// ServerSideObject<Payload>, gets as many type parameters. Write the stubs with WriteSimplifiedSSO.
+func BaseClassStubs(ssos []ServerSideObject) ServerSideObjectList {
+	var stubs ServerSideObjectList
+	seen := make(map[string]int)    // The index of the stub of each base class, by qualified name
+	arities := make(map[string]int) // The number of type parameters of each stub, by qualified name
+	for _, sso := range ssos {
+		superName := rawTypeName(sso.SuperClass)
+		if sso.BaseClass == "" || simpleTypeName(superName) != sso.BaseClass {
+			continue // Interface SSOs and SSOs of a public intermediate class do not extend the base class directly
+		}
+
+		// Work out the package of the base class from its spelling, its import, or the package of the SSO
+		qualified := superName
+		if !strings.Contains(qualified, ".") {
+			qualified = sso.SuperClassImport
+		}
+		if qualified == "" {
+			qualified = qualifiedName(sso.PackageLine, superName)
+		}
+		// Declare as many type parameters as any SSO passes type arguments, so every generic extends clause compiles
+		arity := typeArgumentCount(sso.SuperClass)
+		if i, ok := seen[qualified]; ok {
+			if arity > arities[qualified] {
+				stubs[i].TypeParameters = stubTypeParameters(arity)
+				arities[qualified] = arity
+			}
+			continue
+		}
+		seen[qualified] = len(stubs)
+		arities[qualified] = arity
+
+		packageLine := ""
+		if idx := strings.LastIndex(qualified, "."); idx != -1 {
+			packageLine = qualified[:idx]
+		}
+		stubs = append(stubs, ServerSideObject{
+			ClassName:       sso.BaseClass,
+			TypeParameters:  stubTypeParameters(arity),
+			IsAbstract:      true,
+			PackageLine:     packageLine,
+			DeclaredMethods: superclassMethodsFor(sso.BaseClass),
+		})
+	}
+	return stubs
+}
+
+// RelocateBaseClass makes an SSO that extends its base class directly import the base class from the given package
+// instead, for base class stubs placed there. Any qualifier on the superclass is dropped in favour of the import.
+func RelocateBaseClass(sso *ServerSideObject, packageLine string) {
+	superName := rawTypeName(sso.SuperClass)
+	if sso.BaseClass == "" || simpleTypeName(superName) != sso.BaseClass {
+		return
+	}
+	sso.SuperClass = sso.BaseClass + strings.TrimPrefix(sso.SuperClass, superName)
+	sso.SuperClassImport = qualifiedName(packageLine, sso.BaseClass)
+}
+
+// typeArgumentCount returns the number of type arguments a type is given, such as 2 for Map<String, List<Long>>.
+func typeArgumentCount(typeName string) int {
+	start, end := strings.Index(typeName, "<"), strings.LastIndex(typeName, ">")
+	if start == -1 || end < start {
+		return 0
+	}
+	return len(splitTopLevel(typeName[start+1:end], ','))
+}
+
+// stubTypeParameters returns a type parameter list of the given length for a base class stub, T for a single one and
+// T1, T2, and so on otherwise, or nothing for none.
+func stubTypeParameters(count int) string {
+	switch count {
+	case 0:
+		return ""
+	case 1:
+		return "<T>"
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("T%d", i+1)
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ChecksumsFileName is the name of the checksums file WriteChecksums writes into the output directory.
+const ChecksumsFileName = "SHA256SUMS"
+
+// ChecksumMismatch is a file listed in a checksums file that no longer matches it.
+type ChecksumMismatch struct {
+	Path   string // The path of the file as listed, relative to the output directory
+	Reason string // Why the file does not match, such as "missing" or "checksum mismatch"
+}
+
+// fileChecksum returns the hex-encoded SHA-256 of a file.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WriteChecksums writes ChecksumsFileName to the output directory, listing the SHA-256 of each file in the format of
+// sha256sum, so that `sha256sum -c` can check it too. Paths are relative to the output directory and use forward
+// slashes on every OS, and are in order so that the file changes only where the outputs do. It returns the path of
+// the checksums file.
+func WriteChecksums(outputDir string, paths []string) (string, error) {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		relative, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return "", fmt.Errorf("checksumming %s: %w", path, err)
+		}
+		relative = filepath.ToSlash(relative)
+		if seen[relative] {
+			continue
+		}
+		seen[relative] = true
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return "", fmt.Errorf("checksumming %s: %w", path, err)
+		}
+		lines = append(lines, checksum+"  "+relative+"\n")
+	}
+	slices.SortFunc(lines, func(a, b string) int { return strings.Compare(a[sha256.Size*2+2:], b[sha256.Size*2+2:]) })
+
+	checksumsPath := filepath.Join(outputDir, ChecksumsFileName)
+	return checksumsPath, writeFileAtomically(checksumsPath, strings.Join(lines, ""))
+}
+
+// VerifyChecksums checks the files listed in a checksums file against the output directory, returning those that
+// are missing or whose content changed, in the order they are listed. Lines that are not in the sha256sum format are
+// an error, as is a checksums file that cannot be read.
+func VerifyChecksums(outputDir string, checksumsPath string) ([]ChecksumMismatch, error) {
+	file, err := os.Open(checksumsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mismatches []ChecksumMismatch
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		// Accept the binary-mode marker sha256sum writes with -b, as in "<checksum> *<path>"
+		expected, path, ok := strings.Cut(line, " ")
+		path = strings.TrimPrefix(strings.TrimPrefix(path, " "), "*")
+		if !ok || len(expected) != sha256.Size*2 || path == "" {
+			return nil, fmt.Errorf("%s:%d: expected a checksum and a path", checksumsPath, lineNumber)
+		}
+		actual, err := fileChecksum(filepath.Join(outputDir, filepath.FromSlash(path)))
+		switch {
+		case os.IsNotExist(err):
+			mismatches = append(mismatches, ChecksumMismatch{Path: path, Reason: "missing"})
+		case err != nil:
+			mismatches = append(mismatches, ChecksumMismatch{Path: path, Reason: err.Error()})
+		case !strings.EqualFold(actual, expected):
+			mismatches = append(mismatches, ChecksumMismatch{Path: path, Reason: "checksum mismatch"})
+		}
+	}
+	return mismatches, scanner.Err()
+}
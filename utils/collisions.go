@@ -0,0 +1,47 @@
+package utils
+
+import "fmt"
+
+// Collision describes two SSOs whose simplified files would be written to the same output path.
+type Collision struct {
+	OutputPath string            // The path both simplified files would be written to
+	Kept       *ServerSideObject // The SSO that sorts first and keeps the path
+	Colliding  *ServerSideObject // The SSO that would overwrite it
+}
+
+// FindCollisions returns every SSO in the list whose simplified file would overwrite the file of an earlier SSO.
+func FindCollisions(serverSideObjects ServerSideObjectList, outputDir string, opts WriteOptions) []Collision {
+	var collisions []Collision
+	owners := make(map[string]*ServerSideObject)
+	for i := range serverSideObjects {
+		sso := &serverSideObjects[i]
+		outputPath := SimplifiedSSOPath(outputDir, sso, opts)
+		if kept, ok := owners[outputPath]; ok {
+			collisions = append(collisions, Collision{OutputPath: outputPath, Kept: kept, Colliding: sso})
+			continue
+		}
+		owners[outputPath] = sso
+	}
+	return collisions
+}
+
+// RenameCollisions gives every colliding SSO a numbered class name suffix (Foo_2, Foo_3, ...) so that its
+// simplified file no longer overwrites another one, and returns the collisions that were resolved.
+func RenameCollisions(serverSideObjects ServerSideObjectList, outputDir string, opts WriteOptions) []Collision {
+	collisions := FindCollisions(serverSideObjects, outputDir, opts)
+	taken := make(map[string]bool)
+	for i := range serverSideObjects {
+		taken[SimplifiedSSOPath(outputDir, &serverSideObjects[i], opts)] = true
+	}
+	for _, collision := range collisions {
+		baseName := collision.Colliding.ClassName
+		for n := 2; ; n++ {
+			collision.Colliding.ClassName = fmt.Sprintf("%s_%d", baseName, n)
+			if outputPath := SimplifiedSSOPath(outputDir, collision.Colliding, opts); !taken[outputPath] {
+				taken[outputPath] = true
+				break
+			}
+		}
+	}
+	return collisions
+}
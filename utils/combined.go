@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CombinedSSOsFileName is the name of the digest UpdateCombinedSSOs writes into the output directory.
+const CombinedSSOsFileName = "AllSSOs.md"
+
+// RenderCombinedSSOs returns a Markdown digest of the simplified source of every SSO, grouped by package, for review
+// in a single file. Java allows only one public top-level class per file, so the digest is not meant to compile.
+// The generator is named once at the top rather than in the header of every class.
+func RenderCombinedSSOs(ssos []ServerSideObject, opts WriteOptions) (string, error) {
+	// Render each class without a file header, and apply the line endings to the digest as a whole
+	classOpts := opts
+	classOpts.Generator, classOpts.License, classOpts.LineEnding = "", "", LineEndingLF
+
+	sorted := slices.Clone(ssos)
+	slices.SortStableFunc(sorted, func(a, b ServerSideObject) int { return cmp.Compare(a.PackageLine, b.PackageLine) })
+
+	var builder strings.Builder
+	builder.WriteString(renderLicense(opts))
+	builder.WriteString("# Simplified SSOs\n\n")
+	if opts.Generator != "" {
+		builder.WriteString("Generated by " + opts.Generator + ". Do not edit.\n\n")
+	}
+	for i := range sorted {
+		sso := &sorted[i]
+		if i == 0 || sso.PackageLine != sorted[i-1].PackageLine {
+			packageName := sso.PackageLine
+			if packageName == "" {
+				packageName = "(default package)"
+			}
+			builder.WriteString("## " + packageName + "\n\n")
+		}
+		rendered, err := RenderSimplifiedSSO(sso, classOpts)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString("### " + sso.ClassName + "\n\n")
+		if sso.FilePath != "" {
+			builder.WriteString("Source: `" + filepath.ToSlash(sso.FilePath) + "`\n\n")
+		}
+		builder.WriteString("```java\n" + strings.TrimRight(rendered, "\n") + "\n```\n\n")
+	}
+
+	digest := strings.TrimRight(builder.String(), "\n") + "\n"
+	if terminator := opts.LineEnding.terminator(); terminator != "\n" {
+		digest = strings.ReplaceAll(digest, "\n", terminator)
+	}
+	return digest, nil
+}
+
+// UpdateCombinedSSOs writes the digest of RenderCombinedSSOs to CombinedSSOsFileName in the output directory unless
+// it already holds the same content, reporting whether it was written; Touch writes it regardless.
+func UpdateCombinedSSOs(outputDir string, ssos []ServerSideObject, opts WriteOptions) (bool, error) {
+	path := filepath.Join(outputDir, CombinedSSOsFileName)
+	digest, err := RenderCombinedSSOs(ssos, opts)
+	if err != nil {
+		return false, err
+	}
+	if existing, err := os.ReadFile(path); err == nil && !opts.Touch && string(existing) == digest {
+		return false, nil
+	}
+	if err := writeFileAtomically(path, digest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
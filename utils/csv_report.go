@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SkippedMethods returns the warnings about the public methods of the class or its merged superclasses that were
+// left out of its stub, one or more per method.
+func (sso *ServerSideObject) SkippedMethods() []Warning {
+	var skipped []Warning
+	for _, warning := range sso.warnings {
+		if warning.SkippedMethod {
+			skipped = append(skipped, warning)
+		}
+	}
+	return skipped
+}
+
+// WriteCSVSummary writes a header row and then one row per SSO, with its class name, package, source path, the
+// number of methods and fields in its stub, the number of public methods skipped, and the status reported for it,
+// such as whether its simplified file was written.
+func WriteCSVSummary(w io.Writer, ssos []ServerSideObject, status func(sso *ServerSideObject) string) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"class", "package", "source", "methods", "fields", "skippedMethods", "status"})
+	for i := range ssos {
+		sso := &ssos[i]
+		writer.Write([]string{
+			sso.ClassName,
+			sso.PackageLine,
+			filepath.ToSlash(sso.FilePath),
+			strconv.Itoa(len(sso.DeclaredMethods)),
+			strconv.Itoa(len(sso.DeclaredFields)),
+			strconv.Itoa(CountSkippedMethods(sso.warnings)),
+			status(sso),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCSVMethods writes a header row and then one row per public method of each SSO, both those in its stub and
+// those skipped, with the reason a method was skipped. Methods of nested classes name the class as Outer.Inner.
+func WriteCSVMethods(w io.Writer, ssos []ServerSideObject, status func(sso *ServerSideObject) string) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"class", "package", "source", "line", "method", "returnType", "parameters", "modifiers", "kept", "reason", "status"})
+	for i := range ssos {
+		sso := &ssos[i]
+		source := filepath.ToSlash(sso.FilePath)
+		ssoStatus := status(sso)
+
+		// List the methods in the stub, including those of nested classes
+		var writeMethods func(class *ServerSideObject, name string)
+		writeMethods = func(class *ServerSideObject, name string) {
+			for _, method := range class.DeclaredMethods {
+				modifiers := strings.TrimSpace(memberModifiers(method.IsStatic, method.IsFinal))
+				if method.IsDeprecated {
+					modifiers = strings.TrimSpace(modifiers + " deprecated")
+				}
+				writer.Write([]string{name, sso.PackageLine, source, strconv.Itoa(method.Line), method.MethodName, method.ReturnType, joinParameters(method.Parameters), modifiers, "true", "", ssoStatus})
+			}
+			for j := range class.NestedClasses {
+				writeMethods(&class.NestedClasses[j], name+"."+class.NestedClasses[j].ClassName)
+			}
+		}
+		writeMethods(sso, sso.ClassName)
+
+		// List the skipped methods once each, with every reason they were skipped for
+		var order []Warning
+		reasons := make(map[Warning][]string)
+		for _, warning := range sso.SkippedMethods() {
+			key := Warning{Path: warning.Path, Line: warning.Line, Class: warning.Class, Member: warning.Member}
+			if _, ok := reasons[key]; !ok {
+				order = append(order, key)
+			}
+			reasons[key] = append(reasons[key], warning.Reason)
+		}
+		for _, key := range order {
+			writer.Write([]string{key.Class, sso.PackageLine, filepath.ToSlash(key.Path), strconv.Itoa(key.Line), key.Member, "", "", "", "false", strings.Join(reasons[key], "; "), ssoStatus})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
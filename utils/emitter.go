@@ -0,0 +1,86 @@
+package utils
+
+import "strings"
+
+// BraceStyle is where the opening brace of a class or method body is placed in the simplified output.
+type BraceStyle string
+
+const (
+	BraceSameLine BraceStyle = "same-line" // The opening brace ends the declaration line (the default)
+	BraceNextLine BraceStyle = "next-line" // The opening brace goes on its own line below the declaration
+)
+
+// defaultIndentWidth is the number of spaces per level of nesting unless WriteOptions says otherwise.
+const defaultIndentWidth = 4
+
+// javaEmitter builds Java source line by line, applying the indentation and brace style of the WriteOptions.
+type javaEmitter struct {
+	builder strings.Builder
+	unit    string     // The indentation added for each level of nesting
+	braces  BraceStyle // Where opening braces are placed
+	depth   int        // The current level of nesting
+}
+
+// newJavaEmitter returns an emitter at the top level with the indentation and brace style of the options.
+func newJavaEmitter(opts WriteOptions) *javaEmitter {
+	unit := "\t"
+	if !opts.UseTabs {
+		width := opts.IndentWidth
+		if width <= 0 {
+			width = defaultIndentWidth
+		}
+		unit = strings.Repeat(" ", width)
+	}
+	braces := opts.BraceStyle
+	if braces == "" {
+		braces = BraceSameLine
+	}
+	return &javaEmitter{unit: unit, braces: braces}
+}
+
+// line writes a line of text at the current indentation. Empty lines are written without indentation.
+func (e *javaEmitter) line(text string) {
+	if text != "" {
+		e.builder.WriteString(strings.Repeat(e.unit, e.depth))
+	}
+	e.builder.WriteString(text + "\n")
+}
+
+// lines writes each line of a block of text, such as a comment, at the current indentation.
+func (e *javaEmitter) lines(block string) {
+	for _, text := range strings.Split(block, "\n") {
+		e.line(text)
+	}
+}
+
+// open writes a declaration followed by the opening brace of its body and indents the lines that follow.
+func (e *javaEmitter) open(declaration string) {
+	if e.braces == BraceNextLine {
+		e.line(declaration)
+		e.line("{")
+	} else {
+		e.line(declaration + " {")
+	}
+	e.depth++
+}
+
+// close outdents and writes the closing brace of the body opened last.
+func (e *javaEmitter) close() {
+	e.depth--
+	e.line("}")
+}
+
+// empty writes a declaration with an empty body.
+func (e *javaEmitter) empty(declaration string) {
+	if e.braces == BraceNextLine {
+		e.open(declaration)
+		e.close()
+		return
+	}
+	e.line(declaration + " {}")
+}
+
+// String returns the source written so far.
+func (e *javaEmitter) String() string {
+	return e.builder.String()
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// sourceDecoder decodes the raw bytes of a source file into a string, reporting whether every byte sequence was
+// valid in the encoding. Invalid sequences are decoded as U+FFFD.
+type sourceDecoder func(content []byte) (string, bool)
+
+// sourceEncodings maps the supported encoding names, and their common aliases, to their decoders.
+var sourceEncodings = map[string]sourceDecoder{
+	"utf-8":        decodeUTF8,
+	"utf8":         decodeUTF8,
+	"iso-8859-1":   decodeLatin1,
+	"iso8859-1":    decodeLatin1,
+	"latin1":       decodeLatin1,
+	"windows-1252": decodeWindows1252,
+	"cp1252":       decodeWindows1252,
+}
+
+// windows1252High maps the bytes 0x80-0x9F of windows-1252, where it differs from ISO-8859-1. Zero entries are
+// bytes the encoding leaves undefined.
+var windows1252High = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// lookupSourceEncoding returns the decoder for an encoding name, defaulting to UTF-8 for an empty name.
+func lookupSourceEncoding(name string) (sourceDecoder, error) {
+	if name == "" {
+		return decodeUTF8, nil
+	}
+	decode, ok := sourceEncodings[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source encoding %q, expected utf-8, iso-8859-1, or windows-1252", name)
+	}
+	return decode, nil
+}
+
+// decodeUTF8 checks that the content is valid UTF-8.
+func decodeUTF8(content []byte) (string, bool) {
+	if utf8.Valid(content) {
+		return string(content), true
+	}
+	return strings.ToValidUTF8(string(content), "�"), false
+}
+
+// decodeLatin1 decodes ISO-8859-1, where every byte is the code point of the same value.
+func decodeLatin1(content []byte) (string, bool) {
+	var decoded strings.Builder
+	decoded.Grow(len(content))
+	for _, b := range content {
+		decoded.WriteRune(rune(b))
+	}
+	return decoded.String(), true
+}
+
+// decodeWindows1252 decodes windows-1252, which is ISO-8859-1 with printable characters in place of most of the
+// 0x80-0x9F control codes.
+func decodeWindows1252(content []byte) (string, bool) {
+	var decoded strings.Builder
+	decoded.Grow(len(content))
+	valid := true
+	for _, b := range content {
+		r := rune(b)
+		if b >= 0x80 && b <= 0x9F {
+			r = windows1252High[b-0x80]
+			if r == 0 {
+				r = utf8.RuneError
+				valid = false
+			}
+		}
+		decoded.WriteRune(r)
+	}
+	return decoded.String(), valid
+}
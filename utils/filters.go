@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SSOFilter selects SSOs by regular expressions over their class and package names. An SSO matches when it
+// matches every include pattern that is set and none of the exclude patterns, so excludes win over includes.
+type SSOFilter struct {
+	IncludeClass   *regexp.Regexp // Keep only SSOs whose class name matches, when set
+	ExcludeClass   *regexp.Regexp // Drop SSOs whose class name matches, when set
+	IncludePackage *regexp.Regexp // Keep only SSOs whose package matches, when set
+	ExcludePackage *regexp.Regexp // Drop SSOs whose package matches, when set
+}
+
+// NewSSOFilter compiles the include and exclude patterns of an SSOFilter. Empty patterns are left unset.
+func NewSSOFilter(includeClass string, excludeClass string, includePackage string, excludePackage string) (*SSOFilter, error) {
+	filter := &SSOFilter{}
+	patterns := []struct {
+		name    string
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{"includeClass", includeClass, &filter.IncludeClass},
+		{"excludeClass", excludeClass, &filter.ExcludeClass},
+		{"includePackage", includePackage, &filter.IncludePackage},
+		{"excludePackage", excludePackage, &filter.ExcludePackage},
+	}
+	for _, p := range patterns {
+		if p.pattern == "" {
+			continue
+		}
+		compiled, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", p.name, p.pattern, err)
+		}
+		*p.target = compiled
+	}
+	return filter, nil
+}
+
+// Matches reports whether an SSO passes the filter.
+func (f *SSOFilter) Matches(sso *ServerSideObject) bool {
+	if f.ExcludeClass != nil && f.ExcludeClass.MatchString(sso.ClassName) {
+		return false
+	}
+	if f.ExcludePackage != nil && f.ExcludePackage.MatchString(sso.PackageLine) {
+		return false
+	}
+	if f.IncludeClass != nil && !f.IncludeClass.MatchString(sso.ClassName) {
+		return false
+	}
+	if f.IncludePackage != nil && !f.IncludePackage.MatchString(sso.PackageLine) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the SSOs that pass the filter, in their original order.
+func (f *SSOFilter) Apply(serverSideObjects ServerSideObjectList) ServerSideObjectList {
+	var matched ServerSideObjectList
+	for i := range serverSideObjects {
+		if f.Matches(&serverSideObjects[i]) {
+			matched = append(matched, serverSideObjects[i])
+		}
+	}
+	return matched
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitignoreRule is a single pattern line from a .gitignore file.
+type gitignoreRule struct {
+	base     string // Directory of the .gitignore file, relative to the repository root ("" for the root)
+	pattern  string // The glob pattern, without its negation, anchoring, or directory markers
+	negate   bool   // Whether the pattern re-includes paths ignored by earlier patterns ("!pattern")
+	dirOnly  bool   // Whether the pattern only matches directories ("pattern/")
+	anchored bool   // Whether the pattern is matched against the whole path below base rather than any trailing part
+}
+
+// gitignore holds the .gitignore rules that apply to a scan, in the order git gives them precedence: rules from
+// deeper directories come after their parents', and later rules win over earlier ones.
+type gitignore struct {
+	rootPrefix string // Path of the scan root relative to the repository root ("" when they are the same)
+	rules      []gitignoreRule
+}
+
+// loadAncestorGitignores finds the repository containing the directory, by looking for a .git entry in it and
+// its parents, and loads the .gitignore files of the directories between the repository root and the directory.
+// The directory's own .gitignore is left to the walk. Outside a repository only the walk's files apply.
+func loadAncestorGitignores(directory string) (*gitignore, error) {
+	absolute, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the repository root
+	repoRoot := ""
+	for dir := absolute; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			repoRoot = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			return &gitignore{}, nil
+		}
+	}
+
+	relative, err := filepath.Rel(repoRoot, absolute)
+	if err != nil {
+		return nil, err
+	}
+	ignore := &gitignore{rootPrefix: filepath.ToSlash(relative)}
+	if ignore.rootPrefix == "." {
+		ignore.rootPrefix = ""
+		return ignore, nil
+	}
+
+	// Load the .gitignore files from the repository root down to, but not including, the directory
+	base := ""
+	for _, segment := range append([]string{""}, strings.Split(ignore.rootPrefix, "/")...) {
+		base = path.Join(base, segment)
+		if base == ignore.rootPrefix {
+			break
+		}
+		content, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(base), ".gitignore"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ignore.rules = append(ignore.rules, parseGitignore(base, content)...)
+	}
+	return ignore, nil
+}
+
+// load adds the rules of the .gitignore file in a directory of the scanned filesystem, if there is one.
+// relativeDir is the directory's path relative to the scan root.
+func (g *gitignore) load(fsys fs.FS, dir string, relativeDir string) error {
+	content, err := fs.ReadFile(fsys, path.Join(dir, ".gitignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	g.rules = append(g.rules, parseGitignore(g.repoPath(relativeDir), content)...)
+	return nil
+}
+
+// ignored reports whether a path relative to the scan root is ignored by the loaded rules.
+func (g *gitignore) ignored(relativePath string, isDir bool) bool {
+	repoPath := g.repoPath(relativePath)
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.matches(repoPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// repoPath converts a path relative to the scan root into a path relative to the repository root.
+func (g *gitignore) repoPath(relativePath string) string {
+	if relativePath == "." {
+		relativePath = ""
+	}
+	return path.Join(g.rootPrefix, relativePath)
+}
+
+// matches reports whether the rule matches a path relative to the repository root.
+func (rule gitignoreRule) matches(repoPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+
+	// Rules only apply below the directory of their .gitignore file
+	relativePath := repoPath
+	if rule.base != "" {
+		if !strings.HasPrefix(repoPath, rule.base+"/") {
+			return false
+		}
+		relativePath = strings.TrimPrefix(repoPath, rule.base+"/")
+	}
+
+	pattern := rule.pattern
+	if !rule.anchored {
+		pattern = "**/" + pattern
+	}
+	if !matchGlob(pattern, relativePath) {
+		return false
+	}
+
+	// A trailing "/**" matches everything inside a directory, but not the directory itself
+	if strings.HasSuffix(pattern, "/**") && matchGlob(strings.TrimSuffix(pattern, "/**"), relativePath) {
+		return false
+	}
+	return true
+}
+
+// parseGitignore parses the content of a .gitignore file in the directory base, relative to the repository root.
+// Invalid patterns are skipped, as git does.
+func parseGitignore(base string, content []byte) []gitignoreRule {
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		// Trailing spaces are ignored unless escaped with a backslash
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+
+		// Skip blank lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := gitignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// A slash at the start or in the middle anchors the pattern to the directory of the .gitignore file
+		if strings.HasPrefix(line, "/") {
+			rule.anchored = true
+			line = line[1:]
+		} else if strings.Contains(line, "/") {
+			rule.anchored = true
+		}
+
+		if line == "" || validateGlob(line) != nil {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGitignoreIgnored(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{name: "file name anywhere", content: "*.bak", path: "a/b/Foo.bak", want: true},
+		{name: "other extension", content: "*.bak", path: "a/b/Foo.java", want: false},
+		{name: "directory only matches directories", content: "build/", path: "build", isDir: false, want: false},
+		{name: "directory only", content: "build/", path: "a/build", isDir: true, want: true},
+		{name: "anchored at the root", content: "/out", path: "out", isDir: true, want: true},
+		{name: "anchored not below the root", content: "/out", path: "a/out", isDir: true, want: false},
+		{name: "slash in the middle anchors", content: "gen/src", path: "x/gen/src", isDir: true, want: false},
+		{name: "double star", content: "**/tmp/*.java", path: "a/b/tmp/Foo.java", want: true},
+		{name: "contents of a directory", content: "cache/**", path: "cache/Foo.java", want: true},
+		{name: "directory itself for trailing double star", content: "cache/**", path: "cache", isDir: true, want: false},
+		{name: "negation re-includes", content: "*.java\n!Keep.java", path: "a/Keep.java", want: false},
+		{name: "negation leaves others ignored", content: "*.java\n!Keep.java", path: "a/Drop.java", want: true},
+		{name: "later rule wins", content: "!Keep.java\n*.java", path: "a/Keep.java", want: true},
+		{name: "comments and blank lines", content: "# *.java\n\n", path: "Foo.java", want: false},
+		{name: "escaped hash", content: "\\#notes.java", path: "#notes.java", want: true},
+		{name: "trailing spaces", content: "*.bak   ", path: "Foo.bak", want: true},
+		{name: "CRLF line endings", content: "*.bak\r\n*.tmp\r\n", path: "Foo.tmp", want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ignore := &gitignore{rules: parseGitignore("", []byte(test.content))}
+			if got := ignore.ignored(test.path, test.isDir); got != test.want {
+				t.Errorf("ignored(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestScanRespectsNestedGitignores(t *testing.T) {
+	files := map[string]string{
+		".gitignore":                "build/\n*.orig.java\n",
+		"src/Alpha.java":            testSSOSource("Alpha", "alpha"),
+		"src/Beta.orig.java":        testSSOSource("BetaOrig", "beta"),
+		"build/Gamma.java":          testSSOSource("Gamma", "gamma"),
+		"module/.gitignore":         "generated/\n!Keep.orig.java\n",
+		"module/Delta.java":         testSSOSource("Delta", "delta"),
+		"module/Keep.orig.java":     testSSOSource("Keep", "keep"),
+		"module/generated/Eps.java": testSSOSource("Epsilon", "epsilon"),
+		"other/generated/Zeta.java": testSSOSource("Zeta", "zeta"),
+	}
+	tests := []struct {
+		name    string
+		respect bool
+		want    []string
+	}{
+		{name: "respected", respect: true, want: []string{"Alpha", "Delta", "Keep", "Zeta"}},
+		{name: "ignored by default", respect: false, want: []string{"Alpha", "BetaOrig", "Delta", "Epsilon", "Gamma", "Keep", "Zeta"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ssos := scanTestFS(t, files, WithRespectGitignore(test.respect))
+			if got := classNames(ssos); !slices.Equal(got, test.want) {
+				t.Errorf("SSOs = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScanRespectsRepositoryRootGitignore(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		".gitignore":              "/src/stale/\n",
+		"src/Alpha.java":          testSSOSource("Alpha", "alpha"),
+		"src/stale/Old.java":      testSSOSource("Old", "old"),
+		"src/nested/stale/B.java": testSSOSource("Beta", "beta"),
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, src := range files {
+		path = filepath.Join(repo, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The scan starts below the repository root, whose .gitignore still applies
+	ssos, err := ScanForSSOs(filepath.Join(repo, "src"), WithRespectGitignore(true))
+	if err != nil {
+		t.Fatalf("ScanForSSOs: %v", err)
+	}
+	if got, want := classNames(ssos), []string{"Alpha", "Beta"}; !slices.Equal(got, want) {
+		t.Errorf("SSOs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// validateGlob checks that every segment of a slash-separated glob pattern is a valid path.Match pattern.
+func validateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether a slash-separated path matches a glob pattern. Segments are matched with path.Match,
+// and a "**" segment matches any number of path segments, including none.
+func matchGlob(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches the segments of a path against the segments of a glob pattern.
+func matchSegments(patterns []string, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+
+	// A "**" segment either matches nothing or consumes one more path segment
+	if patterns[0] == "**" {
+		if matchSegments(patterns[1:], names) {
+			return true
+		}
+		return len(names) > 0 && matchSegments(patterns, names[1:])
+	}
+
+	if len(names) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], names[0]); !matched {
+		return false
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
+
+// EscapeGlob escapes the glob metacharacters in a slash-separated path, so that it can be used as an exclude
+// pattern that matches only that path.
+func EscapeGlob(name string) string {
+	var escaped strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
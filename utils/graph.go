@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"cmp"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dotIDPattern matches the characters that cannot appear in an unquoted DOT identifier.
+var dotIDPattern = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// dotID returns a DOT identifier for a name, with every character DOT does not allow replaced by an underscore.
+func dotID(prefix string, name string) string {
+	return prefix + dotIDPattern.ReplaceAllString(name, "_")
+}
+
+// dotString quotes text as a DOT string.
+func dotString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+// RenderGraph returns a Graphviz DOT graph of the SSOs: a cluster per package holding a node per SSO, labeled with
+// its class name and method count, and an edge from each SSO to its superclass. Superclasses that are SSOs point on
+// to their own superclasses; other intermediate superclasses, whose members were merged into the SSOs, are drawn
+// dashed with a dashed edge to the base class they lead to. Packages, nodes, and edges are in order so that the
+// graph changes only where the SSOs do.
+func RenderGraph(ssos []ServerSideObject) string {
+	sorted := slices.Clone(ssos)
+	slices.SortStableFunc(sorted, func(a, b ServerSideObject) int {
+		return cmp.Or(cmp.Compare(a.PackageLine, b.PackageLine), cmp.Compare(a.ClassName, b.ClassName))
+	})
+
+	// Give each SSO a unique identifier, numbering those whose names only differ in characters DOT does not allow
+	ids := make([]string, len(sorted))
+	used := make(map[string]bool)
+	for i := range sorted {
+		id := dotID("sso_", qualifiedName(sorted[i].PackageLine, sorted[i].ClassName))
+		for n := 2; used[id]; n++ {
+			id = dotID("sso_", qualifiedName(sorted[i].PackageLine, sorted[i].ClassName)) + "_" + strconv.Itoa(n)
+		}
+		used[id] = true
+		ids[i] = id
+	}
+
+	var builder strings.Builder
+	builder.WriteString("digraph SSOs {\n")
+	builder.WriteString("    rankdir=BT;\n")
+	builder.WriteString("    node [shape=box];\n")
+	for i := range sorted {
+		sso := &sorted[i]
+		if i == 0 || sso.PackageLine != sorted[i-1].PackageLine {
+			builder.WriteString("\n    subgraph " + dotID("cluster_", packageDisplayName(sso.PackageLine)) + " {\n")
+			builder.WriteString("        label=" + dotString(packageDisplayName(sso.PackageLine)) + ";\n")
+		}
+		methods := strconv.Itoa(len(sso.DeclaredMethods)) + " methods"
+		if len(sso.DeclaredMethods) == 1 {
+			methods = "1 method"
+		}
+		builder.WriteString("        " + ids[i] + " [label=" + dotString(sso.ClassName+"\n"+methods) + "];\n")
+		if i == len(sorted)-1 || sso.PackageLine != sorted[i+1].PackageLine {
+			builder.WriteString("    }\n")
+		}
+	}
+
+	// Link each SSO to its superclass, adding nodes for the base classes and intermediate superclasses
+	var nodes, edges []string
+	seen := make(map[string]bool)
+	addNode := func(id string, node string) {
+		if !seen[id] {
+			seen[id] = true
+			nodes = append(nodes, "    "+id+" "+node+";\n")
+		}
+	}
+	for i := range sorted {
+		sso := &sorted[i]
+		if sso.SuperClass == "" {
+			continue // SSOs that implement a base interface extend no base class
+		}
+		superName := rawTypeName(sso.SuperClass)
+		if j := graphSuperclassIndex(sorted, sso, superName); j != -1 {
+			edges = append(edges, "    "+ids[i]+" -> "+ids[j]+";\n")
+			continue
+		}
+		baseID := dotID("base_", sso.BaseClass)
+		if sso.BaseClass != "" {
+			addNode(baseID, "[label="+dotString(sso.BaseClass)+", shape=ellipse]")
+		}
+		if simpleTypeName(superName) == sso.BaseClass {
+			edges = append(edges, "    "+ids[i]+" -> "+baseID+";\n")
+			continue
+		}
+		superID := dotID("super_", superName)
+		addNode(superID, "[label="+dotString(simpleTypeName(superName))+", style=dashed]")
+		edges = append(edges, "    "+ids[i]+" -> "+superID+";\n")
+		if sso.BaseClass != "" {
+			edges = append(edges, "    "+superID+" -> "+baseID+" [style=dashed];\n")
+		}
+	}
+	slices.Sort(nodes)
+	slices.Sort(edges)
+	edges = slices.Compact(edges)
+	if len(nodes) > 0 {
+		builder.WriteString("\n" + strings.Join(nodes, ""))
+	}
+	if len(edges) > 0 {
+		builder.WriteString("\n" + strings.Join(edges, ""))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// graphSuperclassIndex returns the index of the SSO that is the superclass of an SSO, preferring an exact qualified
+// match, then a class in the same package, then any class with the same simple name, or -1 when the superclass is not
+// an SSO.
+func graphSuperclassIndex(ssos []ServerSideObject, sso *ServerSideObject, superName string) int {
+	qualified := superName
+	if !strings.Contains(qualified, ".") && sso.SuperClassImport != "" {
+		qualified = sso.SuperClassImport
+	}
+	matches := []func(*ServerSideObject) bool{
+		func(c *ServerSideObject) bool { return qualifiedName(c.PackageLine, c.ClassName) == qualified },
+		func(c *ServerSideObject) bool { return c.PackageLine == sso.PackageLine && c.ClassName == superName },
+		func(c *ServerSideObject) bool { return c.ClassName == simpleTypeName(superName) },
+	}
+	for _, match := range matches {
+		for i := range ssos {
+			if &ssos[i] != sso && match(&ssos[i]) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// WriteGraph writes the graph of RenderGraph to path, leaving the file alone if it already holds the same graph. It
+// reports whether the file was written.
+func WriteGraph(path string, ssos []ServerSideObject) (bool, error) {
+	graph := RenderGraph(ssos)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == graph {
+		return false, nil
+	}
+	if err := writeFileAtomically(path, graph); err != nil {
+		return false, err
+	}
+	return true, nil
+}
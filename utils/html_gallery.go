@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// galleryTemplateSource defines the style, index, and class templates of the HTML gallery.
+//
+//go:embed templates/gallery.html.tmpl
+var galleryTemplateSource string
+
+// galleryTemplates renders the pages of the HTML gallery, escaping every name and member it is given.
+var galleryTemplates = template.Must(template.New("gallery.html.tmpl").Parse(galleryTemplateSource))
+
+// HTMLGalleryIndexFileName is the name of the index page WriteHTMLGallery writes alongside the page of every SSO.
+const HTMLGalleryIndexFileName = "index.html"
+
+// galleryIndex is the data of the index page of the gallery.
+type galleryIndex struct {
+	Title     string
+	Generator string
+	Classes   []galleryClassLink
+	Packages  []galleryPackage
+}
+
+// galleryPackage groups the links to the classes of a package on the index page.
+type galleryPackage struct {
+	Name    string
+	Classes []galleryClassLink
+}
+
+// galleryClassLink links a class from the index page.
+type galleryClassLink struct {
+	Name       string
+	SearchName string // The lowercased qualified name the search box matches against
+	Page       string
+	Methods    int
+	Fields     int
+}
+
+// galleryClassPage is the data of the page of a class.
+type galleryClassPage struct {
+	Name          string
+	QualifiedName string
+	Package       string
+	Source        string
+	Deprecated    bool
+	Summary       string
+	Sections      []galleryMembers
+	Highlighted   template.HTML // The simplified source, already escaped and marked up for highlighting
+}
+
+// galleryMembers lists the methods and fields of a class or one of its nested classes.
+type galleryMembers struct {
+	Name    string
+	Methods []galleryMethod
+	Fields  []galleryField
+}
+
+// galleryMethod is a row of the method table of a class page.
+type galleryMethod struct {
+	Signature  string
+	Returns    string
+	Summary    string
+	Deprecated bool
+}
+
+// galleryField is a row of the field table of a class page.
+type galleryField struct {
+	Name      string
+	Type      string
+	Modifiers string
+}
+
+// HTMLGalleryPagePath returns the path of the gallery page of a ServerSideObject, named after its qualified name.
+func HTMLGalleryPagePath(galleryDir string, sso *ServerSideObject) string {
+	return filepath.Join(galleryDir, galleryPageName(sso))
+}
+
+// galleryPageName returns the file name of the gallery page of a ServerSideObject.
+func galleryPageName(sso *ServerSideObject) string {
+	return qualifiedName(sso.PackageLine, sso.ClassName) + ".html"
+}
+
+// RenderHTMLGalleryIndex returns the index page of the gallery, listing every SSO grouped by package with a search
+// box that filters the list as it is typed into. The page is self-contained, with its style and script embedded.
+func RenderHTMLGalleryIndex(ssos []ServerSideObject, opts WriteOptions) (string, error) {
+	sorted := slices.Clone(ssos)
+	slices.SortStableFunc(sorted, func(a, b ServerSideObject) int {
+		return cmp.Or(cmp.Compare(a.PackageLine, b.PackageLine), cmp.Compare(a.ClassName, b.ClassName))
+	})
+
+	index := galleryIndex{Title: "SSO Gallery", Generator: opts.Generator}
+	for i := range sorted {
+		sso := &sorted[i]
+		link := galleryClassLink{
+			Name:       sso.ClassName,
+			SearchName: strings.ToLower(qualifiedName(sso.PackageLine, sso.ClassName)),
+			Page:       galleryPageName(sso),
+			Methods:    len(sso.DeclaredMethods),
+			Fields:     len(sso.DeclaredFields),
+		}
+		index.Classes = append(index.Classes, link)
+		if i == 0 || sso.PackageLine != sorted[i-1].PackageLine {
+			index.Packages = append(index.Packages, galleryPackage{Name: packageDisplayName(sso.PackageLine)})
+		}
+		last := &index.Packages[len(index.Packages)-1]
+		last.Classes = append(last.Classes, link)
+	}
+
+	var page bytes.Buffer
+	if err := galleryTemplates.ExecuteTemplate(&page, "index", index); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
+
+// RenderHTMLGalleryPage returns the gallery page of a ServerSideObject: the tables of its methods and fields and those
+// of its nested classes, followed by its simplified source as RenderSimplifiedSSO renders it, highlighted.
+func RenderHTMLGalleryPage(sso *ServerSideObject, opts WriteOptions) (string, error) {
+	// Highlight the source with LF line endings, which is how a browser shows it regardless
+	sourceOpts := opts
+	sourceOpts.LineEnding = LineEndingLF
+	source, err := RenderSimplifiedSSO(sso, sourceOpts)
+	if err != nil {
+		return "", err
+	}
+
+	data := galleryClassPage{
+		Name:          sso.ClassName,
+		QualifiedName: qualifiedName(sso.PackageLine, sso.ClassName),
+		Package:       packageDisplayName(sso.PackageLine),
+		Source:        filepath.ToSlash(sso.FilePath),
+		Deprecated:    sso.IsDeprecated,
+		Summary:       javadocSummary(sso.Javadoc),
+		Sections:      galleryMemberSections(sso, sso.ClassName),
+		Highlighted:   highlightJava(source),
+	}
+	var page bytes.Buffer
+	if err := galleryTemplates.ExecuteTemplate(&page, "class", data); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
+
+// galleryMemberSections lists the members of a class and then those of each of its nested classes.
+func galleryMemberSections(sso *ServerSideObject, name string) []galleryMembers {
+	section := galleryMembers{Name: name}
+	for _, method := range sso.DeclaredMethods {
+		section.Methods = append(section.Methods, galleryMethod{
+			Signature:  memberModifiers(method.IsStatic, method.IsFinal) + method.MethodName + "(" + joinParameters(method.Parameters) + ")",
+			Returns:    method.ReturnType,
+			Summary:    javadocSummary(method.Javadoc),
+			Deprecated: method.IsDeprecated,
+		})
+	}
+	for _, field := range sso.DeclaredFields {
+		modifiers := strings.TrimSpace(memberModifiers(field.IsStatic, field.IsFinal))
+		if field.IsDeprecated {
+			modifiers = strings.TrimSpace(modifiers + " deprecated")
+		}
+		section.Fields = append(section.Fields, galleryField{Name: field.Name, Type: field.Type, Modifiers: modifiers})
+	}
+	sections := []galleryMembers{section}
+	for i := range sso.NestedClasses {
+		sections = append(sections, galleryMemberSections(&sso.NestedClasses[i], name+"."+sso.NestedClasses[i].ClassName)...)
+	}
+	return sections
+}
+
+// WriteHTMLGallery writes the gallery page of every SSO and the index page to the gallery directory, leaving files
+// that already hold the same content alone. It returns the number of files written.
+func WriteHTMLGallery(galleryDir string, ssos []ServerSideObject, opts WriteOptions) (int, error) {
+	if err := os.MkdirAll(galleryDir, 0o755); err != nil {
+		return 0, err
+	}
+	written := 0
+	write := func(path string, content string) error {
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+			return nil
+		}
+		if err := writeFileAtomically(path, content); err != nil {
+			return err
+		}
+		written++
+		return nil
+	}
+	for i := range ssos {
+		page, err := RenderHTMLGalleryPage(&ssos[i], opts)
+		if err != nil {
+			return written, err
+		}
+		if err := write(HTMLGalleryPagePath(galleryDir, &ssos[i]), page); err != nil {
+			return written, err
+		}
+	}
+	index, err := RenderHTMLGalleryIndex(ssos, opts)
+	if err != nil {
+		return written, err
+	}
+	if err := write(filepath.Join(galleryDir, HTMLGalleryIndexFileName), index); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// packageDisplayName returns the name a package is listed under, naming the default package.
+func packageDisplayName(packageLine string) string {
+	if packageLine == "" {
+		return "(default package)"
+	}
+	return packageLine
+}
+
+// javaTokenPattern matches the tokens of Java source that the gallery highlights: comments, string and character
+// literals, annotations, keywords, and numbers. Everything else is shown plain.
+var javaTokenPattern = regexp.MustCompile(`(?s)(/\*.*?\*/|//[^\n]*)|("(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*')|(@[A-Za-z_$][\w$.]*)|\b(abstract|boolean|byte|char|class|default|double|enum|extends|false|final|float|implements|import|int|interface|long|new|null|package|private|protected|public|return|short|static|super|this|throw|throws|true|void)\b|\b(\d[\d_]*(?:\.\d+)?[LlFfDd]?)\b`)
+
+// highlightJava escapes Java source for HTML and wraps the tokens javaTokenPattern matches in spans classed by kind.
+func highlightJava(source string) template.HTML {
+	classes := []string{"com", "str", "ann", "kw", "num"}
+	var builder strings.Builder
+	last := 0
+	for _, match := range javaTokenPattern.FindAllStringSubmatchIndex(source, -1) {
+		builder.WriteString(template.HTMLEscapeString(source[last:match[0]]))
+		for group, class := range classes {
+			if match[2*group+2] >= 0 {
+				builder.WriteString(`<span class="` + class + `">` + template.HTMLEscapeString(source[match[0]:match[1]]) + "</span>")
+				break
+			}
+		}
+		last = match[1]
+	}
+	builder.WriteString(template.HTMLEscapeString(source[last:]))
+	return template.HTML(builder.String())
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+// baseClassName is the simple name of the class SSOs ultimately extend unless other base classes are configured.
+const baseClassName = "ServerSideObject"
+
+// javaClass is a class declaration found during a scan, parsed the same way as an SSO.
+type javaClass struct {
+	sso      ServerSideObject // The parsed class and its public members
+	isPublic bool             // Whether the class is declared public
+	warnings []Warning        // The warnings raised while parsing the class
+}
+
+// ssoBases are the simple names of the classes and interfaces whose subclasses and implementations are SSOs.
+type ssoBases struct {
+	classes    []string // Base classes, matched against extends clauses
+	interfaces []string // Base interfaces, matched against implements clauses
+}
+
+// resolveSSOs returns the public classes whose inheritance chain reaches one of the base classes or implements one of
+// the base interfaces. The public methods and fields of intermediate superclasses are merged into each SSO, with the
+// SSO's own declarations taking precedence. Each SSO found is reported to the logger.
+func resolveSSOs(declaredClasses []javaClass, bases ssoBases, logger Logger) ServerSideObjectList {
+	index := indexClasses(declaredClasses)
+
+	var matchingFiles ServerSideObjectList
+	for i := range declaredClasses {
+		class := &declaredClasses[i]
+		if !class.isPublic {
+			continue
+		}
+		ancestors, baseClass, _, ok := inheritanceChain(class, index, bases)
+		if !ok {
+			continue
+		}
+
+		matchingFiles = append(matchingFiles, resolveSSO(class, ancestors, baseClass, logger))
+	}
+	return matchingFiles
+}
+
+// resolveSSO builds the SSO for a class whose inheritance chain reaches baseClass, which may be a base interface,
+// merging in the public methods and fields of its ancestors and of the base class, and reports it to the logger.
+func resolveSSO(class *javaClass, ancestors []*javaClass, baseClass string, logger Logger) ServerSideObject {
+	// Output statement to indicate the SSO was found and is being parsed
+	logger.Printf("SSO found: %s.\n", class.sso.ClassName)
+
+	// SSOs in the default package are usually a mistake, and cannot be imported by packaged consumers
+	if class.sso.PackageLine == "" {
+		logger.Printf("Warning: SSO %s in %s is in the default package.\n", class.sso.ClassName, class.sso.FilePath)
+	}
+
+	sso := class.sso
+	sso.BaseClass = baseClass
+	sso.warnings = class.warnings
+
+	// Extend the nearest public ancestor instead of package-private ones, which have no simplified file of their own
+	for _, ancestor := range ancestors {
+		if ancestor.isPublic {
+			break
+		}
+		sso.SuperClass = rawTypeName(ancestor.sso.SuperClass) // Type arguments may refer to the skipped ancestor
+		sso.SuperClassImport = ancestor.sso.SuperClassImport
+	}
+	for _, ancestor := range ancestors {
+		sso.DeclaredMethods = mergeMethods(sso.DeclaredMethods, ancestor.sso.DeclaredMethods)
+		sso.DeclaredFields = mergeFields(sso.DeclaredFields, ancestor.sso.DeclaredFields)
+		sso.warnings = append(sso.warnings, ancestor.warnings...)
+	}
+
+	// Append the base class methods from sso_super.go that the SSO does not override
+	sso.DeclaredMethods = mergeMethods(sso.DeclaredMethods, superclassMethodsFor(baseClass))
+
+	return sso
+}
+
+// indexClasses maps both the qualified and simple names of the declared classes to their declarations.
+// When several classes share a simple name, the first one declared wins the simple-name entry.
+func indexClasses(declaredClasses []javaClass) map[string]*javaClass {
+	index := make(map[string]*javaClass)
+	for i := range declaredClasses {
+		class := &declaredClasses[i]
+		index[qualifiedName(class.sso.PackageLine, class.sso.ClassName)] = class
+		if _, ok := index[class.sso.ClassName]; !ok {
+			index[class.sso.ClassName] = class
+		}
+	}
+	return index
+}
+
+// inheritanceChain returns the declared superclasses between the class and the first class in its chain that
+// extends a base class or implements a base interface, nearest first, along with that base class or interface.
+// It reports false when the chain leaves the classes in the index without reaching a base, in which case the simple
+// name of the missing superclass is returned, or when the chain loops.
+func inheritanceChain(class *javaClass, index map[string]*javaClass, bases ssoBases) ([]*javaClass, string, string, bool) {
+	var ancestors []*javaClass
+	visited := map[*javaClass]bool{class: true}
+	for current := class; ; {
+		if base, ok := bases.match(current); ok {
+			return ancestors, base, "", true
+		}
+
+		superName := rawTypeName(current.sso.SuperClass)
+		parent := lookupSuperclass(current, superName, index)
+		if parent == nil {
+			return nil, "", simpleTypeName(superName), false // Unknown superclass, or none at all
+		}
+		if visited[parent] {
+			return nil, "", "", false // A cycle in the extends graph
+		}
+		visited[parent] = true
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+}
+
+// match reports which base interface the class implements directly or, failing that, which base class it extends
+// directly. Bases are matched by simple name, so qualified and generic spellings match too.
+func (b ssoBases) match(class *javaClass) (string, bool) {
+	for _, iface := range class.sso.Interfaces {
+		if simpleName := simpleTypeName(rawTypeName(iface)); slices.Contains(b.interfaces, simpleName) {
+			return simpleName, true
+		}
+	}
+	if simpleName := simpleTypeName(rawTypeName(class.sso.SuperClass)); simpleName != "" && slices.Contains(b.classes, simpleName) {
+		return simpleName, true
+	}
+	return "", false
+}
+
+// lookupSuperclass finds the declaration of a class's superclass, preferring an exact qualified match,
+// then a class in the same package, then any class with the same simple name.
+func lookupSuperclass(class *javaClass, superName string, index map[string]*javaClass) *javaClass {
+	if strings.Contains(superName, ".") {
+		return index[superName]
+	}
+	if parent, ok := index[qualifiedName(class.sso.PackageLine, superName)]; ok {
+		return parent
+	}
+	return index[superName]
+}
+
+// mergeMethods appends the inherited methods to the declared ones, keeping only the first method with each
+// signature so that declarations override inherited methods and duplicates are dropped.
+func mergeMethods(declared []PublicMethod, inherited []PublicMethod) []PublicMethod {
+	var merged []PublicMethod
+	signatures := make(map[string]bool)
+	for _, methods := range [][]PublicMethod{declared, inherited} {
+		for _, method := range methods {
+			if !signatures[methodSignature(method)] {
+				signatures[methodSignature(method)] = true
+				merged = append(merged, method)
+			}
+		}
+	}
+	return merged
+}
+
+// mergeFields appends the inherited fields that are not hidden by a declared field of the same name.
+func mergeFields(declared []PublicField, inherited []PublicField) []PublicField {
+	merged := append([]PublicField{}, declared...)
+	names := make(map[string]bool)
+	for _, field := range declared {
+		names[field.Name] = true
+	}
+	for _, field := range inherited {
+		if !names[field.Name] {
+			names[field.Name] = true
+			merged = append(merged, field)
+		}
+	}
+	return merged
+}
+
+// methodSignature returns the method name and erased parameter types, which identify an overload.
+// The return type is not part of the signature, so covariant overrides share the signature of the overridden method.
+func methodSignature(method PublicMethod) string {
+	paramTypes := make([]string, len(method.Parameters))
+	for i, param := range method.Parameters {
+		paramTypes[i] = erasedTypeName(param.Type)
+		if param.IsVarargs {
+			paramTypes[i] += "[]" // Varargs are arrays for overload purposes
+		}
+	}
+	return method.MethodName + "(" + strings.Join(paramTypes, ",") + ")"
+}
+
+// qualifiedName joins a package and class name, leaving the name alone for the default package.
+func qualifiedName(packageLine string, className string) string {
+	if packageLine == "" {
+		return className
+	}
+	return packageLine + "." + className
+}
+
+// rawTypeName removes any generic type arguments from a type name.
+func rawTypeName(typeName string) string {
+	if idx := strings.Index(typeName, "<"); idx != -1 {
+		return strings.TrimSpace(typeName[:idx])
+	}
+	return typeName
+}
+
+// erasedTypeName removes every generic type argument list from a type name, keeping any array brackets.
+func erasedTypeName(typeName string) string {
+	var builder strings.Builder
+	depth := 0
+	for _, c := range typeName {
+		switch {
+		case c == '<':
+			depth++
+		case c == '>':
+			depth--
+		case depth == 0 && c != ' ':
+			builder.WriteRune(c)
+		}
+	}
+	return builder.String()
+}
+
+// simpleTypeName removes any package qualifier from a type name.
+func simpleTypeName(typeName string) string {
+	return typeName[strings.LastIndex(typeName, ".")+1:]
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InterfaceNaming names the interface extracted from an SSO by adding a prefix and a suffix to its class name.
+type InterfaceNaming struct {
+	Prefix string // Added before the class name, such as the I of ITokenSSO
+	Suffix string // Added after the class name, such as the API of TokenSSOAPI
+}
+
+// defaultInterfacePrefix names extracted interfaces when InterfaceNaming sets neither a prefix nor a suffix.
+const defaultInterfacePrefix = "I"
+
+// Name returns the name of the interface extracted from a class, which starts with I unless a prefix or a suffix is
+// set, so that it never clashes with the class itself.
+func (n InterfaceNaming) Name(className string) string {
+	if n.Prefix == "" && n.Suffix == "" {
+		return defaultInterfacePrefix + className
+	}
+	return n.Prefix + className + n.Suffix
+}
+
+// extractsInterface reports whether an interface is extracted from a class. Only SSOs get one: nested classes and
+// base class stubs, which have no base class of their own, do not.
+func extractsInterface(sso *ServerSideObject) bool {
+	return sso.BaseClass != ""
+}
+
+// interfaceMethods returns the methods an extracted interface declares: the public instance methods of the SSO. They
+// come from the same DeclaredMethods as the simplified class, so methods skipped by the parser are skipped in both,
+// and the class implements every method of its interface.
+func interfaceMethods(sso *ServerSideObject, opts WriteOptions) []PublicMethod {
+	methods := sso.DeclaredMethods
+	if opts.SortMembers {
+		methods = sortedMethods(methods)
+	}
+	var declared []PublicMethod
+	for _, method := range methods {
+		if !method.IsStatic && accessModifier(method.AccessModifier) == "public" {
+			declared = append(declared, method)
+		}
+	}
+	return declared
+}
+
+// interfaceReference returns how a class refers to the interface extracted from it, passing on its type parameters,
+// so that Box<T extends Number> implements IBox<T>.
+func interfaceReference(sso *ServerSideObject, opts WriteOptions) string {
+	return opts.InterfaceNaming.Name(sso.ClassName) + typeParameterNames(sso.TypeParameters)
+}
+
+// typeParameterNames returns the names of a type parameter list without their bounds, such as "<K, V>" for
+// "<K extends Comparable<K>, V>", or an empty string for an empty list.
+func typeParameterNames(typeParameters string) string {
+	inner := strings.TrimSpace(typeParameters)
+	inner = strings.TrimSuffix(strings.TrimPrefix(inner, "<"), ">")
+	if inner == "" {
+		return ""
+	}
+
+	// Split the list on the commas outside the type arguments of the bounds, keeping the first word of each parameter
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			switch inner[i] {
+			case '<':
+				depth++
+			case '>':
+				depth--
+			}
+			if inner[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		if fields := strings.Fields(inner[start:i]); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+		start = i + 1
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
+
+// InterfacePath returns the path of the .java file of the interface extracted from a ServerSideObject, placed in
+// the directory of its package unless FlatOutput is set, as SimplifiedSSOPath places the class.
+func InterfacePath(outputDir string, sso *ServerSideObject, opts WriteOptions) string {
+	fileName := opts.InterfaceNaming.Name(sso.ClassName) + ".java"
+	if opts.FlatOutput || sso.PackageLine == "" {
+		return filepath.Join(outputDir, fileName)
+	}
+	packageDir := filepath.Join(strings.Split(sso.PackageLine, ".")...)
+	return filepath.Join(outputDir, packageDir, fileName)
+}
+
+// RenderInterface returns the source of the public interface extracted from a ServerSideObject, in the same package
+// as the class, declaring each of its public instance methods without a body. Fields, constructors, and static
+// methods are left out, since interfaces cannot declare them as the class does. Types of nested classes are
+// qualified with the class name, since the interface does not see them by their simple names.
+func RenderInterface(sso *ServerSideObject, opts WriteOptions) string {
+	e := newJavaEmitter(opts)
+	if header := renderHeader(sso, opts); header != "" {
+		e.lines(strings.TrimSuffix(header, "\n"))
+	}
+	if sso.PackageLine != "" {
+		e.line("package " + sso.PackageLine + ";")
+		e.line("")
+	}
+	if !opts.StripJavadoc && sso.Javadoc != "" {
+		e.lines(sso.Javadoc)
+	}
+	if sso.IsDeprecated {
+		e.line("@Deprecated")
+	}
+	e.open("public interface " + opts.InterfaceNaming.Name(sso.ClassName) + sso.TypeParameters)
+	e.line("")
+
+	// Qualify references to nested classes, which the interface sees only through the class
+	nested := make(map[string]bool, len(sso.NestedClasses)+len(sso.NestedEnums))
+	for _, class := range sso.NestedClasses {
+		nested[class.ClassName] = true
+	}
+	for _, enum := range sso.NestedEnums {
+		nested[enum.Name] = true
+	}
+	qualify := func(typeName string) string {
+		if len(nested) == 0 {
+			return typeName
+		}
+		return javaTypeNamePattern.ReplaceAllStringFunc(typeName, func(name string) string {
+			if nested[name] {
+				return sso.ClassName + "." + name
+			}
+			return name
+		})
+	}
+
+	for _, method := range interfaceMethods(sso, opts) {
+		if !opts.StripJavadoc && method.Javadoc != "" {
+			e.lines(method.Javadoc)
+		}
+		if method.IsDeprecated {
+			e.line("@Deprecated")
+		}
+		params := make([]Parameter, len(method.Parameters))
+		for i, param := range method.Parameters {
+			params[i] = param
+			params[i].Type = qualify(param.Type)
+		}
+		declaration := qualify(method.ReturnType) + " " + method.MethodName + "(" + joinParameters(params) + ")"
+		if len(method.Exceptions) > 0 {
+			declaration += " throws " + strings.Join(method.Exceptions, ", ")
+		}
+		e.line(declaration + ";")
+		e.line("")
+	}
+	e.close()
+
+	// Terminate every line the same way, including those of the license and Javadoc
+	rendered := strings.ReplaceAll(e.String(), "\r\n", "\n")
+	if terminator := opts.LineEnding.terminator(); terminator != "\n" {
+		rendered = strings.ReplaceAll(rendered, "\n", terminator)
+	}
+	return rendered
+}
+
+// WriteInterfaces writes the interface RenderInterface extracts from each SSO to the directory, leaving files that
+// already hold the same content alone unless Touch is set. It returns the number of files written.
+func WriteInterfaces(dir string, ssos []ServerSideObject, opts WriteOptions) (int, error) {
+	written := 0
+	for i := range ssos {
+		sso := &ssos[i]
+		if !extractsInterface(sso) {
+			continue
+		}
+		path := InterfacePath(dir, sso, opts)
+		content := RenderInterface(sso, opts)
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == content && !opts.Touch {
+			continue
+		}
+		if err := writeFileAtomically(path, content); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// javadocGapPattern matches the text allowed between a Javadoc comment and the declaration it documents: whitespace
+// and annotations, after comments have been blanked
+var javadocGapPattern = regexp.MustCompile(`^(?:\s|@[a-zA-Z0-9_$.]+(?:\s*\([^()]*\))?)*$`)
+
+// javadocComment is the position of a /** ... */ comment in a file.
+type javadocComment struct {
+	start int // Offset of the opening /**
+	end   int // Offset just past the closing */
+}
+
+// javadocIndex finds the Javadoc comments documenting declarations of a file.
+type javadocIndex struct {
+	source   string           // The file content, with comments
+	stripped string           // The file content with comments blanked in place
+	comments []javadocComment // The Javadoc comments of the file, in order
+}
+
+// newJavadocIndex indexes the Javadoc comments of the source. The stripped content must be the source with comments
+// blanked in place, as stripComments produces.
+func newJavadocIndex(source string, stripped string) javadocIndex {
+	index := javadocIndex{source: source, stripped: stripped}
+	for i := 0; i < len(source); i++ {
+		switch {
+		case source[i] == '"' || source[i] == '\'':
+			i = skipLiteral(source, i) - 1
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end == -1 {
+				return index
+			}
+			end += i + 4
+			if strings.HasPrefix(source[i:], "/**") && end-i > len("/**/") {
+				index.comments = append(index.comments, javadocComment{start: i, end: end})
+			}
+			i = end - 1
+		}
+	}
+	return index
+}
+
+// before returns the Javadoc comment documenting the declaration starting at the offset, with its indentation
+// normalized so that the opening /** is at column 0, or nothing when the declaration is undocumented.
+func (index javadocIndex) before(offset int) string {
+	i := sort.Search(len(index.comments), func(i int) bool { return index.comments[i].end > offset }) - 1
+	if i < 0 || !javadocGapPattern.MatchString(index.stripped[index.comments[i].end:offset]) {
+		return ""
+	}
+	comment := index.comments[i]
+	column := comment.start - (strings.LastIndexByte(index.source[:comment.start], '\n') + 1)
+	return normalizeJavadoc(index.source[comment.start:comment.end], column)
+}
+
+// normalizeJavadoc removes up to column characters of leading whitespace from every line of a Javadoc comment after
+// the first, the indentation of its opening /**, keeping the indentation of the lines relative to it.
+func normalizeJavadoc(comment string, column int) string {
+	lines := strings.Split(comment, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " \t")
+		if indentation := len(line) - len(trimmed); indentation > column {
+			trimmed = line[column:]
+		}
+		lines[i] = strings.TrimRight(trimmed, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
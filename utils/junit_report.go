@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// The results a JUnitCase can have.
+const (
+	JUnitPassed  = "passed"
+	JUnitFailed  = "failed"
+	JUnitSkipped = "skipped"
+)
+
+// JUnitCase is the outcome of processing a single SSO, reported as a JUnit test case named after the class in a test
+// suite named after its package.
+type JUnitCase struct {
+	ClassName string        // The name of the class
+	Package   string        // The package of the class, empty for the default package
+	Result    string        // One of JUnitPassed, JUnitFailed, or JUnitSkipped
+	Message   string        // Why the case failed or was skipped
+	Duration  time.Duration // The time spent processing the class
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the test cases of a package.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a single SSO.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+// junitMessage is the failure or skip message of a test case, with the message repeated as its text.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes a JUnit XML report with a test suite per package, in order, and a test case per SSO. XML
+// escaping of names and messages is left to encoding/xml. With no cases, the report holds a single empty test suite
+// so that it stays valid for tools that expect at least one.
+func WriteJUnitReport(w io.Writer, cases []JUnitCase) error {
+	sorted := slices.Clone(cases)
+	slices.SortStableFunc(sorted, func(a, b JUnitCase) int {
+		return cmp.Or(cmp.Compare(a.Package, b.Package), cmp.Compare(a.ClassName, b.ClassName))
+	})
+
+	report := junitTestSuites{Name: "sso_simplifier"}
+	var total time.Duration
+	var suiteTimes []time.Duration
+	for i, c := range sorted {
+		if i == 0 || c.Package != sorted[i-1].Package {
+			report.Suites = append(report.Suites, junitTestSuite{Name: packageDisplayName(c.Package)})
+			suiteTimes = append(suiteTimes, 0)
+		}
+		suite := &report.Suites[len(report.Suites)-1]
+		testCase := junitTestCase{Name: c.ClassName, ClassName: qualifiedName(c.Package, c.ClassName), Time: junitSeconds(c.Duration)}
+		switch c.Result {
+		case JUnitFailed:
+			testCase.Failure = &junitMessage{Message: c.Message, Text: c.Message}
+			suite.Failures++
+		case JUnitSkipped:
+			testCase.Skipped = &junitMessage{Message: c.Message, Text: c.Message}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		suiteTimes[len(suiteTimes)-1] += c.Duration
+		total += c.Duration
+	}
+	if len(report.Suites) == 0 {
+		report.Suites = append(report.Suites, junitTestSuite{Name: "sso_simplifier"})
+		suiteTimes = append(suiteTimes, 0)
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = junitSeconds(suiteTimes[i])
+		report.Tests += report.Suites[i].Tests
+		report.Failures += report.Suites[i].Failures
+		report.Skipped += report.Suites[i].Skipped
+	}
+	report.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSeconds formats a duration in seconds, as JUnit reports expect.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
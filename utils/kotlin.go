@@ -0,0 +1,316 @@
+package utils
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Language is the language the simplified SSOs are written in.
+type Language string
+
+const (
+	LanguageJava   Language = "java"   // Write Java sources (the default)
+	LanguageKotlin Language = "kotlin" // Write Kotlin sources, for consumers that prefer idiomatic Kotlin stubs
+)
+
+// extension returns the file extension of the sources of the language.
+func (l Language) extension() string {
+	if l == LanguageKotlin {
+		return ".kt"
+	}
+	return ".java"
+}
+
+// kotlinTemplateSource is the template of the built-in Kotlin output format.
+//
+//go:embed templates/simplified_sso_kotlin.tmpl
+var kotlinTemplateSource string
+
+// kotlinTemplate renders simplified SSOs in Kotlin unless WriteOptions sets a template of its own.
+var kotlinTemplate = template.Must(template.New("simplified_sso_kotlin.tmpl").Parse(kotlinTemplateSource))
+
+// kotlinTypes maps the built-in allowed Java types to their Kotlin types. Object types are nullable, since the stubs
+// return null for them as the Java stubs do.
+var kotlinTypes = map[string]string{
+	"boolean": "Boolean",
+	"byte":    "Byte",
+	"char":    "Char",
+	"short":   "Short",
+	"int":     "Int",
+	"long":    "Long",
+	"float":   "Float",
+	"double":  "Double",
+	"void":    "Unit",
+	"String":  "String?",
+
+	"Boolean":   "Boolean?",
+	"Byte":      "Byte?",
+	"Character": "Char?",
+	"Short":     "Short?",
+	"Integer":   "Int?",
+	"Long":      "Long?",
+	"Float":     "Float?",
+	"Double":    "Double?",
+}
+
+// kotlinDefaults are the default values of the Kotlin primitive types whose Java spelling differs.
+var kotlinDefaults = map[string]string{
+	"char": `'\u0000'`,
+}
+
+// kotlinTypeName returns the Kotlin type of a Java type. Arrays of primitives become their primitive array types,
+// such as IntArray, other arrays and unmapped object types are nullable, and the type arguments of generic types are
+// mapped as well.
+func kotlinTypeName(javaType string) string {
+	if elementType, ok := strings.CutSuffix(javaType, "[]"); ok {
+		if slices.Contains(primitiveTypes, elementType) && elementType != "void" {
+			return kotlinTypes[elementType] + "Array?"
+		}
+		return "Array<" + kotlinTypeName(elementType) + ">?"
+	}
+	if kotlinType, ok := kotlinTypes[javaType]; ok {
+		return kotlinType
+	}
+
+	// Map the type arguments of generic types, keeping the names of other types as spelled
+	mapped := javaTypeNamePattern.ReplaceAllStringFunc(javaType, func(name string) string {
+		if kotlinType, ok := kotlinTypes[name]; ok && name != javaType {
+			return strings.TrimSuffix(kotlinType, "?")
+		}
+		return name
+	})
+	return mapped + "?"
+}
+
+// kotlinDefaultValue returns the value a Kotlin stub returns for a Java type.
+func kotlinDefaultValue(types typeTable, javaType string) string {
+	if defaultValue, ok := kotlinDefaults[javaType]; ok {
+		return defaultValue
+	}
+	return types.defaultValueFor(javaType)
+}
+
+// renderKotlinClass renders a simplified class declaration in Kotlin, including its nested classes, at the emitter's
+// indentation. Kotlin requires overrides to be marked, so the class extends its base class directly: its members
+// already include those it inherits from any SSOs in between, and the methods of the base class are marked override.
+// Classes and their functions are open, as in Java. Checked exceptions have no Kotlin equivalent and are left out.
+func renderKotlinClass(e *javaEmitter, sso *ServerSideObject, opts WriteOptions, nested bool) {
+	types := newTypeTable(opts.AllowedTypes, false)
+
+	// Keep the Java semantics: classes can be extended, and nested classes that are not static are inner classes
+	classModifiers := "open "
+	if sso.IsAbstract {
+		classModifiers = "abstract "
+	}
+	if nested && !sso.IsStatic {
+		classModifiers += "inner "
+	}
+	var supertypes []string
+	extendsBase := !opts.OmitExtends && sso.SuperClass != ""
+	if extendsBase {
+		supertypes = append(supertypes, kotlinSuperClass(sso)+"()")
+	}
+	for _, iface := range sso.Interfaces {
+		if simpleTypeName(iface) == "Serializable" {
+			supertypes = append(supertypes, "java.io.Serializable")
+		}
+	}
+	supertypeClause := ""
+	if len(supertypes) > 0 {
+		supertypeClause = " : " + strings.Join(supertypes, ", ")
+	}
+	if !opts.StripJavadoc && sso.Javadoc != "" {
+		e.lines(sso.Javadoc)
+	}
+	if sso.IsDeprecated {
+		e.line(`@Deprecated("Deprecated in the source")`)
+	}
+	e.open(classModifiers + "class " + sso.ClassName + sso.TypeParameters + supertypeClause)
+	e.line("")
+
+	// Write instance fields as fields, so that Java callers see the same API
+	fields, methods := sso.DeclaredFields, sso.DeclaredMethods
+	if opts.SortMembers {
+		fields, methods = sortedFields(fields), sortedMethods(methods)
+	}
+	var staticFields []PublicField
+	for _, field := range fields {
+		if field.IsStatic {
+			staticFields = append(staticFields, field)
+			continue
+		}
+		renderKotlinField(e, field, types)
+	}
+
+	// Write the methods, marking those of the base class as overrides
+	var baseMethods []PublicMethod
+	if extendsBase {
+		baseMethods = superclassMethodsFor(sso.BaseClass)
+	}
+	var staticMethods []PublicMethod
+	for _, method := range methods {
+		if method.IsStatic {
+			staticMethods = append(staticMethods, method)
+			continue
+		}
+		modifiers := "open "
+		if method.IsFinal {
+			modifiers = ""
+		}
+		if declaresSignature(baseMethods, method) {
+			modifiers = "override "
+		}
+		renderKotlinMethod(e, method, modifiers, types, opts)
+	}
+
+	// Write the static members and serialVersionUID into the companion object, where Java sees them as statics
+	if len(staticFields) > 0 || len(staticMethods) > 0 || sso.SerialVersionUID != "" {
+		e.open("companion object")
+		if sso.SerialVersionUID != "" {
+			e.line("private const val serialVersionUID: Long = " + sso.SerialVersionUID)
+			e.line("")
+		}
+		for _, field := range staticFields {
+			renderKotlinField(e, field, types)
+		}
+		for _, method := range staticMethods {
+			e.line("@JvmStatic")
+			renderKotlinMethod(e, method, "", types, opts)
+		}
+		e.close()
+		e.line("")
+	}
+
+	// Write nested classes as nested stubs so references such as Outer.Inner still compile
+	for i := range sso.NestedClasses {
+		renderKotlinClass(e, &sso.NestedClasses[i], opts, true)
+		e.line("")
+	}
+	for _, enum := range sso.NestedEnums {
+		e.lines(kotlinEnum(enum))
+		e.line("")
+	}
+
+	e.close()
+}
+
+// kotlinSuperClass returns the class a Kotlin stub extends: its superclass as spelled when that is the base class,
+// and otherwise the base class itself.
+func kotlinSuperClass(sso *ServerSideObject) string {
+	if simpleTypeName(rawTypeName(sso.SuperClass)) == sso.BaseClass || sso.BaseClass == "" {
+		return sso.SuperClass
+	}
+	return sso.BaseClass
+}
+
+// renderKotlinField renders a field as a property exposed to Java as a field. Static final fields with a constant
+// of a primitive type or String become const vals.
+func renderKotlinField(e *javaEmitter, field PublicField, types typeTable) {
+	initializer := field.Initializer
+	if initializer == "" {
+		initializer = kotlinDefaultValue(types, field.Type)
+	}
+	if field.IsDeprecated {
+		e.line(`@Deprecated("Deprecated in the source")`)
+	}
+	typeName := kotlinTypeName(field.Type)
+	switch {
+	case field.IsStatic && field.IsFinal && field.Initializer != "" && kotlinTypes[field.Type] != "":
+		e.line("const val " + field.Name + ": " + strings.TrimSuffix(typeName, "?") + " = " + initializer)
+	case field.IsFinal:
+		e.line("@JvmField val " + field.Name + ": " + typeName + " = " + initializer)
+	default:
+		e.line("@JvmField var " + field.Name + ": " + typeName + " = " + initializer)
+	}
+	e.line("")
+}
+
+// renderKotlinMethod renders a function with a stub body, following the StubBody of the options.
+func renderKotlinMethod(e *javaEmitter, method PublicMethod, modifiers string, types typeTable, opts WriteOptions) {
+	if !opts.StripJavadoc && method.Javadoc != "" {
+		e.lines(method.Javadoc)
+	}
+	if method.IsDeprecated {
+		e.line(`@Deprecated("Deprecated in the source")`)
+	}
+
+	// Kotlin has no checked exceptions, so the throws clause becomes @Throws for Java callers that catch them
+	if len(method.Exceptions) > 0 {
+		classes := make([]string, len(method.Exceptions))
+		for i, exception := range method.Exceptions {
+			classes[i] = exception + "::class"
+		}
+		e.line("@Throws(" + strings.Join(classes, ", ") + ")")
+	}
+	params := make([]string, len(method.Parameters))
+	for i, param := range method.Parameters {
+		if param.IsVarargs {
+			params[i] = "vararg " + param.Name + ": " + kotlinTypeName(param.Type)
+		} else {
+			params[i] = param.Name + ": " + kotlinTypeName(param.Type)
+		}
+	}
+	signature := modifiers + "fun " + method.MethodName + "(" + strings.Join(params, ", ") + ")"
+	if method.ReturnType != "void" {
+		signature += ": " + kotlinTypeName(method.ReturnType)
+	}
+	e.open(signature)
+	switch opts.StubBody {
+	case StubBodyThrow:
+		e.line(`throw UnsupportedOperationException("SSO stub")`)
+	case StubBodyTODO:
+		e.line("// TODO: SSO stub")
+		fallthrough
+	default:
+		if method.ReturnType != "void" {
+			e.line("return " + kotlinDefaultValue(types, method.ReturnType))
+		}
+	}
+	e.close()
+	e.line("")
+}
+
+// kotlinEnum declares a Java enum as a Kotlin enum class with the same constants. Constructor arguments and class
+// bodies of the constants are left out, since they belong to the implementation.
+func kotlinEnum(enum EnumDeclaration) string {
+	source := enum.Source
+	start := strings.Index(source, "{")
+	if start == -1 {
+		return "enum class " + enum.Name
+	}
+
+	// Collect the constants up to the first semicolon or the closing brace, skipping their arguments and bodies
+	var constants []string
+	var current strings.Builder
+	depth := 0
+	for _, r := range source[start+1:] {
+		if depth == 0 && (r == ',' || r == ';' || r == '}') {
+			if name := strings.TrimSpace(current.String()); name != "" {
+				constants = append(constants, name)
+			}
+			current.Reset()
+			if r != ',' {
+				break
+			}
+			continue
+		}
+		switch r {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		default:
+			if depth == 0 {
+				current.WriteRune(r)
+			}
+		}
+	}
+	return "enum class " + enum.Name + " { " + strings.Join(constants, ", ") + " }"
+}
+
+// KotlinEnum declares a file enum as a Kotlin enum class, for templates of Kotlin output.
+func (d TemplateData) KotlinEnum(enum EnumDeclaration) string {
+	return kotlinEnum(enum)
+}
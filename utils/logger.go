@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Logger receives the informational messages of a scan, such as the SSOs found and warnings that do not stop it.
+// Each message is a complete line, including its trailing newline.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// DebugLogger is a Logger that also receives debug messages, such as every file a scan visits. Loggers that do not
+// implement it get no debug messages.
+type DebugLogger interface {
+	Logger
+	Debugf(format string, args ...any)
+}
+
+// debugf sends a debug message to the logger if it receives them.
+func debugf(logger Logger, format string, args ...any) {
+	if debugLogger, ok := logger.(DebugLogger); ok {
+		debugLogger.Debugf(format, args...)
+	}
+}
+
+// slogLogger sends messages to a *slog.Logger, at the warning level for those starting with "Warning:" and the
+// informational level for the rest.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a DebugLogger that sends each message to logger as a record, without its trailing newline.
+func NewSlogLogger(logger *slog.Logger) DebugLogger {
+	return slogLogger{logger: logger}
+}
+
+// Printf formats the message and logs it at the warning or informational level.
+func (l slogLogger) Printf(format string, args ...any) {
+	level := slog.LevelInfo
+	if strings.HasPrefix(format, "Warning:") {
+		level = slog.LevelWarn
+	}
+	l.log(level, format, args...)
+}
+
+// Debugf formats the message and logs it at the debug level.
+func (l slogLogger) Debugf(format string, args ...any) {
+	l.log(slog.LevelDebug, format, args...)
+}
+
+// log formats the message unless the logger discards the level.
+func (l slogLogger) log(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if l.logger.Enabled(ctx, level) {
+		l.logger.Log(ctx, level, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	}
+}
+
+// nopLogger discards every message. It is the default, so that embedding programs get no output they did not ask for.
+type nopLogger struct{}
+
+// Printf discards the message.
+func (nopLogger) Printf(format string, args ...any) {}
+
+// writerLogger writes messages to an io.Writer.
+type writerLogger struct {
+	out io.Writer
+}
+
+// NewWriterLogger returns a Logger that writes each message to out.
+func NewWriterLogger(out io.Writer) Logger {
+	return writerLogger{out: out}
+}
+
+// Printf formats the message and writes it.
+func (l writerLogger) Printf(format string, args ...any) {
+	fmt.Fprintf(l.out, format, args...)
+}
+
+// syncLogger serializes the messages sent to a Logger, so that messages from concurrent scan workers never
+// interleave and Loggers need not be safe for concurrent use.
+type syncLogger struct {
+	mu     sync.Mutex
+	logger Logger
+}
+
+// Printf passes the message on while holding the lock.
+func (l *syncLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logger.Printf(format, args...)
+}
+
+// Debugf passes the debug message on while holding the lock, if the Logger receives them.
+func (l *syncLogger) Debugf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	debugf(l.logger, format, args...)
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ManifestSchemaVersion is the version of the manifest format, raised whenever a change could break its consumers.
+const ManifestSchemaVersion = 1
+
+// Manifest describes the simplified SSOs of a run for downstream tooling.
+type Manifest struct {
+	SchemaVersion int             `json:"schemaVersion"`         // The ManifestSchemaVersion the manifest was written with
+	Generator     string          `json:"generator"`             // The name and version of the generating tool
+	GeneratedAt   string          `json:"generatedAt,omitempty"` // The generation time in RFC 3339, left out of reproducible runs
+	InputPath     string          `json:"inputPath"`             // The directory or file that was scanned
+	SSOs          []ManifestEntry `json:"ssos"`                  // The simplified SSOs, empty but present when none were found
+	ParseCache    *ParseCache     `json:"parseCache,omitempty"`  // The parse results of the scanned files, for incremental runs
+}
+
+// ManifestEntry is a simplified SSO in the manifest, along with the public methods left out of its stub.
+type ManifestEntry struct {
+	ServerSideObject
+	SkippedMethods []Warning `json:"skippedMethods"` // Why each skipped public method of the class or its superclasses was left out
+}
+
+// NewManifest describes the SSOs, using the Generator and Timestamp of the write options as generation metadata.
+func NewManifest(inputPath string, ssos []ServerSideObject, opts WriteOptions) Manifest {
+	manifest := Manifest{
+		SchemaVersion: ManifestSchemaVersion,
+		Generator:     opts.Generator,
+		InputPath:     inputPath,
+		SSOs:          []ManifestEntry{},
+	}
+	if !opts.Timestamp.IsZero() {
+		manifest.GeneratedAt = opts.Timestamp.UTC().Format(time.RFC3339)
+	}
+	for _, sso := range ssos {
+		entry := ManifestEntry{ServerSideObject: sso, SkippedMethods: append([]Warning{}, sso.SkippedMethods()...)}
+		manifest.SSOs = append(manifest.SSOs, entry)
+	}
+	return manifest
+}
+
+// ReadManifest reads a manifest written by WriteManifest.
+func ReadManifest(path string) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("%s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// PruneSimplifiedSSOs removes the simplified files of the SSOs in a previous manifest that are not among the current
+// SSOs, returning the paths removed. Files without the generated header are left alone, as WriteSimplifiedSSO leaves
+// them, and so are files that no longer exist.
+func PruneSimplifiedSSOs(outputDir string, previous []ManifestEntry, current []ServerSideObject, opts WriteOptions) ([]string, error) {
+	currentPaths := make(map[string]bool)
+	for i := range current {
+		currentPaths[SimplifiedSSOPath(outputDir, &current[i], opts)] = true
+	}
+
+	var removed []string
+	for i := range previous {
+		path := SimplifiedSSOPath(outputDir, &previous[i].ServerSideObject, opts)
+		if currentPaths[path] {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && !isGeneratedFile(string(content))) {
+			continue
+		}
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return removed, fmt.Errorf("pruning %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// WriteManifest writes the manifest as indented JSON to path, replacing any previous manifest atomically.
+func WriteManifest(path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, string(data)+"\n")
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MarkdownIndexFileName is the name of the index WriteMarkdownDocs writes alongside the page of every SSO.
+const MarkdownIndexFileName = "index.md"
+
+var (
+	// javadocInlineTagPattern matches inline Javadoc tags such as {@code name} or {@link Type#member}, capturing the text
+	javadocInlineTagPattern = regexp.MustCompile(`\{@\w+\s+([^}]*)\}`)
+	// javadocSentenceEndPattern matches the end of the first sentence of a Javadoc comment, as the javadoc tool finds it
+	javadocSentenceEndPattern = regexp.MustCompile(`\.(\s|$)`)
+)
+
+// MarkdownDocPath returns the path of the Markdown page of a ServerSideObject, named after its qualified name so
+// that the pages of every package can share one directory.
+func MarkdownDocPath(docsDir string, sso *ServerSideObject) string {
+	return filepath.Join(docsDir, markdownDocName(sso))
+}
+
+// markdownDocName returns the file name of the Markdown page of a ServerSideObject.
+func markdownDocName(sso *ServerSideObject) string {
+	return qualifiedName(sso.PackageLine, sso.ClassName) + ".md"
+}
+
+// RenderMarkdown returns a Markdown page documenting a ServerSideObject: its package, a table of its public methods,
+// and a table of its public fields, followed by the same for each nested class. Members are listed in declaration
+// order so that the page changes only where the class does.
+func RenderMarkdown(sso *ServerSideObject) string {
+	var builder strings.Builder
+	builder.WriteString("# " + sso.ClassName + "\n\n")
+	builder.WriteString("Package: `" + packageDisplayName(sso.PackageLine) + "`\n\n")
+	if sso.FilePath != "" {
+		builder.WriteString("Source: `" + filepath.ToSlash(sso.FilePath) + "`\n\n")
+	}
+	renderMarkdownMembers(&builder, sso, "##")
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// renderMarkdownMembers writes the description and member tables of a class, and then those of its nested classes
+// under headings one level deeper.
+func renderMarkdownMembers(builder *strings.Builder, sso *ServerSideObject, heading string) {
+	if sso.IsDeprecated {
+		builder.WriteString("**Deprecated.**\n\n")
+	}
+	if summary := javadocSummary(sso.Javadoc); summary != "" {
+		builder.WriteString(summary + "\n\n")
+	}
+
+	builder.WriteString(heading + " Methods\n\n")
+	if len(sso.DeclaredMethods) == 0 {
+		builder.WriteString("None.\n\n")
+	} else {
+		builder.WriteString("| Signature | Returns | Description |\n| --- | --- | --- |\n")
+		for _, method := range sso.DeclaredMethods {
+			signature := memberModifiers(method.IsStatic, method.IsFinal) + method.MethodName + "(" + joinParameters(method.Parameters) + ")"
+			description := javadocSummary(method.Javadoc)
+			if method.IsDeprecated {
+				description = strings.TrimSpace("**Deprecated.** " + description)
+			}
+			builder.WriteString("| " + markdownCode(signature) + " | " + markdownCode(method.ReturnType) + " | " + markdownCell(description) + " |\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(heading + " Fields\n\n")
+	if len(sso.DeclaredFields) == 0 {
+		builder.WriteString("None.\n\n")
+	} else {
+		builder.WriteString("| Name | Type | Modifiers |\n| --- | --- | --- |\n")
+		for _, field := range sso.DeclaredFields {
+			modifiers := strings.TrimSpace(memberModifiers(field.IsStatic, field.IsFinal))
+			if field.IsDeprecated {
+				modifiers = strings.TrimSpace(modifiers + " deprecated")
+			}
+			builder.WriteString("| " + markdownCode(field.Name) + " | " + markdownCode(field.Type) + " | " + markdownCell(modifiers) + " |\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	for i := range sso.NestedClasses {
+		nested := &sso.NestedClasses[i]
+		builder.WriteString(heading + " " + sso.ClassName + "." + nested.ClassName + "\n\n")
+		nestedCopy := *nested
+		nestedCopy.ClassName = sso.ClassName + "." + nested.ClassName
+		renderMarkdownMembers(builder, &nestedCopy, heading+"#")
+	}
+}
+
+// RenderMarkdownIndex returns a Markdown index linking the page of every SSO, grouped by package, with both the
+// packages and the classes within them in order.
+func RenderMarkdownIndex(ssos []ServerSideObject) string {
+	sorted := slices.Clone(ssos)
+	slices.SortStableFunc(sorted, func(a, b ServerSideObject) int {
+		return cmp.Or(cmp.Compare(a.PackageLine, b.PackageLine), cmp.Compare(a.ClassName, b.ClassName))
+	})
+
+	var builder strings.Builder
+	builder.WriteString("# SSO Documentation\n\n")
+	for i := range sorted {
+		sso := &sorted[i]
+		if i == 0 || sso.PackageLine != sorted[i-1].PackageLine {
+			if i > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString("## " + packageDisplayName(sso.PackageLine) + "\n\n")
+		}
+		builder.WriteString("- [" + sso.ClassName + "](" + markdownDocName(sso) + ")\n")
+	}
+	return builder.String()
+}
+
+// WriteMarkdownDocs writes the page of RenderMarkdown for every SSO and the index of RenderMarkdownIndex to the docs
+// directory, leaving files that already hold the same content alone. It returns the number of files written.
+func WriteMarkdownDocs(docsDir string, ssos []ServerSideObject) (int, error) {
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		return 0, err
+	}
+	written := 0
+	write := func(path string, content string) error {
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+			return nil
+		}
+		if err := writeFileAtomically(path, content); err != nil {
+			return err
+		}
+		written++
+		return nil
+	}
+	for i := range ssos {
+		if err := write(MarkdownDocPath(docsDir, &ssos[i]), RenderMarkdown(&ssos[i])); err != nil {
+			return written, err
+		}
+	}
+	if err := write(filepath.Join(docsDir, MarkdownIndexFileName), RenderMarkdownIndex(ssos)); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// javadocSummary returns the first sentence of a Javadoc comment as a single line, with inline tags reduced to their
+// text, or "" if there is no comment or it has only block tags.
+func javadocSummary(javadoc string) string {
+	var text []string
+	for _, line := range strings.Split(javadoc, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "/**")
+		line = strings.TrimSuffix(line, "*/")
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if strings.HasPrefix(line, "@") {
+			break // The description ends at the first block tag
+		}
+		if line != "" {
+			text = append(text, line)
+		}
+	}
+	summary := javadocInlineTagPattern.ReplaceAllString(strings.Join(text, " "), "$1")
+	if loc := javadocSentenceEndPattern.FindStringIndex(summary); loc != nil {
+		summary = summary[:loc[0]+1]
+	}
+	return summary
+}
+
+// markdownCode renders text as a code span in a table cell.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(text, "|", "\\|") + "`"
+}
+
+// markdownCell escapes the characters of text that would end a table cell or row.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "|", "\\|"), "\n", " ")
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// parseCacheVersion is raised whenever the parser changes what it records, so that older caches are not reused.
+const parseCacheVersion = 2
+
+// ParseCache holds the parse results of the files of a scan by path and content hash, so that a later scan with the
+// same parse settings can reuse the results of unchanged files instead of parsing them again. Inheritance is still
+// resolved across every file on each scan, so a changed superclass is picked up by unchanged subclasses.
+type ParseCache struct {
+	Settings string       `json:"settings"` // The scan options that affect parsing, which must match for reuse
+	Files    []CachedFile `json:"files"`    // The parse results of each file, by path
+	Reused   int          `json:"-"`        // The files whose results were reused from the previous cache
+	Reparsed int          `json:"-"`        // The files that were parsed because they were new, changed, or not cached
+}
+
+// CachedFile is the parse result of a single source file.
+type CachedFile struct {
+	Path     string        `json:"path"`     // The path of the file as recorded in the SSOs
+	Hash     string        `json:"hash"`     // The SHA-256 of the file content, in hex
+	Classes  []CachedClass `json:"classes"`  // The class declarations parsed from the file
+	Warnings []Warning     `json:"warnings"` // The warnings about the file that do not belong to a class
+}
+
+// CachedClass is a class declaration parsed from a file, before inheritance is resolved.
+type CachedClass struct {
+	ServerSideObject
+	IsPublic bool      `json:"isPublic"` // Whether the class is declared public
+	Warnings []Warning `json:"warnings"` // The warnings raised while parsing the class
+}
+
+// WithParseCache reuses the parse results in previous for files whose content is unchanged, provided it was recorded
+// with the same parse settings, and records the results of every file scanned into next, including its counts of
+// reused and reparsed files. Either may be nil.
+func WithParseCache(previous *ParseCache, next *ParseCache) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.PreviousParseCache = previous
+		opts.ParseCache = next
+	}
+}
+
+// parseSettings describes the scan options that affect what the parser records.
+func (opts ScanOptions) parseSettings() string {
+	allowed := make([]string, 0, len(opts.AllowedTypes))
+	for typeName, defaultValue := range opts.AllowedTypes {
+		allowed = append(allowed, typeName+"="+defaultValue)
+	}
+	slices.Sort(allowed)
+	return fmt.Sprintf("version=%d encoding=%s lenient=%t protected=%t allowed=%s", parseCacheVersion, opts.SourceEncoding, opts.Lenient, opts.IncludeProtected, strings.Join(allowed, ","))
+}
+
+// parseCache looks up and records the parse results of the files of a scan. Its methods may be called concurrently.
+type parseCache struct {
+	previous map[string]CachedFile // The reusable results of the previous scan, by path
+	next     *ParseCache           // Where the results of this scan are recorded, or nil
+	mu       sync.Mutex
+}
+
+// newParseCache returns the cache for a scan with the options, or nil when the options set no cache.
+func newParseCache(opts ScanOptions) *parseCache {
+	if opts.PreviousParseCache == nil && opts.ParseCache == nil {
+		return nil
+	}
+	cache := &parseCache{previous: make(map[string]CachedFile), next: opts.ParseCache}
+	settings := opts.parseSettings()
+	if opts.PreviousParseCache != nil && opts.PreviousParseCache.Settings == settings {
+		for _, file := range opts.PreviousParseCache.Files {
+			cache.previous[file.Path] = file
+		}
+	}
+	if cache.next != nil {
+		*cache.next = ParseCache{Settings: settings}
+	}
+	return cache
+}
+
+// hashContent returns the hash that identifies the content of a file in the cache.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup returns the cached parse results of the file at path if its content is unchanged, recording them again.
+func (c *parseCache) lookup(path string, hash string) ([]javaClass, []Warning, bool) {
+	file, ok := c.previous[path]
+	if !ok || file.Hash != hash {
+		return nil, nil, false
+	}
+	classes := make([]javaClass, len(file.Classes))
+	for i, class := range file.Classes {
+		classes[i] = javaClass{sso: class.ServerSideObject, isPublic: class.IsPublic, warnings: class.Warnings}
+	}
+	c.store(file, true)
+	return classes, file.Warnings, true
+}
+
+// record stores the parse results of the file at path.
+func (c *parseCache) record(path string, hash string, classes []javaClass, warnings []Warning) {
+	file := CachedFile{Path: path, Hash: hash, Classes: make([]CachedClass, len(classes)), Warnings: warnings}
+	for i, class := range classes {
+		file.Classes[i] = CachedClass{ServerSideObject: class.sso, IsPublic: class.isPublic, Warnings: class.warnings}
+	}
+	c.store(file, false)
+}
+
+// store adds a file to the results of this scan and counts it.
+func (c *parseCache) store(file CachedFile, reused bool) {
+	if c.next == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.next.Files = append(c.next.Files, file)
+	if reused {
+		c.next.Reused++
+	} else {
+		c.next.Reparsed++
+	}
+}
+
+// finish orders the recorded files by path, so that the cache does not depend on which worker finished first.
+func (c *parseCache) finish() {
+	if c == nil || c.next == nil {
+		return
+	}
+	slices.SortFunc(c.next.Files, func(a, b CachedFile) int { return strings.Compare(a.Path, b.Path) })
+}
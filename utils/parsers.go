@@ -1,147 +1,1479 @@
 package utils
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// readChunkSize is the number of bytes read from a file between cancellation checks
+const readChunkSize = 64 * 1024
+
 var (
 	// packagePattern matches package declarations in normalized content
 	packagePattern = regexp.MustCompile(`package ([a-zA-Z0-9_.]+);`)
-	// classPattern matches public class declarations extending ServerSideObject in normalized content
-	classPattern = regexp.MustCompile(`public class [a-zA-Z0-9_$]+ extends ServerSideObject`)
-	// methodPattern matches public method declarations in normalized content, allowing for extra whitespace
-	methodPattern = regexp.MustCompile(`public\s+([a-zA-Z0-9_$<>\[\]]+)\s+([a-zA-Z0-9_$]+)\s*\(([^)]*)\)`)
-	// publicFieldPattern matches public field declarations with optional modifiers, type, name, and optional initializer
-	publicFieldPattern = regexp.MustCompile(`public(?:\s+(?:static|final|transient|volatile))*\s+([a-zA-Z0-9_$\[\]]+)\s+([a-zA-Z0-9_$]+)(?:\s*=\s*[^;]+)?;`)
+	// importPattern matches single-type import declarations in normalized content, capturing the imported type
+	importPattern = regexp.MustCompile(`\bimport ((?:[a-zA-Z0-9_$]+\s*\.\s*)+[a-zA-Z0-9_$]+)\s*;`)
+	// classPattern matches class declarations in normalized content, capturing the class modifiers and the class name
+	classPattern = regexp.MustCompile(`\b((?:(?:public|protected|private|static|abstract|final|strictfp) )*)class ([a-zA-Z0-9_$]+)`)
+	// enumPattern matches enum declarations in normalized content, capturing the enum name
+	enumPattern = regexp.MustCompile(`\b(?:(?:public|protected|private|static|strictfp)\s+)*enum\s+([a-zA-Z0-9_$]+)`)
+	// extendsPattern matches the extends clause following a class name and its type parameters, capturing the
+	// optionally package-qualified superclass
+	extendsPattern = regexp.MustCompile(`^\s*extends ((?:[a-zA-Z0-9_$]+\s*\.\s*)*[a-zA-Z0-9_$]+)`)
+	// implementsPattern matches the start of the implements clause following a class's extends clause, if any
+	implementsPattern = regexp.MustCompile(`^\s*implements\s`)
+	// nestedTypeHeaderPattern matches the header of a nested type declaration at the end of the text preceding its body,
+	// capturing the modifiers, the kind of type, and the type name
+	nestedTypeHeaderPattern = regexp.MustCompile(`(?:^|[\s;{}])((?:(?:public|protected|private|static|abstract|final|strictfp|sealed|non-sealed)\s+)*)(class|interface|enum|record|@interface)\s+([a-zA-Z0-9_$]+)[^=]*$`)
+	// methodPattern matches method declarations in normalized content, allowing for extra whitespace and modifiers
+	// in any order, capturing the modifiers (including any @Deprecated marker), type parameters of a generic method,
+	// return type, name, parameters, and any throws clause
+	methodPattern = regexp.MustCompile(`((?:@Deprecated\s+)?\b(?:(?:public|protected|private|static|final|abstract|synchronized|native|strictfp|default)\s+)+)(<[^(){};=]*?>\s*)?([a-zA-Z0-9_$.<>\[\]?]+(?:\s*,\s*[a-zA-Z0-9_$.<>\[\]?]+)*(?:\s*\[\s*\])*)\s+([a-zA-Z0-9_$]+)\s*\(([^)]*)\)\s*(?:throws\s+([a-zA-Z0-9_$.,\s]+?)\s*)?[{;]`)
+	// constructorPattern matches public constructor declarations (optionally generic) in normalized content, capturing the name and parameters
+	constructorPattern = regexp.MustCompile(`public\s+(?:<[^>]*>\s*)?([a-zA-Z0-9_$]+)\s*\(([^)]*)\)`)
+	// publicFieldPattern matches the start of field declarations with modifiers in any order, a type with any type
+	// arguments, and a first declarator, capturing the modifiers (including any @Deprecated marker), type, and the
+	// start of the declarators
+	publicFieldPattern = regexp.MustCompile(`((?:@Deprecated\s+)?\b(?:(?:public|protected|private|static|final|transient|volatile)\s+)+)([a-zA-Z0-9_$.\[\]]+(?:\s*<[^;=(){}]*>)?(?:\s*\[\s*\])*)\s+([a-zA-Z0-9_$]+(?:\s*\[\s*\])*\s*[=,;])`)
+	// deprecatedAnnotationPattern matches a @Deprecated annotation, optionally qualified with java.lang
+	deprecatedAnnotationPattern = regexp.MustCompile(`@(?:java\.lang\.)?Deprecated\b`)
+	// deprecatedTagPattern matches the @deprecated block tag at the start of a line of a Javadoc comment
+	deprecatedTagPattern = regexp.MustCompile(`(?m)^[\s*/]*@deprecated\b`)
+	// serialVersionUIDPattern matches a serialVersionUID declaration, capturing its modifiers and value
+	serialVersionUIDPattern = regexp.MustCompile(`\b((?:(?:public|protected|private|static|final)\s+)+)long\s+serialVersionUID\s*=\s*([^;]+?)\s*;`)
+	// declaratorPattern matches a single field declarator, capturing the name, any brackets declared on it, and any initializer
+	declaratorPattern = regexp.MustCompile(`^\s*([a-zA-Z0-9_$]+)((?:\s*\[\s*\])*)(?:\s*=\s*(.*?))?\s*$`)
 )
 
-// ScanForSSOs scans .java files in the given directory and returns a list of files that contain an SSO.
-func ScanForSSOs(directory string) (ServerSideObjectList, error) {
-	var matchingFiles ServerSideObjectList
+// ScanForSSOs scans .java files in the given directory, or in the given .zip or .jar source archive, and returns a
+// list of files that contain an SSO. A single .java file may also be given, in which case only that file is parsed.
+// A class is an SSO when it is public and its inheritance chain, followed through the classes declared
+// in the scanned directory, reaches ServerSideObject.
+//
+// Files that cannot be read do not stop the scan: the SSOs found elsewhere are returned together with a
+// ScanErrors error listing the failed paths. WithFailFast restores stopping at the first such file.
+//
+// Files are parsed concurrently by a pool of WithParallelism workers, defaulting to GOMAXPROCS. The scan prints
+// nothing; pass WithLogger to receive its "SSO found" lines and warnings.
+func ScanForSSOs(directory string, options ...ScanOption) (ServerSideObjectList, error) {
+	return ScanForSSOsContext(context.Background(), directory, options...)
+}
+
+// ScanForSSOsAndPrint is ScanForSSOs with its messages printed to stdout, as ScanForSSOs itself used to do.
+//
+// Deprecated: Use ScanForSSOs with WithLogger(NewWriterLogger(os.Stdout)). This wrapper will be removed in the
+// next release.
+func ScanForSSOsAndPrint(directory string, options ...ScanOption) (ServerSideObjectList, error) {
+	return ScanForSSOs(directory, append([]ScanOption{WithLogger(NewWriterLogger(os.Stdout))}, options...)...)
+}
+
+// ScanForSSOsContext is ScanForSSOs with cancellation. The context is checked between files and while reading
+// them; once it is done the scan returns the SSOs resolved from the files parsed so far, together with a
+// ScanError wrapping ctx.Err() and the path that was being processed.
+func ScanForSSOsContext(ctx context.Context, directory string, options ...ScanOption) (ServerSideObjectList, error) {
+	return scanPath(ctx, directory, newScanOptions(options), nil)
+}
 
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+// scanPath opens the directory, source archive, or single .java file at directory as a filesystem and scans it
+// with scanFS.
+func scanPath(ctx context.Context, directory string, opts ScanOptions, emit func(ServerSideObject)) (ServerSideObjectList, error) {
+	// Stat the input up front so a missing input is reported by its own path rather than relative to the filesystem
+	info, err := os.Stat(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// Source archives are scanned in memory, with paths recorded as archive!/path/inside.java
+	if !info.IsDir() && isSourceArchive(directory) {
+		archive, err := zip.OpenReader(directory)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("could not open archive %s: %w", directory, err)
 		}
+		defer archive.Close()
 
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".java") {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
+		recordedPath := func(name string) string {
+			return directory + "!/" + name
+		}
+		return scanFS(ctx, archive, ".", recordedPath, nil, opts, opts.newGitignore(), emit)
+	}
+
+	// Scan the directory as a filesystem rooted at itself; a single .java file is scanned from its parent directory
+	base, root := directory, "."
+	if !info.IsDir() {
+		if !IsJavaSourceFile(directory, info) {
+			return nil, fmt.Errorf("%s is not a directory, a .java file, or a .zip or .jar archive", directory)
+		}
+		base, root = filepath.Dir(directory), filepath.Base(directory)
+	}
+
+	// Record paths as they would be written on the command line rather than relative to the filesystem, and tell
+	// directories reached through symlinks apart by the real paths they resolve to
+	recordedPath := func(name string) string {
+		return filepath.Join(base, filepath.FromSlash(name))
+	}
+	realPath := func(name string) (string, error) {
+		return filepath.EvalSymlinks(recordedPath(name))
+	}
+
+	// Gitignore rules from directories above the input, up to the repository root, also apply
+	ignore := opts.newGitignore()
+	if ignore != nil && info.IsDir() {
+		if ignore, err = loadAncestorGitignores(directory); err != nil {
+			return nil, err
+		}
+	}
+	return scanFS(ctx, os.DirFS(base), root, recordedPath, realPath, opts, ignore, emit)
+}
+
+// walkedDirs records the directories a walk has descended into, by real path when the filesystem is on disk and
+// otherwise by the file info of the directory, which os.SameFile compares.
+type walkedDirs struct {
+	fsys     fs.FS
+	realPath func(string) (string, error) // Resolves fs paths to real paths on disk, or nil
+	paths    map[string]bool              // The real paths walked, when realPath is set
+	infos    []fs.FileInfo                // The directories walked, when realPath is not set
+}
+
+// contains reports whether the directory at name, or the directory it links to, has been walked.
+func (w *walkedDirs) contains(name string) bool {
+	if w.realPath != nil {
+		path, err := w.realPath(name)
+		return err == nil && w.paths[path]
+	}
+	info, err := fs.Stat(w.fsys, name)
+	return err == nil && slices.ContainsFunc(w.infos, func(walked fs.FileInfo) bool { return os.SameFile(walked, info) })
+}
+
+// enter records the directory at name as walked, reporting whether it is the first time.
+func (w *walkedDirs) enter(name string) (bool, error) {
+	if w.realPath != nil {
+		path, err := w.realPath(name)
+		if err != nil {
+			return false, err
+		}
+		if w.paths[path] {
+			return false, nil
+		}
+		if w.paths == nil {
+			w.paths = make(map[string]bool)
+		}
+		w.paths[path] = true
+		return true, nil
+	}
+	info, err := fs.Stat(w.fsys, name)
+	if err != nil {
+		return false, err
+	}
+	if slices.ContainsFunc(w.infos, func(walked fs.FileInfo) bool { return os.SameFile(walked, info) }) {
+		return false, nil
+	}
+	w.infos = append(w.infos, info)
+	return true, nil
+}
+
+// IsJavaSourceFile reports whether the path, described by info, is a regular .java file rather than a directory.
+func IsJavaSourceFile(path string, info os.FileInfo) bool {
+	return info.Mode().IsRegular() && hasJavaExtension(path)
+}
+
+// hasJavaExtension reports whether a file name ends in .java, in any letter case.
+func hasJavaExtension(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".java")
+}
+
+// isScannableSource reports whether a file should be parsed for classes. package-info.java and module-info.java
+// can never declare an SSO, so they are skipped without being read.
+func isScannableSource(name string) bool {
+	if !hasJavaExtension(name) {
+		return false
+	}
+	baseName := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	return baseName != "package-info" && baseName != "module-info"
+}
+
+// isSourceArchive reports whether the path names a .zip or .jar archive of Java sources.
+func isSourceArchive(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	return extension == ".zip" || extension == ".jar"
+}
+
+// ScanForSSOsFS scans the .java files under root in the given filesystem, as ScanForSSOs does for a directory.
+// FilePath values in the result are the fs-relative paths of the files.
+func ScanForSSOsFS(fsys fs.FS, root string, options ...ScanOption) (ServerSideObjectList, error) {
+	return ScanForSSOsFSContext(context.Background(), fsys, root, options...)
+}
+
+// ScanForSSOsFSContext is ScanForSSOsFS with cancellation, as ScanForSSOsContext is for ScanForSSOs.
+func ScanForSSOsFSContext(ctx context.Context, fsys fs.FS, root string, options ...ScanOption) (ServerSideObjectList, error) {
+	recordedPath := func(name string) string {
+		return name
+	}
+	opts := newScanOptions(options)
+	return scanFS(ctx, fsys, root, recordedPath, nil, opts, opts.newGitignore(), nil)
+}
+
+// scanFS implements the scans: it walks root in fsys, parses the .java files with a pool of workers, and resolves
+// the SSOs among the declared classes. recordedPath maps fs paths to the paths recorded in results and errors;
+// realPath, when not nil, resolves fs paths to their real paths on disk, through any symlinks. ignore, when not nil,
+// holds the gitignore rules from above root that the walk adds to. When emit is not nil, SSOs are passed to it as
+// soon as they are resolved instead of being returned.
+func scanFS(ctx context.Context, fsys fs.FS, root string, recordedPath func(string) string, realPath func(string) (string, error), opts ScanOptions, ignore *gitignore, emit func(ServerSideObject)) (ServerSideObjectList, error) {
+	// Reject an unsupported source encoding and invalid exclude patterns before doing any work
+	decode, err := lookupSourceEncoding(opts.SourceEncoding)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range opts.Exclude {
+		if err := validateGlob(pattern); err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+	}
+	config := parseConfig{types: newTypeTable(opts.AllowedTypes, opts.Lenient), includeProtected: opts.IncludeProtected, cache: newParseCache(opts)}
+	defer config.cache.finish()
+
+	// scanCtx is also cancelled when a fail-fast scan hits its first error, so the walk and the workers wind down early
+	scanCtx, stopScan := context.WithCancel(ctx)
+	defer stopScan()
+
+	// filesSkipped counts the source files the walk leaves out, for progress reports
+	var filesSkipped atomic.Int64
+
+	// Producer: walk the tree and hand every .java file to the workers in discovery order
+	jobs := make(chan fileJob)
+	var walkErr error
+	var scanErrors ScanErrors
+	var stoppedAt string
+	go func() {
+		defer close(jobs)
+		index := 0
+		// walked holds the directories descended into so far, so that followed symlinks cannot loop or scan a
+		// directory twice, whichever way the walk reaches it first
+		walked := walkedDirs{fsys: fsys, realPath: realPath}
+		var walkDir fs.WalkDirFunc
+		walkDir = func(name string, entry fs.DirEntry, err error) error {
+			path := recordedPath(name)
+			if scanCtx.Err() != nil {
+				stoppedAt = path
+				return fs.SkipAll
 			}
-			defer file.Close()
 
-			// Read the file content
-			content, err := io.ReadAll(file)
 			if err != nil {
-				return err
-			}
-
-			// Normalize the content by removing newlines and extra spaces
-			normalizedContent := strings.Join(strings.Fields(string(content)), " ")
-
-			// Check if the file contains a public class extending ServerSideObject
-			if classPattern.MatchString(normalizedContent) {
-				className := info.Name()[:len(info.Name())-len(filepath.Ext(info.Name()))] // File name without extension
-
-				// Output statement to indicate the SSO was found and is being parsed
-				fmt.Printf("SSO found: %s.\n", className)
-
-				// Extract package string
-				packageMatch := packagePattern.FindStringSubmatch(normalizedContent)
-				var packageLine string
-				if len(packageMatch) > 1 {
-					packageLine = packageMatch[1]
-				}
-
-				// Locate the class definition boundaries
-				classStart := strings.Index(normalizedContent, "class "+className+" extends ServerSideObject")
-				classEnd := strings.LastIndex(normalizedContent, "}")
-				if classStart == -1 || classEnd == -1 || classStart >= classEnd {
-					return nil // Invalid class definition
-				}
-				classContent := normalizedContent[classStart : classEnd+1]
-
-				// Remove any private classes from classContent before extracting public methods
-				classContent = removePrivateClasses(classContent)
-
-				// Extract public methods within the class definition
-				methodMatches := methodPattern.FindAllStringSubmatch(classContent, -1)
-				var declaredMethods []PublicMethod
-				for _, match := range methodMatches {
-					if len(match) >= 4 {
-						// Check if return type is allowed
-						if _, ok := allowedTypes[match[1]]; !ok {
-							continue // Skip this method if return type is not allowed
-						}
-						parameters := extractParameters(match[3])
-
-						// Check if all parameter types are valid
-						if !areParametersValid(parameters) {
-							continue // Skip this method if an invalid parameter type is found
-						}
-
-						declaredMethods = append(declaredMethods, PublicMethod{
-							AccessModifier: "public",
-							ReturnType:     match[1],
-							MethodName:     match[2],
-							Parameters:     parameters,
-						})
+				// The scan cannot do anything useful without its root
+				if name == root || opts.FailFast {
+					return &ScanError{Path: path, Err: err}
+				}
+				scanErrors = append(scanErrors, &ScanError{Path: path, Err: err})
+				return nil
+			}
+
+			// Skip excluded and gitignored paths, and the whole tree below such a directory, without reading them
+			relativePath := relativeTo(root, name)
+			if name != root && (opts.excluded(relativePath) || (ignore != nil && ignore.ignored(relativePath, entry.IsDir()))) {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				if isScannableSource(entry.Name()) {
+					filesSkipped.Add(1)
+				}
+				return nil
+			}
+
+			// Skip a directory already scanned through a symlink to it
+			if opts.FollowSymlinks && entry.IsDir() {
+				if first, err := walked.enter(name); err == nil && !first {
+					opts.Logger.Printf("Warning: not scanning %s, since it was already scanned through a symlink.\n", path)
+					return fs.SkipDir
+				}
+			}
+
+			// Pick up the directory's own .gitignore before the walk descends into it
+			if ignore != nil && entry.IsDir() {
+				if err := ignore.load(fsys, name, relativePath); err != nil {
+					if opts.FailFast {
+						return &ScanError{Path: path, Err: err}
 					}
+					scanErrors = append(scanErrors, &ScanError{Path: path, Err: err})
 				}
+			}
 
-				// Extract public fields within the class definition
-				fieldMatches := publicFieldPattern.FindAllStringSubmatch(classContent, -1)
-				var declaredFields []PublicField
-				for _, match := range fieldMatches {
-					if len(match) >= 3 {
-						declaredFields = append(declaredFields, PublicField{
-							Type: match[1],
-							Name: match[2],
-						})
+			// Walk through symlinks to directories, unless the target has been walked already
+			if opts.FollowSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+				target, err := fs.Stat(fsys, name)
+				if err != nil {
+					// A broken symlink only costs its own target, even when failing fast
+					scanErrors = append(scanErrors, &ScanError{Path: path, Err: fmt.Errorf("broken symlink: %w", err)})
+					return nil
+				}
+				if target.IsDir() {
+					if walked.contains(name) {
+						opts.Logger.Printf("Warning: not following symlink %s, since its target directory is already being scanned.\n", path)
+						return nil
 					}
+					return fs.WalkDir(fsys, name, walkDir)
 				}
+			}
 
-				// Append superclass methods to declaredMethods from sso_super.go
-				declaredMethods = append(declaredMethods, SuperclassMethods...)
+			if !entry.IsDir() && isScannableSource(entry.Name()) {
+				// Files over the size limit are skipped without being read, whether or not the scan fails fast
+				if opts.MaxFileSize > 0 {
+					if info, err := entry.Info(); err == nil && info.Size() > opts.MaxFileSize {
+						scanErrors = append(scanErrors, &ScanError{Path: path, Err: fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrFileTooLarge, info.Size(), opts.MaxFileSize)})
+						filesSkipped.Add(1)
+						return nil
+					}
+				}
 
-				// Create a new ServerSideObject and append it to the list
-				matchingFiles = append(matchingFiles, ServerSideObject{
-					FilePath:        path,
-					ClassName:       className,
-					PackageLine:     packageLine,
-					DeclaredMethods: declaredMethods,
-					DeclaredFields:  declaredFields,
-				})
+				debugf(opts.Logger, "Visiting %s.\n", path)
+				select {
+				case jobs <- fileJob{index: index, name: name, path: path}:
+					index++
+				case <-scanCtx.Done():
+					stoppedAt = path
+					return fs.SkipAll
+				}
+			}
+			return nil
+		}
+		walkErr = fs.WalkDir(fsys, root, walkDir)
+	}()
+
+	// Workers: parse the discovered files concurrently
+	results := make(chan fileResult)
+	var workers sync.WaitGroup
+	for i := 0; i < opts.Parallelism; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				// Keep draining after a stop so the producer is never left blocked on a send
+				if scanCtx.Err() != nil {
+					continue
+				}
+				classes, warnings, err := parseFileSafely(scanCtx, fsys, job.name, job.path, decode, config)
+				results <- fileResult{fileJob: job, classes: classes, warnings: warnings, err: err}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	// Collect the results; the channel closes only after the producer and every worker have finished
+	var parsed []fileResult
+	var parseErrors ScanErrors
+	var firstErr error
+	var interruptedAt string
+	var scanProgress ScanProgress
+
+	// Warnings are reported for the files and the SSOs that are kept, once each even when an ancestor is shared
+	var warnings []Warning
+	seenWarnings := make(map[Warning]bool)
+	addSSOWarnings := func(sso *ServerSideObject) {
+		for _, warning := range sso.warnings {
+			if !seenWarnings[warning] {
+				seenWarnings[warning] = true
+				warnings = append(warnings, warning)
+			}
+		}
+	}
 
-				// Pretty print the added ServerSideObject
-				// model.PrettyPrintStruct(matchingFiles[len(matchingFiles)-1])
+	// A streaming scan resolves SSOs as their inheritance chains become known instead of after the walk
+	var resolver *streamResolver
+	if emit != nil {
+		resolver = newStreamResolver(opts.bases(), opts.Logger)
+	}
+
+	for result := range results {
+		// Report progress from this goroutine only, so callbacks never run concurrently
+		if opts.OnProgress != nil {
+			scanProgress.FilesScanned++
+			if result.err != nil {
+				scanProgress.FilesFailed++
+			}
+			for i := range result.classes {
+				if _, ok := opts.bases().match(&result.classes[i]); ok {
+					scanProgress.SSOsFound++
+				}
 			}
+			scanProgress.FilesSkipped = int(filesSkipped.Load())
+			scanProgress.CurrentFile = result.path
+			opts.OnProgress(scanProgress)
 		}
-		return nil
-	})
 
-	// Sort the matchingFiles by ClassName before returning
-	sort.Sort(matchingFiles)
+		if result.err != nil {
+			// Reads interrupted by a stop are not failures of the file itself
+			if scanCtx.Err() != nil && errors.Is(result.err, scanCtx.Err()) {
+				if interruptedAt == "" {
+					interruptedAt = result.path
+				}
+				continue
+			}
+			if opts.FailFast {
+				if firstErr == nil {
+					firstErr = &ScanError{Path: result.path, Err: result.err}
+					stopScan()
+				}
+				continue
+			}
+			parseErrors = append(parseErrors, &ScanError{Path: result.path, Err: result.err})
+			continue
+		}
 
+		if resolver != nil {
+			warnings = append(warnings, result.warnings...)
+			resolver.add(result.classes, func(sso ServerSideObject) {
+				if opts.Filter == nil || opts.Filter.Matches(&sso) {
+					addSSOWarnings(&sso)
+					emit(sso)
+				}
+			})
+			continue
+		}
+		parsed = append(parsed, result)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	var matchingFiles ServerSideObjectList
+	if resolver == nil {
+		// Restore discovery order so the outcome does not depend on which worker finished first
+		sort.Slice(parsed, func(i, j int) bool { return parsed[i].index < parsed[j].index })
+		var declaredClasses []javaClass
+		for _, result := range parsed {
+			declaredClasses = append(declaredClasses, result.classes...)
+			warnings = append(warnings, result.warnings...)
+		}
+
+		// Second pass: keep the classes that inherit from one of the base classes
+		matchingFiles = resolveSSOs(declaredClasses, opts.bases(), opts.Logger)
+		if opts.Filter != nil {
+			matchingFiles = opts.Filter.Apply(matchingFiles)
+		}
+
+		// Sort the matchingFiles by ClassName before returning
+		sort.Sort(matchingFiles)
+		for i := range matchingFiles {
+			addSSOWarnings(&matchingFiles[i])
+		}
+	}
+	if opts.Warnings != nil {
+		*opts.Warnings = append(*opts.Warnings, warnings...)
+	}
+
+	// A cancelled scan reports where it stopped, ahead of any per-file errors
+	if err := ctx.Err(); err != nil {
+		if interruptedAt == "" {
+			interruptedAt = stoppedAt
+		}
+		if interruptedAt == "" {
+			interruptedAt = recordedPath(root)
+		}
+		return matchingFiles, &ScanError{Path: interruptedAt, Err: err}
+	}
+
+	err = walkErr
+	scanErrors = append(scanErrors, parseErrors...)
+	if err == nil && len(scanErrors) > 0 {
+		sort.Slice(scanErrors, func(i, j int) bool { return scanErrors[i].Path < scanErrors[j].Path })
+		err = scanErrors
+	}
 	return matchingFiles, err
 }
 
+// relativeTo returns the path of name, a path within the scanned filesystem, relative to the scan root.
+func relativeTo(root string, name string) string {
+	if name == root {
+		return "."
+	}
+	if root == "." {
+		return name
+	}
+	return strings.TrimPrefix(name, root+"/")
+}
+
+// fileJob is a .java file discovered by the walk, numbered in discovery order.
+type fileJob struct {
+	index int    // Position of the file in the walk
+	name  string // Path of the file within the scanned filesystem
+	path  string // Path of the file as recorded in results and errors
+}
+
+// fileResult is the outcome of parsing a single fileJob.
+type fileResult struct {
+	fileJob
+	classes  []javaClass // The class declarations parsed from the file
+	warnings []Warning   // The warnings about the file that do not belong to a parsed class
+	err      error       // The error that stopped the file from being parsed, if any
+}
+
+// parseConfig holds the scan options that decide which members the parser extracts.
+type parseConfig struct {
+	types            typeTable   // The member types that are supported
+	includeProtected bool        // Whether protected methods and fields are extracted alongside public ones
+	cache            *parseCache // The parse results to reuse and record, or nil
+}
+
+// accessModifier returns the access modifier of a member with the given modifiers, reporting false for members
+// that are not extracted.
+func (config parseConfig) accessModifier(modifiers []string) (string, bool) {
+	switch {
+	case slices.Contains(modifiers, "public"):
+		return "public", true
+	case config.includeProtected && slices.Contains(modifiers, "protected"):
+		return "protected", true
+	}
+	return "", false
+}
+
+// parseFileSafely parses a file with parseFile, turning a panic into an error so that a single bad file cannot
+// take down a worker and leave the pool waiting on it.
+func parseFileSafely(ctx context.Context, fsys fs.FS, name string, path string, decode sourceDecoder, config parseConfig) (classes []javaClass, warnings []Warning, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			classes, warnings, err = nil, nil, fmt.Errorf("panic while parsing: %v", r)
+		}
+	}()
+	return parseFile(ctx, fsys, name, path, decode, config)
+}
+
+// parseFile reads a single .java file from the filesystem and parses it with parseSource. The file is closed before
+// returning, and only the parsed declarations outlive the call, not the file content.
+func parseFile(ctx context.Context, fsys fs.FS, name string, path string, decode sourceDecoder, config parseConfig) ([]javaClass, []Warning, error) {
+	content, err := readFile(ctx, fsys, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.cache == nil {
+		classes, warnings := parseSource(path, content, decode, config)
+		return classes, warnings, nil
+	}
+
+	// Reuse the results of an unchanged file, and record those of any other
+	hash := hashContent(content)
+	if classes, warnings, ok := config.cache.lookup(path, hash); ok {
+		return classes, warnings, nil
+	}
+	classes, warnings := parseSource(path, content, decode, config)
+	config.cache.record(path, hash, classes, warnings)
+	return classes, warnings, nil
+}
+
+// ParseSSOSource parses the source of a single .java file held in memory, returning the SSO it declares, or nil when
+// it does not declare one, along with the parse warnings about the file and the SSO. The filename is recorded as
+// the SSO's FilePath. Only superclasses declared in the same source are followed, so an SSO whose chain to
+// ServerSideObject passes through another file is not recognized; use ScanForSSOs for whole trees.
+func ParseSSOSource(filename string, src []byte) (sso *ServerSideObject, warnings []Warning, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			sso, warnings, err = nil, nil, fmt.Errorf("panic while parsing %s: %v", filename, r)
+		}
+	}()
+
+	classes, fileWarnings := parseSource(filename, src, decodeUTF8, parseConfig{types: newTypeTable(nil, false)})
+	ssos := resolveSSOs(classes, ssoBases{classes: []string{baseClassName}}, nopLogger{})
+	if len(ssos) == 0 {
+		return nil, fileWarnings, nil
+	}
+	return &ssos[0], append(fileWarnings, ssos[0].warnings...), nil
+}
+
+// parseSource decodes the content of a .java file and parses its class declarations, recording them under path.
+// Warnings about the file that do not belong to a parsed class are returned separately.
+func parseSource(path string, content []byte, decode sourceDecoder, config parseConfig) ([]javaClass, []Warning) {
+	// Decode the content, warning rather than silently mis-parsing when it is not valid in the source encoding
+	decodedContent, valid := decode(content)
+	var encodingWarnings []Warning
+	if !valid {
+		encodingWarnings = append(encodingWarnings, Warning{Path: path, Reason: "byte sequences invalid in the source encoding", Rule: RuleInvalidEncoding})
+	}
+
+	// Remove comments so commented-out declarations are not matched, keeping the Javadoc comments for the stubs
+	sourceContent := normalizeEncoding(decodedContent)
+	strippedContent := stripComments(sourceContent)
+	javadocs := newJavadocIndex(sourceContent, strippedContent)
+
+	// Normalize the content by removing newlines and extra spaces
+	normalizedContent, offsets := normalizeWhitespace(strippedContent)
+
+	// Map positions in the normalized content back to lines of the file; comments were blanked in place, so the
+	// stripped content has the same lines as the file
+	newlines := newLineIndex(strippedContent)
+	lineAt := func(pos int) int {
+		return newlines.lineAt(offsets.inputOffset(pos))
+	}
+	javadocAt := func(pos int) string {
+		return javadocs.before(offsets.inputOffset(pos))
+	}
+
+	classes, fileWarnings := parseClasses(path, normalizedContent, config, lineAt, javadocAt)
+	return classes, append(encodingWarnings, fileWarnings...)
+}
+
+// utf8BOM is the byte order mark some Windows editors write at the start of UTF-8 files
+const utf8BOM = "\ufeff"
+
+// normalizeEncoding removes a leading UTF-8 byte order mark from decoded file content and converts Windows line
+// endings to Unix ones, so files edited on Windows parse like their Unix-encoded twins.
+func normalizeEncoding(content string) string {
+	text := strings.TrimPrefix(content, utf8BOM)
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// readFile reads a file in chunks, checking the context between chunks so a cancelled scan does not wait on a
+// large file or a slow mount.
+func readFile(ctx context.Context, fsys fs.FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var content bytes.Buffer
+	chunk := make([]byte, readChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := file.Read(chunk)
+		content.Write(chunk[:n])
+		if err == io.EOF {
+			return content.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseClasses parses every class declaration with an extends clause in the normalized content of a file.
+// lineAt maps positions in the normalized content to lines of the file, and javadocAt to the Javadoc comment
+// documenting the declaration there.
+func parseClasses(path string, normalizedContent string, config parseConfig, lineAt func(int) int, javadocAt func(int) string) ([]javaClass, []Warning) {
+	var declaredClasses []javaClass
+	fileWarnings := &parseWarnings{path: path}
+
+	// Extract package string
+	packageMatch := packagePattern.FindStringSubmatch(normalizedContent)
+	var packageLine string
+	if len(packageMatch) > 1 {
+		packageLine = packageMatch[1]
+	}
+
+	// Types kept by lenient mode are qualified through the file's imports
+	imports := make(map[string]string)
+	for _, importMatch := range importPattern.FindAllStringSubmatch(normalizedContent, -1) {
+		importedType := qualifiedTypeName(importMatch[1])
+		imports[simpleTypeName(importedType)] = importedType
+	}
+	config.types = config.types.withImports(imports)
+
+	// Enums declared alongside the classes of the file may be reproduced with any SSO from it
+	fileEnums := extractTopLevelEnums(normalizedContent)
+
+	for _, classMatch := range classPattern.FindAllStringSubmatchIndex(normalizedContent, -1) {
+		classModifiers := strings.Fields(normalizedContent[classMatch[2]:classMatch[3]])
+		className := normalizedContent[classMatch[4]:classMatch[5]]
+
+		// Capture any type parameters declared between the class name and the extends clause
+		typeParamsEnd := skipTypeArguments(normalizedContent, classMatch[5])
+		typeParameters := strings.TrimSpace(normalizedContent[classMatch[5]:typeParamsEnd])
+
+		// Include any generic arguments on the superclass in its recorded spelling
+		var superClass string
+		headerEnd := typeParamsEnd
+		if extendsMatch := extendsPattern.FindStringSubmatchIndex(normalizedContent[typeParamsEnd:]); extendsMatch != nil {
+			headerEnd = skipTypeArguments(normalizedContent, typeParamsEnd+extendsMatch[3])
+			superClass = qualifiedTypeName(normalizedContent[typeParamsEnd+extendsMatch[2] : headerEnd])
+		}
+
+		// Remember how an unqualified superclass was imported, so the extends clause can be reproduced
+		superClassImport := imports[rawTypeName(superClass)]
+
+		var interfaces []string
+		if implementsMatch := implementsPattern.FindStringIndex(normalizedContent[headerEnd:]); implementsMatch != nil {
+			interfaces, headerEnd = splitTypeList(normalizedContent, headerEnd+implementsMatch[1])
+		}
+		if superClass == "" && len(interfaces) == 0 {
+			continue // Classes without a superclass or interfaces can never be SSOs
+		}
+
+		// Locate the class definition boundaries
+		classStart := classMatch[0]
+		classEnd := findClassEnd(normalizedContent, headerEnd)
+		if classEnd == -1 {
+			fileWarnings.add(lineAt(classMatch[4]), className, "", RuleUnbalancedBraces, "unbalanced braces, class skipped")
+			continue
+		}
+		classContent := normalizedContent[classStart : classEnd+1]
+
+		sso := ServerSideObject{
+			FilePath:         path,
+			Line:             lineAt(classMatch[4]),
+			Javadoc:          javadocAt(classStart),
+			IsDeprecated:     isDeclarationDeprecated(normalizedContent, classStart) || isJavadocDeprecated(javadocAt(classStart)),
+			ClassName:        className,
+			TypeParameters:   typeParameters,
+			SuperClass:       superClass,
+			SuperClassImport: superClassImport,
+			Interfaces:       interfaces,
+			IsAbstract:       slices.Contains(classModifiers, "abstract"),
+			PackageLine:      packageLine,
+			FileEnums:        fileEnums,
+		}
+
+		// Extract public methods, fields, and nested classes within the class definition
+		classWarnings := &parseWarnings{path: path}
+		parseClassMembers(&sso, classContent, config, classWarnings, func(pos int) int { return lineAt(classStart + pos) }, func(pos int) string { return javadocAt(classStart + pos) })
+
+		declaredClasses = append(declaredClasses, javaClass{
+			sso:      sso,
+			isPublic: slices.Contains(classModifiers, "public"),
+			warnings: classWarnings.warnings,
+		})
+	}
+	return declaredClasses, fileWarnings.warnings
+}
+
+// parseClassMembers extracts the public methods, fields, and constructors of the class content into the SSO,
+// recursing into public nested classes so they can be reproduced as nested stubs. Skipped declarations are
+// reported to warnings. lineAt maps positions in the class content to lines of the file, and javadocAt to the
+// Javadoc comment documenting the declaration there.
+func parseClassMembers(sso *ServerSideObject, classContent string, config parseConfig, warnings *parseWarnings, lineAt func(int) int, javadocAt func(int) string) {
+	// Blank out method bodies and move nested types out so only member-level declarations are matched
+	memberContent, nestedTypes, bodyOffsets := splitClassBody(classContent)
+
+	// Remove annotations so their argument lists do not interfere with member declarations
+	memberContent, annotationOffsets := stripAnnotations(memberContent)
+	memberLineAt := func(pos int) int {
+		return lineAt(bodyOffsets.inputOffset(annotationOffsets.inputOffset(pos)))
+	}
+	memberJavadocAt := func(pos int) string {
+		return javadocAt(bodyOffsets.inputOffset(annotationOffsets.inputOffset(pos)))
+	}
+
+	sso.DeclaredMethods = extractMethods(memberContent, sso.ClassName, config, warnings, memberLineAt, memberJavadocAt)
+	sso.DeclaredFields = extractFields(memberContent, sso.ClassName, config, warnings, memberLineAt, memberJavadocAt)
+	sso.DeclaredConstructors = extractConstructors(memberContent, sso.ClassName, config.types)
+
+	// Keep the serialVersionUID of serializable classes even though it is private, so stubs serialize compatibly
+	if isSerializable(sso) {
+		sso.SerialVersionUID = extractSerialVersionUID(memberContent)
+		if sso.SerialVersionUID == "" {
+			warnings.add(sso.Line, sso.ClassName, "", RuleMissingSerialVersionUID, "serializable class declares no serialVersionUID, so the stub cannot match its implicit one")
+		}
+	}
+
+	for _, nested := range nestedTypes {
+		if !slices.Contains(nested.modifiers, "public") {
+			continue // Only public nested types are part of the API
+		}
+		if nested.kind == "enum" {
+			sso.NestedEnums = append(sso.NestedEnums, EnumDeclaration{Name: nested.name, Source: nested.content})
+			continue
+		}
+		if nested.kind != "class" {
+			warnings.add(lineAt(nested.offset), sso.ClassName, nested.name, RuleUnsupportedNestedType, fmt.Sprintf("nested %s not supported", nested.kind))
+			continue
+		}
+
+		// Capture any type parameters declared after the nested class name
+		nameEnd := strings.Index(nested.content, "class "+nested.name) + len("class "+nested.name)
+		nestedClass := ServerSideObject{
+			Line:           lineAt(nested.offset + nameEnd - len(nested.name)),
+			Javadoc:        javadocAt(nested.offset),
+			IsDeprecated:   isDeclarationDeprecated(classContent, nested.offset) || isJavadocDeprecated(javadocAt(nested.offset)),
+			ClassName:      nested.name,
+			TypeParameters: strings.TrimSpace(nested.content[nameEnd:skipTypeArguments(nested.content, nameEnd)]),
+			IsAbstract:     slices.Contains(nested.modifiers, "abstract"),
+			IsStatic:       slices.Contains(nested.modifiers, "static"),
+			PackageLine:    sso.PackageLine,
+		}
+		parseClassMembers(&nestedClass, nested.content, config, warnings, func(pos int) int { return lineAt(nested.offset + pos) }, func(pos int) string { return javadocAt(nested.offset + pos) })
+		sso.NestedClasses = append(sso.NestedClasses, nestedClass)
+	}
+}
+
+// extractTopLevelEnums extracts the enums declared at the top level of the normalized content of a file.
+func extractTopLevelEnums(normalizedContent string) []EnumDeclaration {
+	var enums []EnumDeclaration
+	searchFrom := 0
+	for _, enumMatch := range enumPattern.FindAllStringSubmatchIndex(normalizedContent, -1) {
+		if enumMatch[0] < searchFrom || braceDepthAt(normalizedContent, enumMatch[0]) != 0 {
+			continue // Nested enums belong to their enclosing class
+		}
+		enumEnd := findClassEnd(normalizedContent, enumMatch[1])
+		if enumEnd == -1 {
+			continue
+		}
+		enums = append(enums, EnumDeclaration{
+			Name:   normalizedContent[enumMatch[2]:enumMatch[3]],
+			Source: normalizedContent[enumMatch[0] : enumEnd+1],
+		})
+		searchFrom = enumEnd + 1
+	}
+	return enums
+}
+
+// braceDepthAt returns how many braces are open at idx, ignoring braces inside literals.
+func braceDepthAt(input string, idx int) int {
+	depth := 0
+	for i := 0; i < idx && i < len(input); i++ {
+		switch input[i] {
+		case '"', '\'':
+			i = skipLiteral(input, i) - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// extractMethods extracts the public methods, and protected ones if configured, with allowed return and parameter
+// types from the class content, reporting the methods it skips to warnings. lineAt maps positions in the class
+// content to lines of the file, and javadocAt to the Javadoc comment documenting the declaration there.
+func extractMethods(classContent string, className string, config parseConfig, warnings *parseWarnings, lineAt func(int) int, javadocAt func(int) string) []PublicMethod {
+	types := config.types
+	var declaredMethods []PublicMethod
+	for _, loc := range methodPattern.FindAllStringSubmatchIndex(classContent, -1) {
+		match := submatches(classContent, loc)
+		line := lineAt(loc[8])
+		if len(match) >= 7 {
+			// Skip methods that are not public, or protected when protected members are included
+			modifiers := strings.Fields(match[1])
+			accessModifier, ok := config.accessModifier(modifiers)
+			if !ok {
+				continue
+			}
+
+			// Skip constructors, whose "return type" is really a modifier or type parameter list
+			if isConstructorMatch(match[3], match[4], className) {
+				continue
+			}
+
+			// Skip generic methods, whose type variables cannot be declared in the stub
+			if match[2] != "" {
+				typeParameters := strings.Join(strings.Fields(match[2]), " ")
+				warnings.addSkippedMethod(line, className, match[4], RuleGenericMethod, fmt.Sprintf("generic method with type parameters %s not supported", typeParameters))
+				continue
+			}
+
+			// Check if return type is allowed, keeping unsupported object types in lenient mode
+			returnType := types.resolveTypeName(normalizeArrayType(match[3], ""))
+			isLenient := false
+			if !types.isReturnTypeAllowed(returnType) {
+				if !types.keepsLeniently(returnType) {
+					warnings.addSkippedMethod(line, className, match[4], RuleUnsupportedReturnType, fmt.Sprintf("return type %s not supported", returnType))
+					continue // Skip this method if return type is not allowed
+				}
+				returnType = types.qualifyTypeName(returnType)
+				isLenient = true
+			}
+			parameters := extractParameters(match[5], types)
+
+			// Check if all parameter types are valid
+			skipped := false
+			for i, param := range parameters {
+				switch {
+				case types.isTypeAllowed(param.Type):
+				case types.keepsLeniently(param.Type):
+					parameters[i].Type = types.qualifyTypeName(param.Type)
+					isLenient = true
+				case param.IsVarargs:
+					// Varargs element types are easy to overlook in a signature, so they are named as such
+					warnings.addSkippedMethod(line, className, match[4], RuleUnsupportedParameterType, fmt.Sprintf("varargs element type %s not supported", param.Type))
+					skipped = true
+				default:
+					warnings.addSkippedMethod(line, className, match[4], RuleUnsupportedParameterType, fmt.Sprintf("parameter type %s not supported", param.Type))
+					skipped = true
+				}
+			}
+			if skipped {
+				continue // Skip this method if an invalid parameter type is found
+			}
+
+			declaredMethods = append(declaredMethods, PublicMethod{
+				AccessModifier: accessModifier,
+				IsStatic:       slices.Contains(modifiers, "static"),
+				IsFinal:        slices.Contains(modifiers, "final"),
+				IsDeprecated:   slices.Contains(modifiers, "@Deprecated") || isJavadocDeprecated(javadocAt(loc[0])),
+				ReturnType:     returnType,
+				MethodName:     match[4],
+				Parameters:     parameters,
+				Exceptions:     extractExceptions(match[6], types),
+				IsLenient:      isLenient,
+				Javadoc:        javadocAt(loc[0]),
+				Line:           line,
+			})
+		}
+	}
+	return declaredMethods
+}
+
+// submatches returns the text of each submatch located by a FindStringSubmatchIndex-style index slice, with
+// unmatched optional groups as empty strings.
+func submatches(content string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = content[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
+// extractExceptions splits a throws clause into its exception types, qualified with their imports since the stubs
+// import nothing.
+func extractExceptions(throwsClause string, types typeTable) []string {
+	var exceptions []string
+	for _, exception := range strings.Split(throwsClause, ",") {
+		if exception = strings.ReplaceAll(exception, " ", ""); exception != "" {
+			exceptions = append(exceptions, types.qualifyTypeName(exception))
+		}
+	}
+	return exceptions
+}
+
+// extractConstructors extracts the public constructors declared by the class content.
+func extractConstructors(classContent string, className string, types typeTable) []PublicConstructor {
+	var declaredConstructors []PublicConstructor
+	for _, match := range constructorPattern.FindAllStringSubmatch(classContent, -1) {
+		if len(match) >= 3 && match[1] == className {
+			declaredConstructors = append(declaredConstructors, PublicConstructor{
+				AccessModifier: "public",
+				Parameters:     extractParameters(match[2], types),
+			})
+		}
+	}
+	return declaredConstructors
+}
+
+// isConstructorMatch reports whether a methodPattern match is actually a constructor declaration:
+// it is named after the class and what was captured as its return type is not a type at all.
+func isConstructorMatch(returnType string, methodName string, className string) bool {
+	if methodName != className {
+		return false
+	}
+	switch returnType {
+	case "static", "final", "abstract", "synchronized", "native", "strictfp":
+		return true
+	}
+	return strings.HasPrefix(returnType, "<")
+}
+
+// extractFields extracts the public fields, and protected ones if configured, with allowed types from the class
+// content, reporting the fields it skips to warnings. lineAt maps positions in the class content to lines of the file,
+// and javadocAt to the Javadoc comment documenting the declaration there.
+func extractFields(classContent string, className string, config parseConfig, warnings *parseWarnings, lineAt func(int) int, javadocAt func(int) string) []PublicField {
+	types := config.types
+	var declaredFields []PublicField
+	statementEnd := 0
+	for _, match := range publicFieldPattern.FindAllStringSubmatchIndex(classContent, -1) {
+		if match[0] < statementEnd {
+			continue // Inside the initializer of the previous field statement
+		}
+
+		// The declarators run until the semicolon ending the statement
+		statementEnd = findStatementEnd(classContent, match[6])
+		if statementEnd == -1 {
+			break
+		}
+
+		// Skip fields that are not public, or protected when protected members are included
+		modifiers := strings.Fields(classContent[match[2]:match[3]])
+		accessModifier, ok := config.accessModifier(modifiers)
+		if !ok {
+			continue
+		}
+		fieldTypeName := normalizeTypeArguments(classContent[match[4]:match[5]])
+
+		// Each declarator in a statement such as "public int x = 1, y;" declares its own field
+		line := lineAt(match[6])
+		for _, declarator := range splitDeclarators(classContent[match[6]:statementEnd]) {
+			declaratorMatch := declaratorPattern.FindStringSubmatch(declarator)
+			if declaratorMatch == nil {
+				continue
+			}
+
+			// Check if field type is allowed
+			fieldType := types.resolveTypeName(normalizeArrayType(fieldTypeName, declaratorMatch[2]))
+			if !types.isTypeAllowed(fieldType) {
+				warnings.add(line, className, declaratorMatch[1], RuleUnsupportedFieldType, fmt.Sprintf("type %s not supported", fieldType))
+				continue // Skip this field if its type is not allowed
+			}
+			field := PublicField{
+				AccessModifier: accessModifier,
+				IsStatic:       slices.Contains(modifiers, "static"),
+				IsFinal:        slices.Contains(modifiers, "final"),
+				IsDeprecated:   slices.Contains(modifiers, "@Deprecated") || isJavadocDeprecated(javadocAt(match[0])),
+				Type:           fieldType,
+				Name:           declaratorMatch[1],
+				Line:           line,
+			}
+
+			// Constants keep their value, since consumers compile against it and final fields need one
+			if field.IsStatic && field.IsFinal {
+				field.Initializer = declaratorMatch[3]
+			}
+			declaredFields = append(declaredFields, field)
+		}
+	}
+	return declaredFields
+}
+
+// findStatementEnd returns the index of the semicolon ending the statement that contains start, skipping
+// semicolons nested inside parentheses, braces, or literals, or -1 if the statement is not terminated.
+func findStatementEnd(input string, start int) int {
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch input[i] {
+		case '"', '\'':
+			i = skipLiteral(input, i) - 1
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+			if depth < 0 {
+				return -1 // Left the enclosing block without finding a semicolon
+			}
+		case ';':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitDeclarators splits the declarators of a field statement on the commas that are not nested inside
+// parentheses, braces, or literals of their initializers.
+func splitDeclarators(declarators string) []string {
+	var parts []string
+	depth := 0
+	last := 0
+	for i := 0; i < len(declarators); i++ {
+		switch declarators[i] {
+		case '"', '\'':
+			i = skipLiteral(declarators, i) - 1
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, declarators[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, declarators[last:])
+}
+
+// skipTypeArguments returns the index just past a generic type argument list starting at or after start
+// (ignoring leading whitespace), or start itself if there is none. Nested argument lists are balanced.
+func skipTypeArguments(input string, start int) int {
+	i := start
+	for i < len(input) && input[i] == ' ' {
+		i++
+	}
+	if i >= len(input) || input[i] != '<' {
+		return start
+	}
+	depth := 0
+	for ; i < len(input); i++ {
+		switch input[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '{', ';':
+			// Not a type argument list after all
+			return start
+		}
+	}
+	return start
+}
+
+// qualifiedTypeName removes the whitespace normalization may leave around the dots of a qualified type name.
+func qualifiedTypeName(typeName string) string {
+	return strings.NewReplacer(" . ", ".", " .", ".", ". ", ".").Replace(strings.TrimSpace(typeName))
+}
+
+// splitTypeList splits the comma-separated type list starting at start, such as an implements clause, up to the
+// brace opening the class body. It returns the types as spelled, including any type arguments, and the position of
+// the brace.
+func splitTypeList(input string, start int) ([]string, int) {
+	var types []string
+	depth := 0
+	typeStart := start
+	i := start
+	for ; i < len(input); i++ {
+		switch input[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',', '{':
+			if depth > 0 {
+				continue
+			}
+			if typeName := qualifiedTypeName(input[typeStart:i]); typeName != "" {
+				types = append(types, typeName)
+			}
+			if input[i] == '{' {
+				return types, i
+			}
+			typeStart = i + 1
+		}
+	}
+	return types, i
+}
+
+// findClassEnd returns the index of the brace closing the class body that opens after declEnd, or -1 if there is none.
+func findClassEnd(input string, declEnd int) int {
+	braceIdx := strings.Index(input[declEnd:], "{")
+	if braceIdx == -1 {
+		return -1
+	}
+	return findMatchingBrace(input, declEnd+braceIdx)
+}
+
+// findMatchingBrace returns the index of the brace closing the one at openIdx, or -1 if the braces are unbalanced.
+// Braces inside string, text block, and char literals are ignored.
+func findMatchingBrace(input string, openIdx int) int {
+	return findMatchingDelimiter(input, openIdx, '{', '}')
+}
+
+// findMatchingDelimiter returns the index of the close delimiter matching the open delimiter at openIdx, or -1 if
+// the delimiters are unbalanced. Delimiters inside string, text block, and char literals are ignored.
+func findMatchingDelimiter(input string, openIdx int, open byte, close byte) int {
+	count := 0
+	for i := openIdx; i < len(input); i++ {
+		switch input[i] {
+		case '"', '\'':
+			i = skipLiteral(input, i) - 1
+		case open:
+			count++
+		case close:
+			count--
+			if count == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// nestedType is a type declared inside a class body.
+type nestedType struct {
+	modifiers []string // The modifiers of the type declaration
+	kind      string   // The kind of type: class, interface, enum, record, or @interface
+	name      string   // The name of the type
+	content   string   // The declaration from its modifiers to its closing brace
+	offset    int      // Position of the declaration in the enclosing class content
+}
+
+// splitClassBody walks the member level of the class content, moving nested type declarations out of it and
+// replacing every other brace block (method bodies, initializer blocks, and anonymous classes or lambdas within
+// field initializers) with an empty "{}" pair, so that only member-level declarations remain. Array initializers
+// of fields are kept. The returned offsetMap maps positions in the remaining content back to the class content.
+func splitClassBody(classContent string) (string, []nestedType, offsetMap) {
+	openIdx := strings.Index(classContent, "{")
+	if openIdx == -1 {
+		return classContent, nil, nil
+	}
+
+	var nestedTypes []nestedType
+	output := []byte(classContent[:openIdx+1])
+	offsets := offsetMap{{out: 0, in: 0}}
+
+	// The current member declaration starts at memberStart in the output and at headerStart in the input
+	memberStart, headerStart := len(output), openIdx+1
+
+	for i := openIdx + 1; i < len(classContent); i++ {
+		offsets.mark(len(output), i)
+		switch c := classContent[i]; c {
+		case '"', '\'':
+			// Copy member-level literals such as field initializers verbatim
+			end := skipLiteral(classContent, i)
+			output = append(output, classContent[i:end]...)
+			i = end - 1
+		case ';':
+			output = append(output, c)
+			memberStart, headerStart = len(output), i+1
+		case '{':
+			closeIdx := findMatchingBrace(classContent, i)
+			if closeIdx == -1 {
+				// Unbalanced braces, keep the remainder as is
+				return string(append(output, classContent[i:]...)), nestedTypes, offsets
+			}
+
+			header := string(output[memberStart:])
+			if match := nestedTypeHeaderPattern.FindStringSubmatchIndex(header); match != nil {
+				// Move the whole nested type declaration out of the class content
+				nestedTypes = append(nestedTypes, nestedType{
+					modifiers: strings.Fields(header[match[2]:match[3]]),
+					kind:      header[match[4]:match[5]],
+					name:      header[match[6]:match[7]],
+					content:   classContent[headerStart+match[2] : closeIdx+1],
+					offset:    headerStart + match[2],
+				})
+				output = output[:memberStart+match[2]]
+				offsets.truncate(len(output))
+				memberStart, headerStart = len(output), closeIdx+1
+			} else if isArrayInitializer(classContent, i) {
+				// Keep array initializers of field declarations, which constants may need verbatim
+				output = append(output, classContent[i:closeIdx+1]...)
+			} else {
+				output = append(output, "{}"...)
+				memberStart, headerStart = len(output), closeIdx+1
+			}
+			i = closeIdx
+		default:
+			output = append(output, c)
+		}
+	}
+	return string(output), nestedTypes, offsets
+}
+
+// normalizeWhitespace collapses every run of whitespace outside of literals into a single space and trims the ends.
+// String, text block, and char literals are copied verbatim so that constant values are not altered. The returned
+// offsetMap maps positions in the result back to the input.
+func normalizeWhitespace(input string) (string, offsetMap) {
+	var builder strings.Builder
+	builder.Grow(len(input))
+	var offsets offsetMap
+
+	pendingSpace := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch c {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			pendingSpace = builder.Len() > 0
+			continue
+		}
+		if pendingSpace {
+			offsets.mark(builder.Len(), i-1)
+			builder.WriteByte(' ')
+			pendingSpace = false
+		}
+		offsets.mark(builder.Len(), i)
+		if c == '"' || c == '\'' {
+			end := skipLiteral(input, i)
+			builder.WriteString(input[i:end])
+			i = end - 1
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String(), offsets
+}
+
+// isSerializable reports whether the class implements Serializable directly.
+func isSerializable(sso *ServerSideObject) bool {
+	for _, iface := range sso.Interfaces {
+		if simpleTypeName(iface) == "Serializable" {
+			return true
+		}
+	}
+	return false
+}
+
+// extractSerialVersionUID returns the value of the static final serialVersionUID declared in the member content of
+// a class, as spelled, or an empty string if there is none.
+func extractSerialVersionUID(memberContent string) string {
+	for _, match := range serialVersionUIDPattern.FindAllStringSubmatch(memberContent, -1) {
+		modifiers := strings.Fields(match[1])
+		if slices.Contains(modifiers, "static") && slices.Contains(modifiers, "final") {
+			return match[2]
+		}
+	}
+	return ""
+}
+
+// isDeclarationDeprecated reports whether the declaration starting at start in the content is annotated @Deprecated,
+// looking at the annotations between it and the end of the previous declaration.
+func isDeclarationDeprecated(content string, start int) bool {
+	annotationsStart := strings.LastIndexAny(content[:start], ";{}") + 1
+	return deprecatedAnnotationPattern.MatchString(content[annotationsStart:start])
+}
+
+// isJavadocDeprecated reports whether a Javadoc comment has a @deprecated tag.
+func isJavadocDeprecated(javadoc string) bool {
+	return deprecatedTagPattern.MatchString(javadoc)
+}
+
+// stripAnnotations removes annotations, including their argument lists, from the content. @Deprecated annotations
+// are kept without their arguments so that deprecated members can still be recognized. The returned offsetMap maps
+// positions in the result back to the content.
+func stripAnnotations(content string) (string, offsetMap) {
+	var builder strings.Builder
+	builder.Grow(len(content))
+	var offsets offsetMap
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		offsets.mark(builder.Len(), i)
+		switch {
+		case c == '"' || c == '\'':
+			// Copy literals verbatim so that an @ inside them is not mistaken for an annotation
+			end := skipLiteral(content, i)
+			builder.WriteString(content[i:end])
+			i = end - 1
+		case c == '@' && !strings.HasPrefix(content[i:], "@interface"):
+			// The annotation name may be qualified
+			end := i + 1
+			for end < len(content) && (isIdentifierChar(content[end]) || content[end] == '.') {
+				end++
+			}
+			name := content[i+1 : end]
+
+			// Skip the argument list, which may itself contain parentheses, commas, and literals
+			argsStart := end
+			for argsStart < len(content) && content[argsStart] == ' ' {
+				argsStart++
+			}
+			if argsStart < len(content) && content[argsStart] == '(' {
+				if argsEnd := findMatchingDelimiter(content, argsStart, '(', ')'); argsEnd != -1 {
+					end = argsEnd + 1
+				}
+			}
+
+			if simpleTypeName(name) == "Deprecated" {
+				builder.WriteString("@Deprecated ")
+			} else {
+				builder.WriteByte(' ')
+			}
+			i = end - 1
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String(), offsets
+}
+
+// isIdentifierChar reports whether c may appear in a Java identifier.
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isArrayInitializer reports whether the brace at openIdx starts an array initializer, which follows either the
+// assignment of a field declaration or the dimensions of an array creation expression.
+func isArrayInitializer(input string, openIdx int) bool {
+	prev := strings.TrimRight(input[:openIdx], " ")
+	return strings.HasSuffix(prev, "=") || strings.HasSuffix(prev, "]")
+}
+
+// stripComments replaces line and block comments (including Javadoc) with whitespace, leaving string and char literals untouched.
+// Newlines inside comments are kept so the line structure of the source is preserved.
+func stripComments(input string) string {
+	var builder strings.Builder
+	builder.Grow(len(input))
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '"' || c == '\'':
+			// Copy the literal verbatim, honoring escape sequences
+			end := skipLiteral(input, i)
+			builder.WriteString(input[i:end])
+			i = end - 1
+		case c == '/' && i+1 < len(input) && input[i+1] == '/':
+			// Line comment runs until the end of the line
+			for i < len(input) && input[i] != '\n' {
+				builder.WriteByte(' ')
+				i++
+			}
+			if i < len(input) {
+				builder.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(input) && input[i+1] == '*':
+			// Block comment runs until the closing */
+			end := strings.Index(input[i+2:], "*/")
+			if end == -1 {
+				end = len(input)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if input[i] == '\n' {
+					builder.WriteByte('\n')
+				} else {
+					builder.WriteByte(' ')
+				}
+			}
+			i--
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// skipLiteral returns the index just past the string, text block, or char literal starting at start.
+func skipLiteral(input string, start int) int {
+	quote := input[start]
+
+	// Text blocks are delimited by triple quotes and may span lines
+	if quote == '"' && strings.HasPrefix(input[start:], `"""`) {
+		i := start + 3
+		for i < len(input) {
+			if input[i] == '\\' {
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(input[i:], `"""`) {
+				return i + 3
+			}
+			i++
+		}
+		return len(input)
+	}
+
+	i := start + 1
+	for i < len(input) {
+		switch input[i] {
+		case '\\':
+			i += 2
+			continue
+		case quote:
+			return i + 1
+		case '\n':
+			// Unterminated literal, stop at the end of the line
+			return i
+		}
+		i++
+	}
+	return len(input)
+}
+
 // Helper function to extract parameters from a method signature
-func extractParameters(paramString string) []Parameter {
+func extractParameters(paramString string, types typeTable) []Parameter {
 	var parameters []Parameter
-	if paramString == "" {
+	if strings.TrimSpace(paramString) == "" {
 		return parameters // No parameters
 	}
 
-	paramPairs := strings.Split(paramString, ",")
-	for _, pair := range paramPairs {
+	for _, pair := range splitTopLevel(paramString, ',') {
 		parts := strings.Fields(strings.TrimSpace(pair))
 		if len(parts) >= 2 {
 			// Remove allowed parameter modifiers (final, annotations)
@@ -153,57 +1485,77 @@ func extractParameters(paramString string) []Parameter {
 					break
 				}
 			}
-			// The type is at parts[j], the name is at parts[j+1]
+			// The type spans parts[j] up to the name, which is always the last part
+			name := parts[len(parts)-1]
+			paramType := strings.Join(parts[j:len(parts)-1], " ")
+			nameBrackets := ""
+			if bracketIdx := strings.Index(name, "["); bracketIdx != -1 {
+				// Move brackets declared on the name (int values[]) onto the type
+				name, nameBrackets = name[:bracketIdx], name[bracketIdx:]
+			}
+
+			// A varargs ellipsis may be attached to the type, the name, or stand alone
+			isVarargs := strings.HasPrefix(name, "...") || strings.HasSuffix(paramType, "...")
+			name = strings.TrimPrefix(name, "...")
+			paramType = strings.TrimSpace(strings.TrimSuffix(paramType, "..."))
+
 			parameters = append(parameters, Parameter{
-				Type: parts[j],
-				Name: parts[j+1],
+				Type:      types.resolveTypeName(normalizeArrayType(paramType, nameBrackets)),
+				Name:      name,
+				IsVarargs: isVarargs,
 			})
 		}
 	}
 	return parameters
 }
 
-// areParametersValid checks if all parameter types are in the allowed list.
-func areParametersValid(parameters []Parameter) bool {
-	for _, param := range parameters {
-		if _, ok := allowedTypes[param.Type]; !ok {
-			return false
-		}
+// normalizeArrayType appends any brackets declared on a variable name to its type and removes whitespace
+// around the brackets, so that "int [ ]" with name brackets "[]" becomes "int[][]".
+func normalizeArrayType(typeName string, nameBrackets string) string {
+	bracketIdx := strings.Index(typeName, "[")
+	if bracketIdx == -1 && nameBrackets == "" {
+		return typeName
+	}
+	if bracketIdx == -1 {
+		bracketIdx = len(typeName)
 	}
-	return true
+	dimensions := strings.Count(typeName[bracketIdx:], "[") + strings.Count(nameBrackets, "[")
+	return strings.TrimSpace(typeName[:bracketIdx]) + strings.Repeat("[]", dimensions)
 }
 
-// removePrivateClasses removes all private class definitions (with nested braces) from the input string.
-func removePrivateClasses(input string) string {
-	for {
-		startIdx := strings.Index(input, "private class ")
-		if startIdx == -1 {
-			break
-		}
-		// Find the opening brace for the class
-		braceIdx := strings.Index(input[startIdx:], "{")
-		if braceIdx == -1 {
-			break
-		}
-		braceIdx += startIdx
-		// Use a counter to find the matching closing brace
-		count := 1
-		endIdx := braceIdx + 1
-		for endIdx < len(input) && count > 0 {
-			if input[endIdx] == '{' {
-				count++
-			} else if input[endIdx] == '}' {
-				count--
-			}
-			endIdx++
-		}
-		// Remove the private class definition
-		if count == 0 {
-			input = input[:startIdx] + input[endIdx:]
-		} else {
-			// Unmatched braces, break to avoid infinite loop
-			break
+// normalizeTypeArguments respaces the type arguments of a generic type the way they are usually written, so that
+// "Map< String ,List<Integer> >" becomes "Map<String, List<Integer>>".
+func normalizeTypeArguments(typeName string) string {
+	start, end := strings.Index(typeName, "<"), strings.LastIndex(typeName, ">")
+	if start == -1 || end < start {
+		return typeName
+	}
+	var arguments []string
+	for _, argument := range splitTopLevel(typeName[start+1:end], ',') {
+		arguments = append(arguments, normalizeTypeArguments(strings.TrimSpace(argument)))
+	}
+	return strings.TrimSpace(typeName[:start]) + "<" + strings.Join(arguments, ", ") + ">" + strings.TrimSpace(typeName[end+1:])
+}
+
+// splitTopLevel splits the input on sep, ignoring separators nested inside generic angle brackets.
+func splitTopLevel(input string, sep byte) []string {
+	var parts []string
+	depth := 0
+	last := 0
+	for i := 0; i < len(input); i++ {
+		switch input[i] {
+		case '<':
+			depth++
+		case '>':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, input[last:i])
+				last = i + 1
+			}
 		}
 	}
-	return input
+	return append(parts, input[last:])
 }
@@ -0,0 +1,1200 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// parseTestSSO parses the source of a single file, failing the test if it does not declare an SSO.
+func parseTestSSO(t *testing.T, src string) (*ServerSideObject, []Warning) {
+	t.Helper()
+	sso, warnings, err := ParseSSOSource("Test.java", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseSSOSource: %v", err)
+	}
+	if sso == nil {
+		t.Fatalf("ParseSSOSource found no SSO in:\n%s", src)
+	}
+	return sso, warnings
+}
+
+// scanTestFS scans an in-memory tree of files with the options, failing the test if the scan fails.
+func scanTestFS(t *testing.T, files map[string]string, options ...ScanOption) ServerSideObjectList {
+	t.Helper()
+	ssos, err := ScanForSSOsFS(testFS(files), ".", options...)
+	if err != nil {
+		t.Fatalf("ScanForSSOsFS: %v", err)
+	}
+	return ssos
+}
+
+// testFS builds an in-memory tree from the sources of its files, by path.
+func testFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS, len(files))
+	for path, src := range files {
+		fsys[path] = &fstest.MapFile{Data: []byte(src)}
+	}
+	return fsys
+}
+
+// failingFS is a filesystem whose files named in failures cannot be opened, standing in for unreadable files.
+type failingFS struct {
+	fs.FS
+	failures map[string]error
+}
+
+// Open fails for the files named in failures, and otherwise opens the file from the wrapped filesystem.
+func (f failingFS) Open(name string) (fs.File, error) {
+	if err, ok := f.failures[name]; ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f.FS.Open(name)
+}
+
+// countingFS is a filesystem that counts the files and directories open at once, to check that a scan closes what
+// it opens as it goes.
+type countingFS struct {
+	fs.FS
+	mu      sync.Mutex
+	open    int // The files open now
+	maxOpen int // The most files open at once so far
+}
+
+// Open opens the file from the wrapped filesystem, counting it as open until it is closed.
+func (f *countingFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.open++
+	f.maxOpen = max(f.maxOpen, f.open)
+	f.mu.Unlock()
+	return &countedFile{File: file, fsys: f}, nil
+}
+
+// countedFile is a file opened through a countingFS.
+type countedFile struct {
+	fs.File
+	fsys   *countingFS
+	closed bool
+}
+
+// Close closes the file, counting it as closed the first time.
+func (f *countedFile) Close() error {
+	f.fsys.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		f.fsys.open--
+	}
+	f.fsys.mu.Unlock()
+	return f.File.Close()
+}
+
+// ReadDir lists the directory when the wrapped file is one, so that the walk can read it.
+func (f *countedFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	dir, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Err: errors.New("not a directory")}
+	}
+	return dir.ReadDir(n)
+}
+
+// syntheticTree returns an in-memory tree of files SSOs spread over packages of 100 files each.
+func syntheticTree(files int) fstest.MapFS {
+	fsys := make(fstest.MapFS, files)
+	for i := 0; i < files; i++ {
+		className := fmt.Sprintf("Generated%d", i)
+		fsys[fmt.Sprintf("pkg%d/%s.java", i/100, className)] = &fstest.MapFile{Data: []byte(testSSOSource(className, "size"))}
+	}
+	return fsys
+}
+
+// testSSOSource returns the source of an SSO in com.example declaring a single int method.
+func testSSOSource(className string, methodName string) string {
+	return "package com.example;\n\npublic class " + className + " extends ServerSideObject {\n    public int " + methodName + "() { return 0; }\n}\n"
+}
+
+// classNames returns the class names of the SSOs in order.
+func classNames(ssos []ServerSideObject) []string {
+	var names []string
+	for _, sso := range ssos {
+		names = append(names, sso.ClassName)
+	}
+	return names
+}
+
+// warningStrings returns the warnings formatted without their paths and lines.
+func warningStrings(warnings []Warning) []string {
+	var formatted []string
+	for _, warning := range warnings {
+		formatted = append(formatted, warning.String())
+	}
+	return formatted
+}
+
+// methodSignatures returns the declared methods of an SSO as "returnType name(parameterTypes)", leaving out the
+// getLastError method every SSO inherits.
+func methodSignatures(sso *ServerSideObject) []string {
+	var signatures []string
+	for _, method := range sso.DeclaredMethods {
+		if method.MethodName == "getLastError" && method.Line == 0 {
+			continue
+		}
+		paramTypes := make([]string, len(method.Parameters))
+		for i, param := range method.Parameters {
+			paramTypes[i] = param.Type
+			if param.IsVarargs {
+				paramTypes[i] += "..."
+			}
+		}
+		signatures = append(signatures, method.ReturnType+" "+method.MethodName+"("+strings.Join(paramTypes, ", ")+")")
+	}
+	return signatures
+}
+
+func TestParseSSOSourceKeepsVoidMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "without parameters",
+			body: "public void start() { }",
+			want: []string{"void start()"},
+		},
+		{
+			name: "with parameters",
+			body: "public void stop(int code, String reason) { System.exit(code); }",
+			want: []string{"void stop(int, String)"},
+		},
+		{
+			name: "only void methods",
+			body: "public void open() { } public void close() { }",
+			want: []string{"void open()", "void close()"},
+		},
+		{
+			name: "void parameter type",
+			body: "public void start() { } public int broken(void value) { return 0; }",
+			want: []string{"void start()"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceIgnoresComments(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "line comment",
+			body: "// public int old() { return 0; }\n    public int current() { return 1; }",
+			want: []string{"int current()"},
+		},
+		{
+			name: "block comment",
+			body: "/* public int old() { return 0; }\n    public int older() { return 0; } */\n    public int current() { return 1; }",
+			want: []string{"int current()"},
+		},
+		{
+			name: "javadoc",
+			body: "/**\n     * Replaces {@code public int old()}.\n     */\n    public int current() { return 1; }",
+			want: []string{"int current()"},
+		},
+		{
+			name: "comment markers in string literals",
+			body: "public String url() { return \"http://example.com/*\"; }\n    public int current() { return 1; }",
+			want: []string{"String url()", "int current()"},
+		},
+		{
+			name: "comment markers in char literals",
+			body: "public char slash() { return '/'; }\n    public char star() { return '*'; }",
+			want: []string{"char slash()", "char star()"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceIgnoresCommentedOutClasses(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "line comment", src: "package com.example;\n\n// public class Old extends ServerSideObject {\n// }\n"},
+		{name: "block comment", src: "package com.example;\n\n/*\npublic class Old extends ServerSideObject {\n}\n*/\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _, err := ParseSSOSource("Old.java", []byte(test.src))
+			if err != nil {
+				t.Fatalf("ParseSSOSource: %v", err)
+			}
+			if sso != nil {
+				t.Errorf("found SSO %s in a comment", sso.ClassName)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceSplitsGenericParameters(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantMethods  []string
+		wantWarnings []string
+	}{
+		{
+			name:         "type arguments with commas",
+			body:         "public int count(Map<String, Integer> counts, String key) { return 0; }",
+			wantWarnings: []string{"Foo.count: parameter type Map<String, Integer> not supported"},
+		},
+		{
+			name:         "nested type arguments",
+			body:         "public int pair(Map<String, Map<String, Integer>> nested, int n) { return 0; }",
+			wantWarnings: []string{"Foo.pair: parameter type Map<String, Map<String, Integer>> not supported"},
+		},
+		{
+			name:         "other methods unaffected",
+			body:         "public int count(Map<String, Integer> counts) { return 0; }\n    public int add(int a, String b) { return 0; }",
+			wantMethods:  []string{"int add(int, String)"},
+			wantWarnings: []string{"Foo.count: parameter type Map<String, Integer> not supported"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, warnings := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.wantMethods) {
+				t.Errorf("methods = %q, want %q", got, test.wantMethods)
+			}
+			if got := warningStrings(warnings); !slices.Equal(got, test.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got, test.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestScanSplitsGenericParametersLeniently(t *testing.T) {
+	ssos := scanTestFS(t, map[string]string{
+		"Foo.java": "package com.example;\n\npublic class Foo extends ServerSideObject {\n    public int count(Map<String, Integer> counts, String key) { return 0; }\n}\n",
+	}, WithLenient(true))
+	if len(ssos) != 1 {
+		t.Fatalf("found %d SSOs, want 1", len(ssos))
+	}
+	want := []string{"int count(Map<String, Integer>, String)"}
+	if got := methodSignatures(&ssos[0]); !slices.Equal(got, want) {
+		t.Errorf("methods = %q, want %q", got, want)
+	}
+}
+
+func TestParseSSOSourceIgnoresBracesInLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "closing brace in string",
+			body: `public String close() { return "}"; }` + "\n    public int after() { return 0; }",
+			want: []string{"String close()", "int after()"},
+		},
+		{
+			name: "opening brace in string",
+			body: `public String open() { return "{"; }` + "\n    public int after() { return 0; }",
+			want: []string{"String open()", "int after()"},
+		},
+		{
+			name: "escaped quotes around braces",
+			body: `public String json() { return "{\"a\": \"}\"}"; }` + "\n    public int after() { return 0; }",
+			want: []string{"String json()", "int after()"},
+		},
+		{
+			name: "braces in char literals",
+			body: "public char open() { return '{'; }\n    public char close() { return '}'; }\n    public int after() { return 0; }",
+			want: []string{"char open()", "char close()", "int after()"},
+		},
+		{
+			name: "brace in string after the last method",
+			body: "public int before() { return 0; }\n    public static final String END = \"}\";",
+			want: []string{"int before()"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceSkipsMethodBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "anonymous class in a method body",
+			body: "public int schedule() { Runnable task = new Runnable() { public void run() { } }; return 0; }\n    public int after() { return 0; }",
+			want: []string{"int schedule()", "int after()"},
+		},
+		{
+			name: "local class in a method body",
+			body: "public int count() { class Counter { public int next() { return 1; } } return 0; }",
+			want: []string{"int count()"},
+		},
+		{
+			name: "static initializer",
+			body: "static { Runnable task = new Runnable() { public void run() { } }; }\n    public int after() { return 0; }",
+			want: []string{"int after()"},
+		},
+		{
+			name: "instance initializer",
+			body: "{ Runnable task = new Runnable() { public void run() { } }; }\n    public int after() { return 0; }",
+			want: []string{"int after()"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceSkipsFieldsInMethodBodies(t *testing.T) {
+	sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    public int size;\n    public int make() { Object o = new Object() { public int hidden; }; return 0; }\n}\n")
+	var names []string
+	for _, field := range sso.DeclaredFields {
+		names = append(names, field.Name)
+	}
+	if want := []string{"size"}; !slices.Equal(names, want) {
+		t.Errorf("fields = %q, want %q", names, want)
+	}
+}
+
+func TestParseSSOSourceSeparatesConstructors(t *testing.T) {
+	tests := []struct {
+		name             string
+		className        string
+		body             string
+		wantConstructors []string
+	}{
+		{
+			name:             "public without parameters",
+			className:        "Foo",
+			body:             "public Foo() { }",
+			wantConstructors: []string{"()"},
+		},
+		{
+			name:             "parameterized",
+			className:        "Foo",
+			body:             "public Foo(int size, String name) { this.size = size; }",
+			wantConstructors: []string{"(int, String)"},
+		},
+		{
+			name:             "generic",
+			className:        "Foo",
+			body:             "public <T> Foo(T value) { }",
+			wantConstructors: []string{"(T)"},
+		},
+		{
+			name:             "overloaded",
+			className:        "Foo",
+			body:             "public Foo() { }\n    public Foo(long id) { }",
+			wantConstructors: []string{"()", "(long)"},
+		},
+		{
+			name:             "class named after an allowed type",
+			className:        "Integer",
+			body:             "public Integer(int value) { }",
+			wantConstructors: []string{"(int)"},
+		},
+		{
+			name:      "not public",
+			className: "Foo",
+			body:      "protected Foo(int size) { }\n    Foo(String name) { }",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class "+test.className+" extends ServerSideObject {\n    "+test.body+"\n    public int size() { return 0; }\n}\n")
+			if got, want := methodSignatures(sso), []string{"int size()"}; !slices.Equal(got, want) {
+				t.Errorf("methods = %q, want %q", got, want)
+			}
+			var constructors []string
+			for _, constructor := range sso.DeclaredConstructors {
+				paramTypes := make([]string, len(constructor.Parameters))
+				for i, param := range constructor.Parameters {
+					paramTypes[i] = param.Type
+				}
+				constructors = append(constructors, "("+strings.Join(paramTypes, ", ")+")")
+			}
+			if !slices.Equal(constructors, test.wantConstructors) {
+				t.Errorf("constructors = %q, want %q", constructors, test.wantConstructors)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceAcceptsArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "array return type",
+			body: "public byte[] blob() { return data; }",
+			want: []string{"byte[] blob()"},
+		},
+		{
+			name: "array parameter types",
+			body: "public int count(String[] names, byte[][] grid) { return 0; }",
+			want: []string{"int count(String[], byte[][])"},
+		},
+		{
+			name: "brackets after the parameter name",
+			body: "public int sum(int values[], long matrix[][]) { return 0; }",
+			want: []string{"int sum(int[], long[][])"},
+		},
+		{
+			name: "brackets split between type and name",
+			body: "public int sum(int[] grid[]) { return 0; }",
+			want: []string{"int sum(int[][])"},
+		},
+		{
+			name: "array of an unsupported type",
+			body: "public Object[] all() { return null; }\n    public int size() { return 0; }",
+			want: []string{"int size()"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceAcceptsBoxedTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "simple names",
+			body: "public Integer count(Long id, Boolean flag) { return 1; }",
+			want: []string{"Integer count(Long, Boolean)"},
+		},
+		{
+			name: "java.lang names",
+			body: "public java.lang.Integer count(java.lang.Character c) { return 1; }",
+			want: []string{"Integer count(Character)"},
+		},
+		{
+			name: "every wrapper",
+			body: "public Double convert(Byte b, Short s, Float f, Character c) { return null; }",
+			want: []string{"Double convert(Byte, Short, Float, Character)"},
+		},
+		{
+			name: "arrays of wrappers",
+			body: "public Integer[] counts(java.lang.Long[] ids) { return null; }",
+			want: []string{"Integer[] counts(Long[])"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceSkipsGenericMethods(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         []string
+		wantWarnings []string
+	}{
+		{
+			name:         "type variable return type",
+			body:         "public <T> T get(Class<T> type) { return null; }",
+			wantWarnings: []string{"Foo.get: generic method with type parameters <T> not supported"},
+		},
+		{
+			name:         "bounded type parameter",
+			body:         "public static <T extends Comparable<T>> int max(List<T> items) { return 0; }",
+			wantWarnings: []string{"Foo.max: generic method with type parameters <T extends Comparable<T>> not supported"},
+		},
+		{
+			name:         "no space before return type",
+			body:         "public <K, V>String join(K key, V value) { return null; }",
+			wantWarnings: []string{"Foo.join: generic method with type parameters <K, V> not supported"},
+		},
+		{
+			name: "generic constructor",
+			body: "public <T> Foo(T value) {}",
+		},
+		{
+			name:         "alongside a plain method",
+			body:         "public <T> void accept(T value) {}\n    public int size() { return 0; }",
+			want:         []string{"int size()"},
+			wantWarnings: []string{"Foo.accept: generic method with type parameters <T> not supported"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, warnings := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+			if got := warningStrings(warnings); !slices.Equal(got, test.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got, test.wantWarnings)
+			}
+			for _, warning := range warnings {
+				if warning.Rule != RuleGenericMethod || !warning.SkippedMethod {
+					t.Errorf("warning %q has rule %s and SkippedMethod %t, want %s and true", warning, warning.Rule, warning.SkippedMethod, RuleGenericMethod)
+				}
+			}
+		})
+	}
+}
+
+func TestScanDeduplicatesOverriddenMethods(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "getLastError overridden",
+			files: map[string]string{
+				"Foo.java": "package com.example;\n\npublic class Foo extends ServerSideObject {\n    public String getLastError() { return error; }\n}\n",
+			},
+			want: []string{"String getLastError() at line 4"},
+		},
+		{
+			name: "superclass method overridden",
+			files: map[string]string{
+				"Base.java": "package com.example;\n\npublic class Base extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+				"Foo.java":  "package com.example;\n\npublic class Foo extends Base {\n    public int size() { return 1; }\n}\n",
+			},
+			want: []string{"int size() at line 4", "String getLastError() at line 0"},
+		},
+		{
+			name: "covariant return type",
+			files: map[string]string{
+				"Base.java": "package com.example;\n\npublic class Base extends ServerSideObject {\n    public Object value(List<String> keys) { return null; }\n}\n",
+				"Foo.java":  "package com.example;\n\npublic class Foo extends Base {\n    public String value(List<String> keys) { return \"\"; }\n}\n",
+			},
+			want: []string{"String value(List<String>) at line 4", "String getLastError() at line 0"},
+		},
+		{
+			name: "overloads kept",
+			files: map[string]string{
+				"Base.java": "package com.example;\n\npublic class Base extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+				"Foo.java":  "package com.example;\n\npublic class Foo extends Base {\n    public int size(int scale) { return 1; }\n}\n",
+			},
+			want: []string{"int size(int) at line 4", "int size() at line 4", "String getLastError() at line 0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var foo *ServerSideObject
+			ssos := scanTestFS(t, test.files, WithLenient(true))
+			for i := range ssos {
+				if ssos[i].ClassName == "Foo" {
+					foo = &ssos[i]
+				}
+			}
+			if foo == nil {
+				t.Fatalf("Foo not found among %d SSOs", len(ssos))
+			}
+			var got []string
+			for _, method := range foo.DeclaredMethods {
+				paramTypes := make([]string, len(method.Parameters))
+				for i, param := range method.Parameters {
+					paramTypes[i] = param.Type
+				}
+				got = append(got, fmt.Sprintf("%s %s(%s) at line %d", method.ReturnType, method.MethodName, strings.Join(paramTypes, ", "), method.Line))
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceCapturesThrowsClauses(t *testing.T) {
+	tests := []struct {
+		name    string
+		imports string
+		body    string
+		want    []string
+	}{
+		{
+			name: "single exception",
+			body: "public String fetchToken(int userId) throws SSOException { return null; }",
+			want: []string{"SSOException"},
+		},
+		{
+			name: "multiple exceptions",
+			body: "public String fetchToken(int userId) throws SSOException, java.io.IOException { return null; }",
+			want: []string{"SSOException", "java.io.IOException"},
+		},
+		{
+			name: "no space before the keyword",
+			body: "public String fetchToken(int userId)throws SSOException { return null; }",
+			want: []string{"SSOException"},
+		},
+		{
+			name: "line breaks around the keyword",
+			body: "public String fetchToken(int userId)\n            throws\n            SSOException ,\n            TimeoutException\n    { return null; }",
+			want: []string{"SSOException", "TimeoutException"},
+		},
+		{
+			name:    "imported exceptions",
+			imports: "import java.io.IOException;\nimport java.util.concurrent.*;\n\n",
+			body:    "public String fetchToken(int userId) throws IOException, TimeoutException { return null; }",
+			want:    []string{"java.io.IOException", "TimeoutException"},
+		},
+		{
+			name: "no throws clause",
+			body: "public String fetchToken(int userId) { return null; }",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\n"+test.imports+"public class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got, want := methodSignatures(sso), []string{"String fetchToken(int)"}; !slices.Equal(got, want) {
+				t.Fatalf("methods = %q, want %q", got, want)
+			}
+			if got := sso.DeclaredMethods[0].Exceptions; !slices.Equal(got, test.want) {
+				t.Errorf("exceptions = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceSkipsAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "method annotation on its own line",
+			body: "@Override\n    public String describe() { return \"\"; }",
+			want: []string{"String describe()"},
+		},
+		{
+			name: "method annotation on the same line",
+			body: "public int before() { return 0; } @Override public int after() { return 0; }",
+			want: []string{"int before()", "int after()"},
+		},
+		{
+			name: "method annotation with arguments",
+			body: "@SuppressWarnings({\"unchecked\", \"rawtypes\"})\n    public int size() { return 0; }",
+			want: []string{"int size()"},
+		},
+		{
+			name: "method annotation with parentheses in a string",
+			body: "@Pattern(regexp = \"(a|b), c\") public int match() { return 0; }",
+			want: []string{"int match()"},
+		},
+		{
+			name: "parameter annotations",
+			body: "public int find(@Nullable Integer limit, @NonNull String name) { return 0; }",
+			want: []string{"int find(Integer, String)"},
+		},
+		{
+			name: "parameter annotation with commas in a string argument",
+			body: "public int find(@Size(max = 10, message = \"a, b\") String name, final int x) { return 0; }",
+			want: []string{"int find(String, int)"},
+		},
+		{
+			name: "qualified annotation",
+			body: "public int find(@javax.annotation.Nullable String name) { return 0; }",
+			want: []string{"int find(String)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			if got := methodSignatures(sso); !slices.Equal(got, test.want) {
+				t.Errorf("methods = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceRecordsDeprecatedAnnotation(t *testing.T) {
+	sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    @Deprecated public int old() { return 0; }\n    @Override public int current() { return 0; }\n}\n")
+	deprecated := make(map[string]bool)
+	for _, method := range sso.DeclaredMethods {
+		deprecated[method.MethodName] = method.IsDeprecated
+	}
+	if !deprecated["old"] || deprecated["current"] {
+		t.Errorf("deprecated = %v, want only old", deprecated)
+	}
+}
+
+func TestParseSSOSourceSplitsFieldDeclarators(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "two declarators",
+			body: "public int a, b;",
+			want: []string{"int a", "int b"},
+		},
+		{
+			name: "three declarators",
+			body: "public long c,d , e;",
+			want: []string{"long c", "long d", "long e"},
+		},
+		{
+			name: "initializers",
+			body: "public int x = 1, y = 2;",
+			want: []string{"int x", "int y"},
+		},
+		{
+			name: "some declarators initialized",
+			body: "public int x = 1, y, z = 3;",
+			want: []string{"int x", "int y", "int z"},
+		},
+		{
+			name: "commas in initializers",
+			body: "public static final String P = \"a,b\", Q = \"c\";\n    public int[] values = {1, 2}, more;",
+			want: []string{"String P", "String Q", "int[] values", "int[] more"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			var got []string
+			for _, field := range sso.DeclaredFields {
+				got = append(got, field.Type+" "+field.Name)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("fields = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceReportsGenericFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         []string
+		wantWarnings []string
+	}{
+		{
+			name:         "generic field",
+			body:         "public List<String> names;",
+			wantWarnings: []string{"Foo.names: type List<String> not supported"},
+		},
+		{
+			name:         "nested type arguments",
+			body:         "public static final Map< String ,List<Integer> > COUNTS = new HashMap<>();",
+			wantWarnings: []string{"Foo.COUNTS: type Map<String, List<Integer>> not supported"},
+		},
+		{
+			name:         "array of a generic type",
+			body:         "public List<String>[] groups, more;",
+			wantWarnings: []string{"Foo.groups: type List<String>[] not supported", "Foo.more: type List<String>[] not supported"},
+		},
+		{
+			name:         "alongside a supported field",
+			body:         "public Set<Long> ids;\n    public int size;",
+			want:         []string{"int size"},
+			wantWarnings: []string{"Foo.ids: type Set<Long> not supported"},
+		},
+		{
+			name: "local variable in a method body",
+			body: "public int size() { final List<String> names = load(); return names.size(); }",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, warnings := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n")
+			var got []string
+			for _, field := range sso.DeclaredFields {
+				got = append(got, field.Type+" "+field.Name)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("fields = %q, want %q", got, test.want)
+			}
+			if got := warningStrings(warnings); !slices.Equal(got, test.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got, test.wantWarnings)
+			}
+			for _, warning := range warnings {
+				if warning.Rule != RuleUnsupportedFieldType {
+					t.Errorf("warning %q has rule %s, want %s", warning, warning.Rule, RuleUnsupportedFieldType)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceRemovesNonPublicNestedTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		nested string
+	}{
+		{name: "private static class", nested: "private static class Cache { public int cached() { return 0; } public int entries; }"},
+		{name: "private class", nested: "private class Cache { public int cached() { return 0; } }"},
+		{name: "protected class", nested: "protected class Helper { public int help() { return 0; } }"},
+		{name: "package-private class", nested: "class Local { public int local() { return 0; } }"},
+		{name: "package-private static final class", nested: "static final class Inner { public int inner() { return 0; } }"},
+		{name: "private interface", nested: "private interface Listener { public int fire(); }"},
+		{name: "private enum", nested: "private enum Mode { A, B; public int code() { return 0; } }"},
+		{name: "nested deeper", nested: "private static class Outer { static class Inner { public int deep() { return 0; } } }"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.nested+"\n    public int size() { return 0; }\n}\n")
+			if got, want := methodSignatures(sso), []string{"int size()"}; !slices.Equal(got, want) {
+				t.Errorf("methods = %q, want %q", got, want)
+			}
+			if len(sso.DeclaredFields) != 0 || len(sso.NestedClasses) != 0 || len(sso.NestedEnums) != 0 {
+				t.Errorf("leaked %d fields, %d nested classes, and %d nested enums", len(sso.DeclaredFields), len(sso.NestedClasses), len(sso.NestedEnums))
+			}
+		})
+	}
+}
+
+func TestScanContinuesPastUnreadableFiles(t *testing.T) {
+	fsys := failingFS{
+		FS: testFS(map[string]string{
+			"a/Alpha.java":  testSSOSource("Alpha", "alpha"),
+			"b/Broken.java": testSSOSource("Broken", "broken"),
+			"c/Gamma.java":  testSSOSource("Gamma", "gamma"),
+			"c/Delta.java":  testSSOSource("Delta", "delta"),
+		}),
+		failures: map[string]error{"b/Broken.java": fs.ErrPermission},
+	}
+
+	t.Run("collects errors", func(t *testing.T) {
+		ssos, err := ScanForSSOsFS(fsys, ".", WithParallelism(1))
+		if got, want := classNames(ssos), []string{"Alpha", "Delta", "Gamma"}; !slices.Equal(got, want) {
+			t.Errorf("SSOs = %q, want %q", got, want)
+		}
+		var scanErrors ScanErrors
+		if !errors.As(err, &scanErrors) {
+			t.Fatalf("error = %v, want ScanErrors", err)
+		}
+		if len(scanErrors) != 1 || scanErrors[0].Path != "b/Broken.java" || !errors.Is(scanErrors[0], fs.ErrPermission) {
+			t.Errorf("scan errors = %v, want b/Broken.java: permission denied", scanErrors)
+		}
+	})
+
+	t.Run("fails fast", func(t *testing.T) {
+		_, err := ScanForSSOsFS(fsys, ".", WithParallelism(1), WithFailFast(true))
+		var scanErr *ScanError
+		if !errors.As(err, &scanErr) || scanErr.Path != "b/Broken.java" {
+			t.Errorf("error = %v, want a ScanError for b/Broken.java", err)
+		}
+	})
+}
+
+func TestScanBoundsOpenFiles(t *testing.T) {
+	const files, parallelism = 3000, 4
+	fsys := &countingFS{FS: syntheticTree(files)}
+	ssos, err := ScanForSSOsFS(fsys, ".", WithParallelism(parallelism))
+	if err != nil {
+		t.Fatalf("ScanForSSOsFS: %v", err)
+	}
+	if len(ssos) != files {
+		t.Errorf("found %d SSOs, want %d", len(ssos), files)
+	}
+	if fsys.maxOpen == 0 {
+		t.Fatal("the scan opened no files through the counting filesystem")
+	}
+	if fsys.open != 0 {
+		t.Errorf("%d files left open", fsys.open)
+	}
+
+	// Each worker reads one file at a time, while the walk holds at most the directory it is listing
+	if limit := parallelism + 1; fsys.maxOpen > limit {
+		t.Errorf("%d files open at once, want at most %d", fsys.maxOpen, limit)
+	}
+}
+
+func BenchmarkScanForSSOsFS(b *testing.B) {
+	fsys := &countingFS{FS: syntheticTree(3000)}
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanForSSOsFS(fsys, "."); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(fsys.maxOpen), "max-open-files")
+}
+
+func TestScanContextCancelledMidScan(t *testing.T) {
+	const files = 3000
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelAfter := func(progress ScanProgress) {
+		if progress.FilesScanned == 100 {
+			cancel()
+		}
+	}
+
+	start := time.Now()
+	ssos, err := ScanForSSOsFSContext(ctx, syntheticTree(files), ".", WithParallelism(2), WithProgress(cancelAfter))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the cancelled scan took %s to return", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || scanErr.Path == "" || scanErr.Path == "." {
+		t.Errorf("error = %v, want a ScanError naming the file or directory being processed", err)
+	}
+	if len(ssos) == 0 || len(ssos) >= files {
+		t.Errorf("found %d SSOs, want the partial results of the files parsed before the cancellation", len(ssos))
+	}
+}
+
+func TestScanContextCancelledBeforeScan(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ssos, err := ScanForSSOsFSContext(ctx, syntheticTree(10), ".")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if len(ssos) != 0 {
+		t.Errorf("found %d SSOs, want none", len(ssos))
+	}
+}
+
+func TestScanForSSOsFS(t *testing.T) {
+	fsys := testFS(map[string]string{
+		"src/com/example/Alpha.java":  testSSOSource("Alpha", "alpha"),
+		"src/com/example/Base.java":   "package com.example;\n\npublic class Base extends ServerSideObject {\n    public int base() { return 0; }\n}\n",
+		"src/com/example/Child.java":  "package com.example;\n\npublic class Child extends Base {\n    public int child() { return 0; }\n}\n",
+		"src/com/example/Plain.java":  "package com.example;\n\npublic class Plain {\n    public int plain() { return 0; }\n}\n",
+		"src/com/example/README.md":   "public class Readme extends ServerSideObject { }\n",
+		"other/com/example/Skip.java": testSSOSource("Skip", "skip"),
+	})
+	tests := []struct {
+		name      string
+		root      string
+		wantPaths []string
+	}{
+		{
+			name:      "whole filesystem",
+			root:      ".",
+			wantPaths: []string{"src/com/example/Alpha.java", "src/com/example/Base.java", "src/com/example/Child.java", "other/com/example/Skip.java"},
+		},
+		{
+			name:      "subdirectory",
+			root:      "src",
+			wantPaths: []string{"src/com/example/Alpha.java", "src/com/example/Base.java", "src/com/example/Child.java"},
+		},
+		{
+			name:      "single file",
+			root:      "src/com/example/Alpha.java",
+			wantPaths: []string{"src/com/example/Alpha.java"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ssos, err := ScanForSSOsFS(fsys, test.root)
+			if err != nil {
+				t.Fatalf("ScanForSSOsFS: %v", err)
+			}
+			var paths []string
+			for _, sso := range ssos {
+				paths = append(paths, sso.FilePath)
+			}
+			if !slices.Equal(paths, test.wantPaths) {
+				t.Errorf("paths = %q, want %q", paths, test.wantPaths)
+			}
+		})
+	}
+
+	t.Run("inherited methods across files", func(t *testing.T) {
+		ssos, err := ScanForSSOsFS(fsys, "src")
+		if err != nil {
+			t.Fatalf("ScanForSSOsFS: %v", err)
+		}
+		child := ssos[slices.IndexFunc(ssos, func(sso ServerSideObject) bool { return sso.ClassName == "Child" })]
+		if got, want := methodSignatures(&child), []string{"int child()", "int base()"}; !slices.Equal(got, want) {
+			t.Errorf("methods = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		if _, err := ScanForSSOsFS(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("error = %v, want fs.ErrNotExist", err)
+		}
+	})
+}
+
+func TestScanBOMAndCRLFLikeUnixTwin(t *testing.T) {
+	scan := func(dir string) ServerSideObject {
+		t.Helper()
+		ssos, err := ScanForSSOs(filepath.Join("testdata", "encoding", dir))
+		if err != nil {
+			t.Fatalf("ScanForSSOs: %v", err)
+		}
+		if len(ssos) != 1 {
+			t.Fatalf("found %d SSOs in %s, want 1", len(ssos), dir)
+		}
+		return ssos[0]
+	}
+	unix, windows := scan("lf"), scan("bom_crlf")
+	if windows.PackageLine != "com.example.tokens" {
+		t.Errorf("PackageLine = %q, want com.example.tokens", windows.PackageLine)
+	}
+	if got, want := methodSignatures(&windows), []string{"String fetchToken(int)", "boolean check(String, long)"}; !slices.Equal(got, want) {
+		t.Errorf("methods = %q, want %q", got, want)
+	}
+
+	// Everything but the path must match the Unix-encoded twin
+	windows.FilePath = unix.FilePath
+	if !reflect.DeepEqual(unix, windows) {
+		t.Errorf("BOM and CRLF file parsed differently:\n got %+v\nwant %+v", windows, unix)
+	}
+}
+
+// writeTestTree writes the files to dir, by slash-separated path relative to it.
+func writeTestTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, src := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanFollowsSymlinksOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		links map[string]string // Symlinks to create, by path, with their targets relative to the link
+		want  []string
+	}{
+		{
+			name:  "symlink to a sibling walked later",
+			links: map[string]string{"alias": "modules"},
+			want:  []string{"Alpha", "Beta"},
+		},
+		{
+			name:  "symlink to a sibling walked earlier",
+			links: map[string]string{"zlink": "modules"},
+			want:  []string{"Alpha", "Beta"},
+		},
+		{
+			name:  "symlink to an ancestor",
+			links: map[string]string{"modules/loop": ".."},
+			want:  []string{"Alpha", "Beta"},
+		},
+		{
+			name:  "symlink to a directory outside the tree",
+			links: map[string]string{"shared": "../outside"},
+			want:  []string{"Alpha", "Beta", "Gamma"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := t.TempDir()
+			writeTestTree(t, base, map[string]string{
+				"src/modules/Alpha.java": testSSOSource("Alpha", "alpha"),
+				"src/modules/Beta.java":  testSSOSource("Beta", "beta"),
+				"outside/Gamma.java":     testSSOSource("Gamma", "gamma"),
+			})
+			for link, target := range test.links {
+				if err := os.Symlink(target, filepath.Join(base, "src", filepath.FromSlash(link))); err != nil {
+					t.Skipf("cannot create symlinks: %v", err)
+				}
+			}
+			var log strings.Builder
+			ssos, err := ScanForSSOs(filepath.Join(base, "src"), WithFollowSymlinks(true), WithLogger(NewWriterLogger(&log)))
+			if err != nil {
+				t.Fatalf("ScanForSSOs: %v", err)
+			}
+			if got := classNames(ssos); !slices.Equal(got, test.want) {
+				t.Errorf("SSOs = %q, want %q\n%s", got, test.want, log.String())
+			}
+			if collisions := FindCollisions(ssos, "out", WriteOptions{}); len(collisions) != 0 {
+				t.Errorf("found %d collisions, want none", len(collisions))
+			}
+		})
+	}
+}
+
+func TestParseSSOSourceRecordsSuperClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		extends string
+		want    string
+	}{
+		{name: "plain", extends: "ServerSideObject", want: "ServerSideObject"},
+		{name: "qualified", extends: "com.vip.sso.ServerSideObject", want: "com.vip.sso.ServerSideObject"},
+		{name: "generic", extends: "ServerSideObject<Payload>", want: "ServerSideObject<Payload>"},
+		{name: "qualified and generic", extends: "com.vip.sso.ServerSideObject<Map<String, Payload>>", want: "com.vip.sso.ServerSideObject<Map<String, Payload>>"},
+		{name: "spaced qualifier", extends: "com.vip.sso . ServerSideObject", want: "com.vip.sso.ServerSideObject"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends "+test.extends+" {\n    public int size() { return 0; }\n}\n")
+			if sso.SuperClass != test.want {
+				t.Errorf("SuperClass = %q, want %q", sso.SuperClass, test.want)
+			}
+			if sso.BaseClass != "ServerSideObject" {
+				t.Errorf("BaseClass = %q, want ServerSideObject", sso.BaseClass)
+			}
+			if got, want := methodSignatures(sso), []string{"int size()"}; !slices.Equal(got, want) {
+				t.Errorf("methods = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// offsetMap maps positions in text produced by a transformation back to positions in the transformation's input.
+// It records where each run of output copied from a contiguous run of input starts; bytes the transformation
+// inserted map to the position of the input they replaced.
+type offsetMap []offsetSegment
+
+// offsetSegment is a run of output starting at out that was produced from input starting at in.
+type offsetSegment struct {
+	out int // Start of the run in the output
+	in  int // Start of the run in the input
+}
+
+// mark records that the output written from position out on comes from the input at position in.
+func (m *offsetMap) mark(out int, in int) {
+	if n := len(*m); n > 0 {
+		last := (*m)[n-1]
+		if out-last.out == in-last.in {
+			return // Continues the current run
+		}
+		if last.out == out {
+			(*m)[n-1].in = in
+			return
+		}
+	}
+	*m = append(*m, offsetSegment{out: out, in: in})
+}
+
+// truncate forgets the segments of output from position out on, after the transformation discarded that output.
+func (m *offsetMap) truncate(out int) {
+	i := sort.Search(len(*m), func(i int) bool { return (*m)[i].out >= out })
+	*m = (*m)[:i]
+}
+
+// inputOffset returns the input position that produced the output position.
+func (m offsetMap) inputOffset(out int) int {
+	i := sort.Search(len(m), func(i int) bool { return m[i].out > out }) - 1
+	if i < 0 {
+		return out
+	}
+	return m[i].in + out - m[i].out
+}
+
+// lineIndex finds the line numbers of positions in a text.
+type lineIndex []int
+
+// newLineIndex indexes the newlines of the text.
+func newLineIndex(text string) lineIndex {
+	var newlines lineIndex
+	for i := strings.IndexByte(text, '\n'); i != -1; {
+		newlines = append(newlines, i)
+		next := strings.IndexByte(text[i+1:], '\n')
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return newlines
+}
+
+// lineAt returns the 1-based line number of the position.
+func (newlines lineIndex) lineAt(pos int) int {
+	return sort.SearchInts(newlines, pos) + 1
+}
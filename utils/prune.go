@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// orphanedFiles returns the generated files with the extension of the language under the output directory whose
+// paths are not expected, in order. Files without the generated header are never orphaned, so hand-written files
+// are left alone. A missing output directory has no orphaned files.
+func orphanedFiles(outputDir string, expected map[string]bool, language Language) ([]string, error) {
+	var orphaned []string
+	extension := language.extension()
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == outputDir {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() || filepath.Ext(path) != extension || expected[path] {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isGeneratedFile(string(content)) {
+			orphaned = append(orphaned, path)
+		}
+		return nil
+	})
+	return orphaned, err
+}
+
+// PruneOrphanedFiles removes the generated files under the output directory that are neither the simplified file of
+// one of the SSOs nor among the other paths to keep, such as base class stubs, and then the package directories left
+// empty, returning the files removed in order. The output directory itself is never removed. With dryRun set,
+// nothing is removed, and the files that would be are returned.
+func PruneOrphanedFiles(outputDir string, ssos []ServerSideObject, keep []string, opts WriteOptions, dryRun bool) ([]string, error) {
+	expected := make(map[string]bool, len(ssos)+len(keep))
+	for i := range ssos {
+		expected[SimplifiedSSOPath(outputDir, &ssos[i], opts)] = true
+	}
+	for _, path := range keep {
+		expected[filepath.Clean(path)] = true
+	}
+	orphaned, err := orphanedFiles(outputDir, expected, opts.Language)
+	if err != nil || dryRun {
+		return orphaned, err
+	}
+
+	var removed []string
+	for _, path := range orphaned {
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("pruning %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+
+	// Remove the directories left empty, deepest first, up to but not including the output directory
+	var dirs []string
+	for _, path := range removed {
+		for dir := filepath.Dir(path); dir != filepath.Clean(outputDir) && dir != "." && !slices.Contains(dirs, dir); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.SortFunc(dirs, func(a, b string) int { return len(b) - len(a) })
+	for _, dir := range dirs {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return removed, fmt.Errorf("pruning %s: %w", dir, err)
+			}
+		}
+	}
+	return removed, nil
+}
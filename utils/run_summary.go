@@ -0,0 +1,46 @@
+package utils
+
+// RunSummary is the JSON object sso_simplifier writes to stdout at the end of a run with --json, for programs that
+// drive it and would otherwise scrape its output.
+type RunSummary struct {
+	Counts   RunCounts      `json:"counts"`            // How many files and SSOs each stage of the run handled
+	Classes  []ClassResult  `json:"classes"`           // The outcome for each SSO written, empty but present when none were
+	Warnings []Warning      `json:"warnings"`          // The parse warnings of the scan, empty but present when there were none
+	Timing   RunTiming      `json:"timing"`            // How long the run took
+	Compile  *CompileResult `json:"compile,omitempty"` // The outcome of --compile, left out when it was not used
+}
+
+// RunCounts counts the files and SSOs handled by a run.
+type RunCounts struct {
+	Scanned   int `json:"scanned"`   // The source files parsed or attempted
+	Matched   int `json:"matched"`   // The SSOs left after the test and class or package filters
+	Written   int `json:"written"`   // The simplified files written
+	Unchanged int `json:"unchanged"` // The simplified files left alone because they had not changed
+	Protected int `json:"protected"` // The existing files left alone because they were not generated by the tool
+	Skipped   int `json:"skipped"`   // The SSOs skipped due to collisions
+	Failed    int `json:"failed"`    // The simplified files that could not be written
+	Aborted   int `json:"aborted"`   // The simplified files left unwritten because --failFast stopped the run
+}
+
+// ClassResult is the outcome of writing the simplified file of an SSO.
+type ClassResult struct {
+	ClassName  string `json:"className"`  // The name of the class, after any renaming due to collisions
+	Package    string `json:"package"`    // The package of the class, empty for the default package
+	SourcePath string `json:"sourcePath"` // The file that declares the class
+	OutputPath string `json:"outputPath"` // The simplified file of the class
+	Status     string `json:"status"`     // One of written, unchanged, protected, failed, or aborted
+}
+
+// RunTiming is the time spent by a run, in milliseconds.
+type RunTiming struct {
+	ScanMillis  int64 `json:"scanMillis"`  // The time spent scanning and parsing the input
+	TotalMillis int64 `json:"totalMillis"` // The time from start to the end of the run
+}
+
+// CompileResult is the outcome of compiling the simplified SSOs into a jar.
+type CompileResult struct {
+	JarPath   string `json:"jarPath"`           // The jar that was or would have been created
+	Succeeded bool   `json:"succeeded"`         // Whether the jar was created
+	Error     string `json:"error,omitempty"`   // Why compilation failed, if it did
+	Skipped   bool   `json:"skipped,omitempty"` // Whether compilation was skipped because simplified SSOs could not be written
+}
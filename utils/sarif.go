@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// sarifSchema and sarifVersion identify the SARIF format the log is written in.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFLog is a SARIF 2.1.0 log, reduced to the properties needed to report warnings.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a single run of a tool and the results it reported.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the tool that produced a run.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver names the tool and lists the rules its results refer to.
+type SARIFDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes a WarningRule.
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+// SARIFResult is a single warning and where it was found.
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFMessage is the text of a message.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFLocation is the place in a file a result is about.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and, when the line is known, the region within it.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is the URI of a file, relative to the directory the tool ran in unless it is absolute.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is the line a result starts on.
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// NewSARIFLog returns a SARIF log with one result per warning, each referring to its WarningRule. Warnings without a
+// known rule are reported without one.
+func NewSARIFLog(warnings []Warning, toolName string, toolVersion string) SARIFLog {
+	driver := SARIFDriver{Name: toolName, Version: toolVersion, Rules: []SARIFRule{}}
+	ruleIndex := make(map[string]int, len(WarningRules))
+	for i, rule := range WarningRules {
+		driver.Rules = append(driver.Rules, SARIFRule{ID: rule.ID, Name: rule.Name, ShortDescription: SARIFMessage{Text: rule.Description}})
+		ruleIndex[rule.ID] = i
+	}
+
+	run := SARIFRun{Tool: SARIFTool{Driver: driver}, Results: []SARIFResult{}}
+	for _, warning := range warnings {
+		location := SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: filepath.ToSlash(warning.Path)}}
+		if warning.Line > 0 {
+			location.Region = &SARIFRegion{StartLine: warning.Line}
+		}
+		result := SARIFResult{
+			Level:     "warning",
+			Message:   SARIFMessage{Text: warning.String()},
+			Locations: []SARIFLocation{{PhysicalLocation: location}},
+		}
+		if index, ok := ruleIndex[warning.Rule]; ok {
+			result.RuleID, result.RuleIndex = warning.Rule, &index
+		}
+		run.Results = append(run.Results, result)
+	}
+	return SARIFLog{Schema: sarifSchema, Version: sarifVersion, Runs: []SARIFRun{run}}
+}
+
+// WriteSARIF writes a SARIF log of the warnings, as NewSARIFLog builds it, to path.
+func WriteSARIF(path string, warnings []Warning, toolName string, toolVersion string) error {
+	data, err := json.MarshalIndent(NewSARIFLog(warnings, toolName, toolVersion), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path, string(data)+"\n")
+}
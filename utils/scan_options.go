@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// ScanOptions controls how ScanForSSOs walks and parses the input tree.
+type ScanOptions struct {
+	FailFast    bool // Stop at the first file that cannot be scanned instead of collecting per-file errors
+	Parallelism int  // Number of files parsed concurrently
+	// Glob patterns, matched against paths relative to the scanned root, of files and directories to skip
+	Exclude []string
+	// Skip paths ignored by .gitignore files in the scanned tree and, for directories, in the repository above it
+	RespectGitignore bool
+	// Keep only the SSOs that pass the filter, when set
+	Filter *SSOFilter
+	// Encoding of the source files: utf-8 (the default), iso-8859-1, or windows-1252
+	SourceEncoding string
+	// Size in bytes above which files are skipped without being read, or 0 for no limit
+	MaxFileSize int64
+	// Walk through symlinks to directories, skipping any whose target has already been walked
+	FollowSymlinks bool
+	// Collects the parse warnings about the scanned files and the SSOs found, when set
+	Warnings *[]Warning
+	// Called after each file is parsed, never concurrently, when set
+	OnProgress func(ScanProgress)
+	// Receives the scan's informational messages; messages are discarded when unset
+	Logger Logger
+	// Simple names of the classes whose subclasses are SSOs; ServerSideObject when unset
+	BaseClasses []string
+	// Simple names of the interfaces whose implementations are SSOs, in addition to the subclasses of BaseClasses
+	BaseInterfaces []string
+	// Default return values of types allowed in members in addition to the built-in ones, keyed by type name as
+	// spelled in the source; entries override the built-in defaults
+	AllowedTypes map[string]string
+	// Keep methods whose return or parameter types are unsupported object types instead of skipping them
+	Lenient bool
+	// Extract protected methods and fields alongside the public ones
+	IncludeProtected bool
+	// Parse results of a previous scan to reuse for unchanged files, when set
+	PreviousParseCache *ParseCache
+	// Receives the parse results of this scan, when set
+	ParseCache *ParseCache
+}
+
+// ScanProgress is a snapshot of a running scan's counters, passed to the WithProgress callback.
+type ScanProgress struct {
+	FilesScanned int    // Source files parsed or attempted so far
+	FilesFailed  int    // Source files among FilesScanned that could not be parsed
+	FilesSkipped int    // Source files left out by exclusions, .gitignore files, or the size limit so far
+	SSOsFound    int    // Classes seen so far that extend a base class or implement a base interface directly; indirect SSOs are resolved at the end
+	CurrentFile  string // The file that was just parsed
+}
+
+// DefaultMaxFileSize is the size above which files are skipped unless WithMaxFileSize says otherwise.
+const DefaultMaxFileSize = 10 * 1024 * 1024
+
+// ErrFileTooLarge is wrapped by the ScanError of a file skipped because it is larger than the size limit.
+var ErrFileTooLarge = errors.New("file too large")
+
+// ScanOption configures a ScanOptions value.
+type ScanOption func(*ScanOptions)
+
+// WithFailFast makes the scan stop at the first file that cannot be scanned.
+func WithFailFast(failFast bool) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.FailFast = failFast
+	}
+}
+
+// WithParallelism sets the number of files parsed concurrently. Values below 1 keep the default of GOMAXPROCS.
+func WithParallelism(workers int) ScanOption {
+	return func(opts *ScanOptions) {
+		if workers > 0 {
+			opts.Parallelism = workers
+		}
+	}
+}
+
+// WithExclude skips files and directories whose path relative to the scanned root matches any of the glob
+// patterns. A "**" segment in a pattern matches any number of directories.
+func WithExclude(patterns ...string) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.Exclude = append(opts.Exclude, patterns...)
+	}
+}
+
+// WithRespectGitignore makes the scan skip paths ignored by .gitignore files.
+func WithRespectGitignore(respect bool) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.RespectGitignore = respect
+	}
+}
+
+// WithFilter keeps only the SSOs that pass the filter in the scan results.
+func WithFilter(filter *SSOFilter) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.Filter = filter
+	}
+}
+
+// WithSourceEncoding sets the encoding the source files are decoded from before parsing.
+func WithSourceEncoding(encoding string) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.SourceEncoding = encoding
+	}
+}
+
+// WithMaxFileSize sets the size in bytes above which files are skipped without being read. 0 removes the limit.
+func WithMaxFileSize(bytes int64) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.MaxFileSize = bytes
+	}
+}
+
+// WithFollowSymlinks makes the scan walk through symlinks to directories. Targets that have already been walked,
+// including those that would form a cycle, are skipped with a warning, as is a directory reached again after being
+// walked through a symlink, so that every directory is scanned once by its real path.
+func WithFollowSymlinks(follow bool) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.FollowSymlinks = follow
+	}
+}
+
+// WithWarnings appends the parse warnings about the scanned files and the SSOs found to warnings. Warnings about
+// classes that turn out not to be SSOs are left out.
+func WithWarnings(warnings *[]Warning) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.Warnings = warnings
+	}
+}
+
+// WithProgress calls onProgress with the scan's counters after each file is parsed. Calls are never concurrent,
+// even when files are parsed in parallel.
+func WithProgress(onProgress func(ScanProgress)) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.OnProgress = onProgress
+	}
+}
+
+// WithLogger sends the scan's informational messages, such as "SSO found" lines and warnings, to logger. Calls to
+// the logger are never concurrent.
+func WithLogger(logger Logger) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.Logger = logger
+	}
+}
+
+// WithSlogLogger sends the scan's messages to logger, as NewSlogLogger does, including debug messages such as every
+// file visited.
+func WithSlogLogger(logger *slog.Logger) ScanOption {
+	return WithLogger(NewSlogLogger(logger))
+}
+
+// WithBaseClasses adds base classes whose subclasses are detected as SSOs, replacing the default of
+// ServerSideObject. Names are matched by simple name, so a package qualifier is ignored.
+func WithBaseClasses(names ...string) ScanOption {
+	return func(opts *ScanOptions) {
+		for _, name := range names {
+			opts.BaseClasses = append(opts.BaseClasses, simpleTypeName(name))
+		}
+	}
+}
+
+// WithBaseInterfaces adds interfaces whose implementing classes, and their subclasses, are detected as SSOs
+// alongside the subclasses of the base classes. Names are matched by simple name, so a package qualifier is ignored.
+func WithBaseInterfaces(names ...string) ScanOption {
+	return func(opts *ScanOptions) {
+		for _, name := range names {
+			opts.BaseInterfaces = append(opts.BaseInterfaces, simpleTypeName(name))
+		}
+	}
+}
+
+// WithAllowedTypes allows members using the given types, mapped to the default values stub methods return for them,
+// in addition to the built-in types. Repeated options are merged, later entries overriding earlier ones.
+func WithAllowedTypes(types map[string]string) ScanOption {
+	return func(opts *ScanOptions) {
+		if opts.AllowedTypes == nil {
+			opts.AllowedTypes = make(map[string]string)
+		}
+		for typeName, defaultValue := range types {
+			opts.AllowedTypes[typeName] = defaultValue
+		}
+	}
+}
+
+// WithLenient keeps methods whose return or parameter types are unsupported object types, qualifying those types
+// through the file's imports. Methods using unsupported primitives are still skipped.
+func WithLenient(lenient bool) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.Lenient = lenient
+	}
+}
+
+// WithIncludeProtected extracts protected methods and fields alongside the public ones, recording each member's
+// access modifier so the stubs keep it.
+func WithIncludeProtected(include bool) ScanOption {
+	return func(opts *ScanOptions) {
+		opts.IncludeProtected = include
+	}
+}
+
+// newScanOptions applies the given options over the defaults.
+func newScanOptions(options []ScanOption) ScanOptions {
+	opts := ScanOptions{Parallelism: runtime.GOMAXPROCS(0), MaxFileSize: DefaultMaxFileSize}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.Logger == nil {
+		opts.Logger = nopLogger{}
+	}
+	if len(opts.BaseClasses) == 0 {
+		opts.BaseClasses = []string{baseClassName}
+	}
+	opts.Logger = &syncLogger{logger: opts.Logger}
+	return opts
+}
+
+// bases returns the base classes and interfaces that SSOs are detected by.
+func (opts ScanOptions) bases() ssoBases {
+	return ssoBases{classes: opts.BaseClasses, interfaces: opts.BaseInterfaces}
+}
+
+// excluded reports whether a path relative to the scanned root matches one of the exclude patterns.
+func (opts ScanOptions) excluded(relativePath string) bool {
+	for _, pattern := range opts.Exclude {
+		if matchGlob(pattern, relativePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanError records a file or directory that could not be scanned.
+type ScanError struct {
+	Path string // The path that could not be scanned
+	Err  error  // The underlying error
+}
+
+// Error returns the path and the underlying error message.
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// ScanErrors is the list of per-file errors collected by a scan that kept going past them.
+type ScanErrors []*ScanError
+
+// Error summarizes the failed paths.
+func (e ScanErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, scanErr := range e {
+		messages[i] = scanErr.Error()
+	}
+	return fmt.Sprintf("%d paths could not be scanned: %s", len(e), strings.Join(messages, "; "))
+}
+
+// newGitignore returns an empty set of gitignore rules for the walk to fill in, or nil when gitignore files are
+// not respected.
+func (opts ScanOptions) newGitignore() *gitignore {
+	if !opts.RespectGitignore {
+		return nil
+	}
+	return &gitignore{}
+}
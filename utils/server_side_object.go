@@ -3,50 +3,201 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 // PublicField represents a Java public property (field) declaration.
 type PublicField struct {
-	Type string // The type of the field
-	Name string // The name of the field
+	AccessModifier string `json:"accessModifier"` // The access modifier of the field, public unless protected members are included
+	IsStatic       bool   `json:"isStatic"`       // Whether the field is declared static
+	IsFinal        bool   `json:"isFinal"`        // Whether the field is declared final
+	IsDeprecated   bool   `json:"isDeprecated"`   // Whether the field is annotated @Deprecated or has a @deprecated Javadoc tag
+	Type           string `json:"type"`           // The type of the field
+	Name           string `json:"name"`           // The name of the field
+	Initializer    string `json:"initializer"`    // The initializer expression of a static final field, reproduced verbatim
+	Line           int    `json:"line"`           // The line of the declaration in the file that declares the field
 }
 
-// ServerSideObject represents a Java file with its path, name, declared methods, and fields.
+// ServerSideObject represents a Java file with its path, name, declared methods, fields, constructors, and nested types.
 type ServerSideObject struct {
-	FilePath        string         // The absolute or relative path of the file
-	ClassName       string         // The name of the class
-	PackageLine     string         // The package line of the Java file
-	DeclaredMethods []PublicMethod // The declared methods of the class
-	DeclaredFields  []PublicField  // The declared public fields of the class
+	FilePath             string              `json:"filePath"`             // The absolute or relative path of the file
+	Line                 int                 `json:"line"`                 // The line of the class declaration in the file
+	Javadoc              string              `json:"javadoc"`              // The Javadoc comment of the class, with its indentation normalized, if any
+	ClassName            string              `json:"className"`            // The name of the class
+	TypeParameters       string              `json:"typeParameters"`       // The type parameter list of the class, such as "<K, V>", if it is generic
+	SuperClass           string              `json:"superClass"`           // The superclass as spelled in the source, including any qualifier and type arguments
+	SuperClassImport     string              `json:"superClassImport"`     // The qualified name imported for an unqualified superclass, if any
+	Interfaces           []string            `json:"interfaces"`           // The interfaces in the implements clause as spelled in the source
+	BaseClass            string              `json:"baseClass"`            // The simple name of the base class or interface that makes the class an SSO, such as ServerSideObject
+	SerialVersionUID     string              `json:"serialVersionUID"`     // The value of the serialVersionUID of a serializable class as spelled, if declared
+	IsAbstract           bool                `json:"isAbstract"`           // Whether the class is declared abstract
+	IsDeprecated         bool                `json:"isDeprecated"`         // Whether the class is annotated @Deprecated or has a @deprecated Javadoc tag
+	IsStatic             bool                `json:"isStatic"`             // Whether a nested class is declared static
+	PackageLine          string              `json:"packageLine"`          // The package line of the Java file
+	DeclaredMethods      []PublicMethod      `json:"declaredMethods"`      // The declared methods of the class
+	DeclaredFields       []PublicField       `json:"declaredFields"`       // The declared public fields of the class
+	DeclaredConstructors []PublicConstructor `json:"declaredConstructors"` // The declared public constructors of the class
+	NestedClasses        []ServerSideObject  `json:"nestedClasses"`        // The public classes nested in the class
+	NestedEnums          []EnumDeclaration   `json:"nestedEnums"`          // The public enums nested in the class
+	FileEnums            []EnumDeclaration   `json:"fileEnums"`            // The enums declared at the top level of the same file
+	warnings             []Warning           // The parse warnings about the class and its merged superclasses
+}
+
+// EnumDeclaration represents a Java enum declared in or alongside an SSO. Enums hide no implementation,
+// so the declaration is kept as (whitespace-normalized) source.
+type EnumDeclaration struct {
+	Name   string `json:"name"`   // The name of the enum
+	Source string `json:"source"` // The source of the enum declaration
 }
 
 // PublicMethod represents a Java method signature broken into elements.
 type PublicMethod struct {
-	AccessModifier string      // The access modifier of the method (e.g., public, private, protected)
-	ReturnType     string      // The return type of the method
-	MethodName     string      // The name of the method
-	Parameters     []Parameter // The parameters of the method
+	AccessModifier string      `json:"accessModifier"` // The access modifier of the method (e.g., public, private, protected)
+	IsStatic       bool        `json:"isStatic"`       // Whether the method is declared static
+	IsFinal        bool        `json:"isFinal"`        // Whether the method is declared final
+	IsDeprecated   bool        `json:"isDeprecated"`   // Whether the method is annotated @Deprecated or has a @deprecated Javadoc tag
+	ReturnType     string      `json:"returnType"`     // The return type of the method
+	MethodName     string      `json:"methodName"`     // The name of the method
+	Parameters     []Parameter `json:"parameters"`     // The parameters of the method
+	Exceptions     []string    `json:"exceptions"`     // The exception types listed in the throws clause of the method
+	IsLenient      bool        `json:"isLenient"`      // Whether the method uses unsupported object types and was kept by lenient mode
+	Javadoc        string      `json:"javadoc"`        // The Javadoc comment of the method, with its indentation normalized, if any
+	Line           int         `json:"line"`           // The line of the declaration in the file that declares the method, or 0 if synthesized
+}
+
+// PublicConstructor represents a Java constructor signature broken into elements.
+type PublicConstructor struct {
+	AccessModifier string      `json:"accessModifier"` // The access modifier of the constructor
+	Parameters     []Parameter `json:"parameters"`     // The parameters of the constructor
 }
 
 // Parameter represents a parameter in a Java method signature.
 type Parameter struct {
-	Type string // The type of the parameter (e.g., int, String)
-	Name string // The name of the parameter
+	Type      string `json:"type"`      // The type of the parameter (e.g., int, String), or the element type of a varargs parameter
+	Name      string `json:"name"`      // The name of the parameter
+	IsVarargs bool   `json:"isVarargs"` // Whether the parameter is a varargs parameter (e.g., String... names)
 }
 
-// allowedTypes defines the list of allowed parameter types and their default return values.
+// allowedTypes defines the built-in allowed parameter types and their default return values.
 var allowedTypes = map[string]string{
 	"boolean": "false",
 	"byte":    "0",
 	"char":    "'\\0'",
 	"short":   "0",
 	"int":     "0",
-	"Integer": "0",
 	"long":    "0L",
 	"float":   "0.0f",
 	"double":  "0.0",
 	"String":  "null",
-	"void":    "null",
+
+	// Boxed wrapper types default to null like any other object
+	"Boolean":   "null",
+	"Byte":      "null",
+	"Character": "null",
+	"Short":     "null",
+	"Integer":   "null",
+	"Long":      "null",
+	"Float":     "null",
+	"Double":    "null",
+}
+
+var (
+	// javaTypeStartPattern matches type names that start like a class or interface name rather than punctuation
+	javaTypeStartPattern = regexp.MustCompile(`^[a-zA-Z_$]`)
+	// javaTypeNamePattern matches the simple or qualified names within a type, such as Map.Entry in Map.Entry<K, V>
+	javaTypeNamePattern = regexp.MustCompile(`[a-zA-Z_$][a-zA-Z0-9_$]*(?:\.[a-zA-Z_$][a-zA-Z0-9_$]*)*`)
+)
+
+// javaLangPrefix is the package qualifier that may be dropped from well-known java.lang types.
+const javaLangPrefix = "java.lang."
+
+// primitiveTypes are the Java primitive types and void, which lenient mode never keeps when they are not allowed.
+var primitiveTypes = []string{"boolean", "byte", "char", "short", "int", "long", "float", "double", "void"}
+
+// typeTable decides which member types are supported: the built-in allowedTypes merged with any types added through
+// ScanOptions or WriteOptions, and in lenient mode any other object type.
+type typeTable struct {
+	defaults map[string]string // The default return values of the allowed types
+	lenient  bool              // Whether methods with unsupported object types are kept
+	imports  map[string]string // The single-type imports of the file being parsed, by simple name
+}
+
+// newTypeTable returns the built-in allowed types with the extra types merged over them.
+func newTypeTable(extra map[string]string, lenient bool) typeTable {
+	defaults := make(map[string]string, len(allowedTypes)+len(extra))
+	for typeName, defaultValue := range allowedTypes {
+		defaults[typeName] = defaultValue
+	}
+	for typeName, defaultValue := range extra {
+		defaults[typeName] = defaultValue
+	}
+	return typeTable{defaults: defaults, lenient: lenient}
+}
+
+// withImports returns the type table for a file with the given single-type imports.
+func (types typeTable) withImports(imports map[string]string) typeTable {
+	types.imports = imports
+	return types
+}
+
+// keepsLeniently reports whether lenient mode keeps a method using the unsupported type: any class, interface, or
+// array of them, but not an unrecognized primitive.
+func (types typeTable) keepsLeniently(typeName string) bool {
+	elementType := strings.TrimRight(typeName, "[]")
+	return types.lenient && javaTypeStartPattern.MatchString(elementType) && !slices.Contains(primitiveTypes, elementType)
+}
+
+// qualifyTypeName replaces the simple names in a type, including its type arguments, with the qualified names they
+// were imported under, so that stubs using the type compile without the original imports. Types from java.lang,
+// the same package, or wildcard imports are left as spelled.
+func (types typeTable) qualifyTypeName(typeName string) string {
+	return javaTypeNamePattern.ReplaceAllStringFunc(typeName, func(name string) string {
+		first, rest, _ := strings.Cut(name, ".")
+		if qualified, ok := types.imports[first]; ok {
+			if rest == "" {
+				return qualified
+			}
+			return qualified + "." + rest
+		}
+		return name
+	})
+}
+
+// resolveTypeName drops the java.lang qualifier from well-known types so that java.lang.String[] resolves to String[].
+// Other qualified names are returned unchanged.
+func (types typeTable) resolveTypeName(typeName string) string {
+	if !strings.HasPrefix(typeName, javaLangPrefix) {
+		return typeName
+	}
+	simpleName := strings.TrimPrefix(typeName, javaLangPrefix)
+	if _, ok := types.defaults[strings.TrimRight(simpleName, "[]")]; ok {
+		return simpleName
+	}
+	return typeName
+}
+
+// isTypeAllowed checks if a type, or the element type of an array of any dimension, is in the allowed list.
+func (types typeTable) isTypeAllowed(typeName string) bool {
+	_, ok := types.defaults[strings.TrimRight(typeName, "[]")]
+	return ok
+}
+
+// isReturnTypeAllowed checks if a method return type is in the allowed list or is void.
+func (types typeTable) isReturnTypeAllowed(returnType string) bool {
+	if returnType == "void" {
+		return true
+	}
+	return types.isTypeAllowed(returnType)
+}
+
+// defaultValueFor returns the simplest value of the given type, which is null for arrays and unsupported types.
+func (types typeTable) defaultValueFor(typeName string) string {
+	if defaultValue, ok := types.defaults[typeName]; ok {
+		return defaultValue
+	}
+	return "null"
 }
 
 // ServerSideObjectList is a custom type that implements sort.Interface for []ServerSideObject.
@@ -57,9 +208,16 @@ func (s ServerSideObjectList) Len() int {
 	return len(s)
 }
 
-// Less compares two ServerSideObjects by ClassName for sorting.
+// Less compares two ServerSideObjects by ClassName for sorting, breaking ties by package and file path
+// so that the order of same-named SSOs is deterministic.
 func (s ServerSideObjectList) Less(i, j int) bool {
-	return s[i].ClassName < s[j].ClassName
+	if s[i].ClassName != s[j].ClassName {
+		return s[i].ClassName < s[j].ClassName
+	}
+	if s[i].PackageLine != s[j].PackageLine {
+		return s[i].PackageLine < s[j].PackageLine
+	}
+	return s[i].FilePath < s[j].FilePath
 }
 
 // Swap swaps two ServerSideObjects in the list.
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// sourcesJarEpoch is the modification time of the entries of a sources jar written without a Timestamp, the
+// earliest time a zip archive can record, so that re-runs produce identical archives.
+var sourcesJarEpoch = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// WriteSourcesJar writes the simplified source of every SSO into a sources jar at path, as WriteSourcesJarTo does.
+// The archive is written to a temporary file and renamed into place.
+func WriteSourcesJar(path string, ssos []ServerSideObject, opts WriteOptions) error {
+	var archive bytes.Buffer
+	if err := WriteSourcesJarTo(&archive, ssos, opts); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return writeFileAtomically(path, archive.String())
+}
+
+// WriteSourcesJarTo writes the simplified source of every SSO to w as a zip archive in the layout of a sources jar:
+// a manifest followed by one entry per SSO in the directory of its package, in name order, whatever FlatOutput says.
+// Every entry carries the Timestamp, or a fixed time when it is zero, so the archive is reproducible.
+func WriteSourcesJarTo(w io.Writer, ssos []ServerSideObject, opts WriteOptions) error {
+	modified := opts.Timestamp
+	if modified.IsZero() {
+		modified = sourcesJarEpoch
+	}
+
+	// Render every SSO first, so the entries can be written in name order
+	entryOpts := opts
+	entryOpts.FlatOutput = false
+	sources := make(map[string]string)
+	for i := range ssos {
+		name := filepath.ToSlash(SimplifiedSSOPath("", &ssos[i], entryOpts))
+		if _, ok := sources[name]; ok {
+			return fmt.Errorf("two SSOs would both be written to %s", name)
+		}
+		rendered, err := RenderSimplifiedSSO(&ssos[i], opts)
+		if err != nil {
+			return err
+		}
+		sources[name] = rendered
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	archive := zip.NewWriter(w)
+	manifest := "Manifest-Version: 1.0\r\n"
+	if opts.Generator != "" {
+		manifest += "Created-By: " + opts.Generator + "\r\n"
+	}
+	if err := writeZipEntry(archive, "META-INF/MANIFEST.MF", manifest+"\r\n", modified); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := writeZipEntry(archive, name, sources[name], modified); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeZipEntry adds a compressed file entry to the archive.
+func writeZipEntry(archive *zip.Writer, name string, content string, modified time.Time) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(entry, content)
+	return err
+}
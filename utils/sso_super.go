@@ -9,3 +9,15 @@ var SuperclassMethods = []PublicMethod{
 		Parameters:     []Parameter{},
 	},
 }
+
+// Public methods inherited from the base classes of other SSO families, keyed by the simple name of the base class.
+// SSOs of ServerSideObject get SuperclassMethods instead.
+var BaseClassMethods = map[string][]PublicMethod{}
+
+// superclassMethodsFor returns the public methods that SSOs inherit from the given base class.
+func superclassMethodsFor(baseClass string) []PublicMethod {
+	if baseClass == baseClassName {
+		return SuperclassMethods
+	}
+	return BaseClassMethods[baseClass]
+}
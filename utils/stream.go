@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+)
+
+// ScanForSSOsStream scans like ScanForSSOsContext, but sends each SSO on the returned channel as soon as it is
+// resolved instead of collecting and sorting them, so that callers can start on the first SSOs while the scan is
+// still running. SSOs arrive in the order their inheritance chains become known, which follows the order files
+// finish parsing rather than ClassName order.
+//
+// The SSO channel is closed when the scan ends. The error channel then receives the scan's error, if any, and is
+// closed too. Callers that stop reading early should cancel ctx so the scan can wind down.
+func ScanForSSOsStream(ctx context.Context, directory string, options ...ScanOption) (<-chan ServerSideObject, <-chan error) {
+	ssos := make(chan ServerSideObject)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(ssos)
+
+		emit := func(sso ServerSideObject) {
+			select {
+			case ssos <- sso:
+			case <-ctx.Done():
+			}
+		}
+		if _, err := scanPath(ctx, directory, newScanOptions(options), emit); err != nil {
+			errs <- err
+		}
+	}()
+	return ssos, errs
+}
+
+// streamResolver resolves SSOs incrementally as the classes of each file are parsed. A class is resolved as soon as
+// every class in its chain to a base is known; until then it waits on the simple name of the first superclass that
+// is missing.
+type streamResolver struct {
+	bases   ssoBases
+	logger  Logger
+	index   map[string]*javaClass   // The classes parsed so far, by qualified and simple name
+	waiting map[string][]*javaClass // Public classes whose chain is incomplete, by the simple name they wait on
+}
+
+// newStreamResolver returns an empty streamResolver for SSOs of the bases that reports the SSOs it resolves to the
+// logger.
+func newStreamResolver(bases ssoBases, logger Logger) *streamResolver {
+	return &streamResolver{
+		bases:   bases,
+		logger:  logger,
+		index:   make(map[string]*javaClass),
+		waiting: make(map[string][]*javaClass),
+	}
+}
+
+// add indexes the classes of a file and passes every SSO that can now be resolved to emit.
+func (r *streamResolver) add(classes []javaClass, emit func(ServerSideObject)) {
+	added := make([]*javaClass, len(classes))
+	for i := range classes {
+		class := &classes[i]
+		added[i] = class
+		r.index[qualifiedName(class.sso.PackageLine, class.sso.ClassName)] = class
+		if _, ok := r.index[class.sso.ClassName]; !ok {
+			r.index[class.sso.ClassName] = class
+		}
+	}
+
+	// Try the new classes, then the classes that were waiting on one of them
+	pending := append([]*javaClass(nil), added...)
+	for _, class := range added {
+		pending = append(pending, r.waiting[class.sso.ClassName]...)
+		delete(r.waiting, class.sso.ClassName)
+	}
+	for _, class := range pending {
+		if !class.isPublic {
+			continue
+		}
+		ancestors, baseClass, missing, ok := inheritanceChain(class, r.index, r.bases)
+		if ok {
+			emit(resolveSSO(class, ancestors, baseClass, r.logger))
+		} else if missing != "" {
+			r.waiting[missing] = append(r.waiting[missing], class)
+		}
+	}
+}
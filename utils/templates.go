@@ -0,0 +1,118 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// defaultTemplateSource is the template of the built-in output format, which WriteOptions.Template replaces.
+//
+//go:embed templates/simplified_sso.tmpl
+var defaultTemplateSource string
+
+// defaultTemplate renders simplified SSOs unless WriteOptions sets a template of its own.
+var defaultTemplate = template.Must(template.New("simplified_sso.tmpl").Parse(defaultTemplateSource))
+
+// TemplateData is the data a template for simplified SSOs is executed with. The fields and methods of the
+// ServerSideObject are available directly, alongside the parts of the file rendered as WriteOptions asks.
+type TemplateData struct {
+	*ServerSideObject
+	Header string // The license and generated header comments, each followed by a blank line, or empty if neither is set
+	Import string // The qualified superclass to import, or empty when the extends clause needs no import
+	Class  string // The simplified class declaration, including nested classes, as the built-in format renders it
+
+	types typeTable
+}
+
+// JoinedParameters returns the parameter list of a method as it is declared, such as "String name, int... values".
+func (d TemplateData) JoinedParameters(method PublicMethod) string {
+	return joinParameters(method.Parameters)
+}
+
+// DefaultReturn returns the simplest expression of a type, such as 0, false, or null, taking the allowed types of
+// the WriteOptions into account.
+func (d TemplateData) DefaultReturn(typeName string) string {
+	return d.types.defaultValueFor(typeName)
+}
+
+// ParseTemplate reads a Go text/template for simplified SSOs from a file and checks it by executing it against a
+// sample SSO, so that mistakes surface with their line and column before any file is written.
+func ParseTemplate(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if err := executeTemplate(io.Discard, tmpl, &sampleSSO, WriteOptions{Generator: "sso_simplifier"}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// sampleSSO exercises the common parts of a template when it is checked.
+var sampleSSO = ServerSideObject{
+	FilePath:    "Sample.java",
+	ClassName:   "Sample",
+	SuperClass:  baseClassName,
+	BaseClass:   baseClassName,
+	PackageLine: "com.example",
+	DeclaredFields: []PublicField{
+		{AccessModifier: "public", Type: "int", Name: "count"},
+	},
+	DeclaredMethods: []PublicMethod{
+		{AccessModifier: "public", ReturnType: "String", MethodName: "describe", Parameters: []Parameter{{Type: "int", Name: "depth"}}},
+		{AccessModifier: "public", ReturnType: "void", MethodName: "reset", Parameters: []Parameter{}},
+	},
+}
+
+// executeTemplate renders a simplified SSO to w with a template.
+func executeTemplate(w io.Writer, tmpl *template.Template, sso *ServerSideObject, opts WriteOptions) error {
+	data := TemplateData{
+		ServerSideObject: sso,
+		Header:           renderHeader(sso, opts),
+		types:            newTypeTable(opts.AllowedTypes, false),
+	}
+
+	// Import the superclass the way the original file did, since the stubs carry no other imports
+	if !opts.OmitExtends {
+		data.Import = sso.SuperClassImport
+	}
+
+	e := newJavaEmitter(opts)
+	if opts.Language == LanguageKotlin {
+		// Kotlin stubs extend the base class directly, so they import it only when it is their superclass
+		if kotlinSuperClass(sso) != sso.SuperClass {
+			data.Import = ""
+		}
+		renderKotlinClass(e, sso, opts, false)
+	} else {
+		renderClass(e, sso, opts)
+	}
+	data.Class = e.String()
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", sso.ClassName, err)
+	}
+	return nil
+}
+
+// joinParameters returns a parameter list as it is declared, with varargs spelled as such.
+func joinParameters(params []Parameter) string {
+	declared := make([]string, len(params))
+	for i, param := range params {
+		declared[i] = param.Type
+		if param.IsVarargs {
+			declared[i] += "..."
+		}
+		declared[i] += " " + param.Name
+	}
+	return strings.Join(declared, ", ")
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// testSourceRoots are the Maven and Gradle source roots that hold test code.
+var testSourceRoots = []string{"/src/test/", "/src/it/", "/src/testFixtures/", "/src/integrationTest/"}
+
+// testClassSuffixes are the class name suffixes conventionally used for unit and integration tests.
+var testClassSuffixes = []string{"Test", "Tests", "IT"}
+
+// IsTestSource reports whether an SSO looks like test code, either because its file is under a Maven or Gradle
+// test source root or because its class name ends like a test class.
+func IsTestSource(sso *ServerSideObject) bool {
+	path := "/" + filepath.ToSlash(sso.FilePath)
+	for _, root := range testSourceRoots {
+		if strings.Contains(path, root) {
+			return true
+		}
+	}
+	for _, suffix := range testClassSuffixes {
+		if strings.HasSuffix(sso.ClassName, suffix) && sso.ClassName != suffix {
+			return true
+		}
+	}
+	return false
+}
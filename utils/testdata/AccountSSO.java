@@ -0,0 +1,41 @@
+package com.example.accounts;
+
+import java.io.IOException;
+
+/**
+ * Looks up and updates accounts.
+ */
+public class AccountSSO extends ServerSideObject {
+    public static final int MAX_RETRIES = 3;
+    public String region;
+
+    public enum Status { ACTIVE, LOCKED }
+
+    public AccountSSO(String region) {
+        this.region = region;
+    }
+
+    /**
+     * Returns the display name of an account.
+     */
+    public String displayName(long accountId) throws IOException {
+        return lookup(accountId).name;
+    }
+
+    public int[] balances(String[] currencies) {
+        return new int[currencies.length];
+    }
+
+    @Deprecated
+    public boolean isLocked(Long accountId) {
+        return false;
+    }
+
+    public static double rate() {
+        return 0.5;
+    }
+
+    public void lock(long accountId, String... reasons) {
+        accounts.remove(accountId);
+    }
+}
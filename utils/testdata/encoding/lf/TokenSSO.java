@@ -0,0 +1,26 @@
+package com.example.tokens;
+
+import java.io.IOException;
+
+/**
+ * Issues and checks tokens.
+ */
+public class TokenSSO extends ServerSideObject {
+    public static final int MAX_AGE = 3600;
+
+    /**
+     * Fetches a token.
+     */
+    @Override
+    public String fetchToken(int userId)
+            throws IOException {
+        return "token";
+    }
+
+    @Deprecated
+    public boolean check(
+            @Nullable String token,
+            long now) {
+        return true;
+    }
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// TypeScriptCombinedFileName is the name of the declaration file WriteTypeScript writes with TypeScriptOptions.Combined.
+const TypeScriptCombinedFileName = "ssos.d.ts"
+
+// typeScriptTypes maps the built-in allowed Java types to the TypeScript types a JS bridge hands them over as.
+var typeScriptTypes = map[string]string{
+	"boolean": "boolean",
+	"byte":    "number",
+	"char":    "string",
+	"short":   "number",
+	"int":     "number",
+	"long":    "number",
+	"float":   "number",
+	"double":  "number",
+	"String":  "string",
+	"void":    "void",
+
+	// Boxed wrapper types may be null, unlike the primitives they wrap
+	"Boolean":   "boolean | null",
+	"Byte":      "number | null",
+	"Character": "string | null",
+	"Short":     "number | null",
+	"Integer":   "number | null",
+	"Long":      "number | null",
+	"Float":     "number | null",
+	"Double":    "number | null",
+}
+
+// TypeScriptOptions controls how WriteTypeScript declares SSOs.
+type TypeScriptOptions struct {
+	// The TypeScript types of Java types, merged over the built-in ones. Allowed types added through WriteOptions
+	// that are not mapped here are declared unknown.
+	Types map[string]string
+	// Write every package into TypeScriptCombinedFileName, each in a namespace, instead of a file per package
+	Combined bool
+}
+
+// ParseTypeScriptType parses a TypeScript type mapping of the form JavaType=TypeScriptType, such as
+// "BigDecimal=string".
+func ParseTypeScriptType(entry string) (javaType string, typeScriptType string, err error) {
+	javaType, typeScriptType, _ = strings.Cut(entry, "=")
+	javaType, typeScriptType = strings.TrimSpace(javaType), strings.TrimSpace(typeScriptType)
+	if !allowedTypeNamePattern.MatchString(javaType) || typeScriptType == "" {
+		return "", "", fmt.Errorf("invalid TypeScript type %q: expected JavaType=TypeScriptType", entry)
+	}
+	return javaType, typeScriptType, nil
+}
+
+// typeScriptTable maps Java types to TypeScript types for a set of options.
+type typeScriptTable map[string]string
+
+// newTypeScriptTable merges the built-in mappings, the allowed types of the write options, and the mappings of the
+// TypeScript options, in increasing order of precedence.
+func newTypeScriptTable(opts WriteOptions, tsOpts TypeScriptOptions) typeScriptTable {
+	table := make(typeScriptTable, len(typeScriptTypes)+len(opts.AllowedTypes)+len(tsOpts.Types))
+	for javaType, tsType := range typeScriptTypes {
+		table[javaType] = tsType
+	}
+	for javaType := range opts.AllowedTypes {
+		if _, ok := table[javaType]; !ok {
+			table[javaType] = "unknown"
+		}
+	}
+	for javaType, tsType := range tsOpts.Types {
+		table[javaType] = tsType
+	}
+	return table
+}
+
+// typeOf returns the TypeScript type of a Java type, including arrays of any dimension. Types without a mapping,
+// such as those kept by lenient mode, are unknown.
+func (table typeScriptTable) typeOf(javaType string) string {
+	elementType := strings.TrimRight(javaType, "[]")
+	dimensions := (len(javaType) - len(elementType)) / 2
+	tsType, ok := table[elementType]
+	if !ok {
+		tsType = "unknown"
+	}
+	if dimensions > 0 && strings.Contains(tsType, " ") {
+		tsType = "(" + tsType + ")" // Keep union types together, as in (number | null)[]
+	}
+	return tsType + strings.Repeat("[]", dimensions)
+}
+
+// RenderTypeScript returns the TypeScript declarations of the SSOs by file name: a file per package named after it,
+// or TypeScriptCombinedFileName with a namespace per package when TypeScriptOptions.Combined is set. Each SSO is
+// declared as an exported interface of its instance methods and fields, and its nested classes as interfaces in a
+// namespace named after it. Packages and classes are in order so that the files change only where the SSOs do.
+func RenderTypeScript(ssos []ServerSideObject, opts WriteOptions, tsOpts TypeScriptOptions) map[string]string {
+	table := newTypeScriptTable(opts, tsOpts)
+	sorted := slices.Clone(ssos)
+	slices.SortStableFunc(sorted, func(a, b ServerSideObject) int {
+		return cmp.Or(cmp.Compare(a.PackageLine, b.PackageLine), cmp.Compare(a.ClassName, b.ClassName))
+	})
+
+	header := ""
+	if opts.Generator != "" {
+		header = generatedHeaderPrefix + opts.Generator + ". Do not edit.\n\n"
+	}
+	files := make(map[string]string)
+	var builder strings.Builder
+	for i := range sorted {
+		sso := &sorted[i]
+		firstOfPackage := i == 0 || sso.PackageLine != sorted[i-1].PackageLine
+		lastOfPackage := i == len(sorted)-1 || sso.PackageLine != sorted[i+1].PackageLine
+		namespaced := tsOpts.Combined && sso.PackageLine != ""
+		indent := ""
+		if namespaced {
+			indent = "    "
+		}
+		if namespaced && firstOfPackage {
+			builder.WriteString("export namespace " + sso.PackageLine + " {\n")
+		}
+		if !firstOfPackage {
+			builder.WriteString("\n")
+		}
+		renderTypeScriptInterface(&builder, sso, table, opts, indent)
+		if !lastOfPackage {
+			continue
+		}
+
+		// Finish the namespace or file of the package
+		if !tsOpts.Combined {
+			name := sso.PackageLine + ".d.ts"
+			if sso.PackageLine == "" {
+				name = "default.d.ts"
+			}
+			files[name] = header + builder.String()
+			builder.Reset()
+		} else if namespaced {
+			builder.WriteString("}\n")
+		}
+		if tsOpts.Combined && i < len(sorted)-1 {
+			builder.WriteString("\n")
+		}
+	}
+	if tsOpts.Combined {
+		files[TypeScriptCombinedFileName] = header + builder.String()
+	}
+	return files
+}
+
+// renderTypeScriptInterface writes the interface of a class, followed by a namespace holding those of its nested
+// classes, if it has any.
+func renderTypeScriptInterface(builder *strings.Builder, sso *ServerSideObject, table typeScriptTable, opts WriteOptions, indent string) {
+	if summary := javadocSummary(sso.Javadoc); summary != "" && !opts.StripJavadoc {
+		builder.WriteString(indent + "/** " + summary + " */\n")
+	}
+	builder.WriteString(indent + "export interface " + sso.ClassName + " {\n")
+	for _, field := range sso.DeclaredFields {
+		if field.IsStatic {
+			continue // Interfaces describe instances, and a bridge has no access to statics through them
+		}
+		readonly := ""
+		if field.IsFinal {
+			readonly = "readonly "
+		}
+		builder.WriteString(indent + "    " + readonly + field.Name + ": " + table.typeOf(field.Type) + ";\n")
+	}
+	for _, method := range sso.DeclaredMethods {
+		if method.IsStatic {
+			continue
+		}
+		if summary := javadocSummary(method.Javadoc); summary != "" && !opts.StripJavadoc {
+			builder.WriteString(indent + "    /** " + summary + " */\n")
+		}
+		params := make([]string, len(method.Parameters))
+		for i, param := range method.Parameters {
+			if param.IsVarargs {
+				params[i] = "..." + param.Name + ": " + table.typeOf(param.Type+"[]")
+			} else {
+				params[i] = param.Name + ": " + table.typeOf(param.Type)
+			}
+		}
+		builder.WriteString(indent + "    " + method.MethodName + "(" + strings.Join(params, ", ") + "): " + table.typeOf(method.ReturnType) + ";\n")
+	}
+	builder.WriteString(indent + "}\n")
+
+	if len(sso.NestedClasses) > 0 {
+		builder.WriteString("\n" + indent + "export namespace " + sso.ClassName + " {\n")
+		for i := range sso.NestedClasses {
+			if i > 0 {
+				builder.WriteString("\n")
+			}
+			renderTypeScriptInterface(builder, &sso.NestedClasses[i], table, opts, indent+"    ")
+		}
+		builder.WriteString(indent + "}\n")
+	}
+}
+
+// WriteTypeScript writes the declaration files of RenderTypeScript to the directory, leaving files that already hold
+// the same content alone. It returns the number of files written.
+func WriteTypeScript(dir string, ssos []ServerSideObject, opts WriteOptions, tsOpts TypeScriptOptions) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+	files := RenderTypeScript(ssos, opts, tsOpts)
+	written := 0
+	for _, name := range sortedKeys(files) {
+		content := files[name]
+		path := filepath.Join(dir, name)
+		if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+			continue
+		}
+		if err := writeFileAtomically(path, content); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unifiedDiffContext is the number of unchanged lines UnifiedDiff shows around each change, as diff -u does.
+const unifiedDiffContext = 3
+
+// diffLine is a line of a diff: a line of both texts, or a line removed from the first or added in the second.
+type diffLine struct {
+	kind byte   // ' ' for a line of both texts, '-' for a removed line, '+' for an added line
+	text string // The line, without its terminator
+}
+
+// UnifiedDiff returns the differences between two texts in the unified format of diff -u, with the texts labeled
+// fromName and toName, or an empty string when they are the same. The diff is computed line by line from a longest
+// common subsequence, which is simple and fast enough for files the size of simplified SSOs.
+func UnifiedDiff(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var builder strings.Builder
+	builder.WriteString("--- " + fromName + "\n")
+	builder.WriteString("+++ " + toName + "\n")
+
+	// Group the changes into hunks, merging changes whose context would overlap
+	for start := 0; start < len(lines); {
+		for start < len(lines) && lines[start].kind == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		hunkStart := max(start-unifiedDiffContext, 0)
+		end := start
+		for i := start; i < len(lines) && i <= end+2*unifiedDiffContext; i++ {
+			if lines[i].kind != ' ' {
+				end = i
+			}
+		}
+		hunkEnd := min(end+unifiedDiffContext+1, len(lines))
+
+		// Number the hunk by the lines of each text that come before it and that it covers
+		fromLine, toLine := 1, 1
+		for _, line := range lines[:hunkStart] {
+			if line.kind != '+' {
+				fromLine++
+			}
+			if line.kind != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, line := range lines[hunkStart:hunkEnd] {
+			if line.kind != '+' {
+				fromCount++
+			}
+			if line.kind != '-' {
+				toCount++
+			}
+		}
+		builder.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount)))
+		for _, line := range lines[hunkStart:hunkEnd] {
+			builder.WriteString(string(line.kind) + line.text + "\n")
+		}
+		start = hunkEnd
+	}
+	return builder.String()
+}
+
+// hunkRange formats the start and length of a hunk for its header, as diff -u does: an empty range starts at the
+// line before it, and a length of one is left out.
+func hunkRange(start int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines, without a final empty line for a trailing line terminator.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the lines of both texts in order, marking those only in the first as removed and those only in
+// the second as added, keeping a longest common subsequence of lines unchanged.
+func diffLines(from []string, to []string) []diffLine {
+	// common[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	common := make([][]int, len(from)+1)
+	for i := range common {
+		common[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			lines = append(lines, diffLine{' ', from[i]})
+			i++
+			j++
+		case j == len(to) || (i < len(from) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{'-', from[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', to[j]})
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VerifyStatus is how the simplified file of an SSO compares with what would be written for it now.
+type VerifyStatus string
+
+const (
+	VerifyUpToDate VerifyStatus = "up-to-date" // The file holds what would be written
+	VerifyStale    VerifyStatus = "stale"      // The file differs from what would be written
+	VerifyMissing  VerifyStatus = "missing"    // No file was written for the SSO
+	VerifyOrphaned VerifyStatus = "orphaned"   // A generated file whose SSO no longer exists
+)
+
+// VerifyResult is the outcome of verifying a single simplified file.
+type VerifyResult struct {
+	ClassName string       // The class of the SSO, or the file name without its extension for an orphaned file
+	Path      string       // The path of the simplified file
+	Status    VerifyStatus // How the file compares
+	Diff      string       // The unified diff from the file to what would be written, for a stale file
+}
+
+// generatedAtPrefix starts the line of the header naming the generation time, which differs on every run.
+const generatedAtPrefix = "// Generated at: "
+
+// withoutGenerationTime removes the generation time from the header of a simplified file, so that files written at
+// different times compare equal.
+func withoutGenerationTime(content string) string {
+	var builder strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if !strings.HasPrefix(line, generatedAtPrefix) {
+			builder.WriteString(line)
+		}
+	}
+	return builder.String()
+}
+
+// VerifySimplifiedSSOs renders each SSO in memory and compares it with its simplified file in the output directory,
+// without writing anything. Generated files in the output directory that belong to none of the SSOs are reported as
+// orphaned; files without the generated header are not considered. Generation times in the headers are ignored, so
+// files written without --reproducible still verify. The results are ordered by path.
+func VerifySimplifiedSSOs(outputDir string, ssos []ServerSideObject, opts WriteOptions) ([]VerifyResult, error) {
+	opts.Timestamp = time.Time{}
+
+	var results []VerifyResult
+	expected := make(map[string]bool, len(ssos))
+	for i := range ssos {
+		sso := &ssos[i]
+		path := SimplifiedSSOPath(outputDir, sso, opts)
+		expected[path] = true
+		rendered, err := RenderSimplifiedSSO(sso, opts)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			results = append(results, VerifyResult{ClassName: sso.ClassName, Path: path, Status: VerifyMissing})
+		case err != nil:
+			return nil, err
+		case withoutGenerationTime(string(existing)) == rendered:
+			results = append(results, VerifyResult{ClassName: sso.ClassName, Path: path, Status: VerifyUpToDate})
+		default:
+			diff := UnifiedDiff(filepath.ToSlash(path), "(regenerated)", withoutGenerationTime(string(existing)), rendered)
+			results = append(results, VerifyResult{ClassName: sso.ClassName, Path: path, Status: VerifyStale, Diff: diff})
+		}
+	}
+
+	// Look for generated files that no SSO accounts for any more
+	orphaned, err := orphanedFiles(outputDir, expected, opts.Language)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range orphaned {
+		className := strings.TrimSuffix(filepath.Base(path), opts.Language.extension())
+		results = append(results, VerifyResult{ClassName: className, Path: path, Status: VerifyOrphaned})
+	}
+
+	slices.SortStableFunc(results, func(a, b VerifyResult) int { return strings.Compare(a.Path, b.Path) })
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Warning describes a declaration the parser skipped or could not fully understand, so that incomplete stubs do
+// not go unnoticed.
+type Warning struct {
+	Path   string `json:"path"`   // The file the warning is about
+	Line   int    `json:"line"`   // The line of the declaration in the file, or 0 for the whole file
+	Class  string `json:"class"`  // The class being parsed, if any
+	Member string `json:"member"` // The method or field being parsed, if any
+	Reason string `json:"reason"` // Why the declaration was skipped
+	Rule   string `json:"rule"`   // The ID of the WarningRule the warning falls under, such as SSO001
+	// Whether the warning is about a public method left out of the stub
+	SkippedMethod bool `json:"skippedMethod"`
+}
+
+// String formats the warning without its path and line, which callers usually print separately.
+func (w Warning) String() string {
+	switch {
+	case w.Class != "" && w.Member != "":
+		return fmt.Sprintf("%s.%s: %s", w.Class, w.Member, w.Reason)
+	case w.Class != "":
+		return fmt.Sprintf("%s: %s", w.Class, w.Reason)
+	default:
+		return w.Reason
+	}
+}
+
+// WarningRule is a category of warning, identified in SARIF logs and warning output by its ID.
+type WarningRule struct {
+	ID          string // A stable identifier, such as SSO001
+	Name        string // A short kebab-case name, such as unsupported-return-type
+	Description string // A sentence describing what the warnings of the rule report
+}
+
+// The IDs of the warning rules. IDs are never reused, so that suppressions in code review tooling stay valid.
+const (
+	RuleUnsupportedReturnType    = "SSO001"
+	RuleUnsupportedParameterType = "SSO002"
+	RuleUnsupportedFieldType     = "SSO003"
+	RuleUnsupportedNestedType    = "SSO004"
+	RuleUnbalancedBraces         = "SSO005"
+	RuleMissingSerialVersionUID  = "SSO006"
+	RuleInvalidEncoding          = "SSO007"
+	RuleAccessorCollision        = "SSO008"
+	RuleSkippedFile              = "SSO009"
+	RuleGenericMethod            = "SSO010"
+)
+
+// WarningRules lists every warning rule in order of ID.
+var WarningRules = []WarningRule{
+	{RuleUnsupportedReturnType, "unsupported-return-type", "A public method was left out of the stub because its return type is not supported."},
+	{RuleUnsupportedParameterType, "unsupported-parameter-type", "A public method was left out of the stub because the type of a parameter is not supported."},
+	{RuleUnsupportedFieldType, "unsupported-field-type", "A public field was left out of the stub because its type is not supported."},
+	{RuleUnsupportedNestedType, "unsupported-nested-type", "A public nested interface, record, or annotation was left out of the stub."},
+	{RuleUnbalancedBraces, "unbalanced-braces", "A class was skipped because its braces do not balance."},
+	{RuleMissingSerialVersionUID, "missing-serial-version-uid", "A serializable class declares no serialVersionUID, so its stub cannot match the implicit one."},
+	{RuleInvalidEncoding, "invalid-encoding", "A file contains byte sequences that are invalid in the source encoding."},
+	{RuleAccessorCollision, "accessor-collision", "A generated accessor was left out because the class declares a method with the same signature."},
+	{RuleSkippedFile, "skipped-file", "A file could not be scanned or was over the size limit."},
+	{RuleGenericMethod, "generic-method", "A public method was left out of the stub because it declares type parameters."},
+}
+
+// parseWarnings collects the warnings raised while parsing a file or class.
+type parseWarnings struct {
+	path     string    // The file being parsed
+	warnings []Warning // The warnings raised so far
+}
+
+// add records a warning under the rule about a class or one of its members, declared at line.
+func (w *parseWarnings) add(line int, class string, member string, rule string, reason string) {
+	w.warnings = append(w.warnings, Warning{Path: w.path, Line: line, Class: class, Member: member, Reason: reason, Rule: rule})
+}
+
+// addSkippedMethod records a warning under the rule about a public method of a class that is left out of the stub.
+func (w *parseWarnings) addSkippedMethod(line int, class string, method string, rule string, reason string) {
+	w.add(line, class, method, rule, reason)
+	w.warnings[len(w.warnings)-1].SkippedMethod = true
+}
+
+// CountSkippedMethods returns the number of distinct methods the warnings report as left out of their stubs. A
+// method skipped for several reasons is counted once.
+func CountSkippedMethods(warnings []Warning) int {
+	methods := make(map[Warning]bool)
+	for _, warning := range warnings {
+		if warning.SkippedMethod {
+			methods[Warning{Path: warning.Path, Line: warning.Line, Class: warning.Class, Member: warning.Member}] = true
+		}
+	}
+	return len(methods)
+}
+
+// GroupWarningsByFile groups warnings by the file they are about, returning the files in sorted order and the
+// warnings of each file in the order they were raised.
+func GroupWarningsByFile(warnings []Warning) ([]string, map[string][]Warning) {
+	byFile := make(map[string][]Warning)
+	var files []string
+	for _, warning := range warnings {
+		if _, ok := byFile[warning.Path]; !ok {
+			files = append(files, warning.Path)
+		}
+		byFile[warning.Path] = append(byFile[warning.Path], warning)
+	}
+	sort.Strings(files)
+	return files, byFile
+}
@@ -1,77 +1,410 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 )
 
-// WriteSimplifiedSSO writes a ServerSideObject to a simplified .java file with a default constructor and minimal method bodies.
-func WriteSimplifiedSSO(outputDir string, sso *ServerSideObject) error {
-	// Ensure the output directory exists
-	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
-		return err
+// WriteOptions controls how simplified SSOs are written.
+type WriteOptions struct {
+	FlatOutput bool // Write every file directly into the output directory instead of mirroring the package structure
+	// Leave out the extends clause of each class, which is reproduced by default so stubs stay assignable to their base
+	OmitExtends bool
+	// Reproduce the implements clause of each class, which then only compiles if the interfaces are available
+	EmitImplements bool
+	// Default return values of types allowed in addition to the built-in ones, keyed by type name as in ScanOptions
+	AllowedTypes map[string]string
+	// Leave out the Javadoc comments of classes and methods, which are reproduced by default
+	StripJavadoc bool
+	// Name and version of the generating tool for the header comment of each file; no header is written when empty
+	Generator string
+	// Generation time for the header comment, left out when zero so that re-runs produce identical files
+	Timestamp time.Time
+	// License text to start each file with, wrapped in a block comment unless it is a comment already; ${YEAR} is
+	// replaced with the year of the Timestamp, or the current year when it is zero
+	License string
+	// Number of spaces per level of nesting, 4 when unset; ignored when UseTabs is set
+	IndentWidth int
+	// Indent with one tab per level of nesting instead of spaces
+	UseTabs bool
+	// Where opening braces are placed, BraceSameLine when unset
+	BraceStyle BraceStyle
+	// What the method bodies do, StubBodyDefault when unset
+	StubBody StubBody
+	// The line endings of the written files, LineEndingLF when unset
+	LineEnding LineEnding
+	// Template to render each file with instead of the built-in format; see TemplateData and ParseTemplate
+	Template *template.Template
+	// Order fields by name and methods by name, then parameter types, instead of keeping their source order followed
+	// by the inherited methods, so moving a member in the source does not change the output
+	SortMembers bool
+	// Replace existing files even when they lack the generated header
+	Force bool
+	// Rewrite files whose content has not changed, updating their modification times
+	Touch bool
+	// The language of the simplified files, LanguageJava when unset
+	Language Language
+	// How the interfaces extracted from the SSOs are named; see WriteInterfaces
+	InterfaceNaming InterfaceNaming
+	// Make each SSO implement the interface WriteInterfaces extracts from it, which must then be compiled alongside
+	ImplementInterfaces bool
+}
+
+// StubBody is what the body of each simplified method does.
+type StubBody string
+
+const (
+	StubBodyDefault StubBody = "default" // Return the default value of the return type (the default)
+	StubBodyThrow   StubBody = "throw"   // Throw an UnsupportedOperationException, so calls against the stubs fail loudly
+	StubBodyTODO    StubBody = "todo"    // Return the default value below a TODO comment
+)
+
+// LineEnding is the line terminator of the simplified files.
+type LineEnding string
+
+const (
+	LineEndingLF     LineEnding = "lf"     // Terminate lines with \n (the default)
+	LineEndingCRLF   LineEnding = "crlf"   // Terminate lines with \r\n
+	LineEndingNative LineEnding = "native" // Terminate lines the way the operating system does, \r\n on Windows
+)
+
+// terminator returns the line terminator of the line ending.
+func (l LineEnding) terminator() string {
+	if l == LineEndingCRLF || (l == LineEndingNative && runtime.GOOS == "windows") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// SimplifiedSSOPath returns the path of the simplified .java file, or .kt file for Kotlin, for a ServerSideObject.
+// Unless FlatOutput is set, the file is placed in the directory matching its package, as javac expects.
+func SimplifiedSSOPath(outputDir string, sso *ServerSideObject, opts WriteOptions) string {
+	fileName := sso.ClassName + opts.Language.extension()
+	if opts.FlatOutput || sso.PackageLine == "" {
+		return filepath.Join(outputDir, fileName)
 	}
+	packageDir := filepath.Join(strings.Split(sso.PackageLine, ".")...)
+	return filepath.Join(outputDir, packageDir, fileName)
+}
 
+// generatedHeaderPrefix starts the header comment of every file written with a Generator set.
+const generatedHeaderPrefix = "// Generated by "
+
+// ErrNotGenerated is wrapped by the error of a write that would replace an existing file without the generated
+// header, which may have been written or edited by hand. WriteOptions.Force replaces such files anyway.
+var ErrNotGenerated = errors.New("existing file was not generated by this tool")
+
+// WriteSimplifiedSSO writes a ServerSideObject to a simplified .java file with a default constructor and minimal method
+// bodies, at the path given by SimplifiedSSOPath, as UpdateSimplifiedSSO does.
+func WriteSimplifiedSSO(outputDir string, sso *ServerSideObject, opts WriteOptions) error {
+	_, err := UpdateSimplifiedSSO(outputDir, sso, opts)
+	return err
+}
+
+// UpdateSimplifiedSSO writes a ServerSideObject to its simplified .java file unless the file already holds the same
+// content, reporting whether it was written; Touch writes it regardless. The file is written to a temporary file and
+// renamed into place, so a failed write never leaves a partial file behind. When a Generator is set, an existing file
+// without the generated header is only replaced if Force is set; otherwise the error wraps ErrNotGenerated.
+func UpdateSimplifiedSSO(outputDir string, sso *ServerSideObject, opts WriteOptions) (bool, error) {
 	// Construct the output file path
-	outputFilePath := filepath.Join(outputDir, sso.ClassName+".java")
+	outputFilePath := SimplifiedSSOPath(outputDir, sso, opts)
 
-	// Open the file for writing
-	file, err := os.Create(outputFilePath)
+	rendered, err := RenderSimplifiedSSO(sso, opts)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("writing %s: %w", outputFilePath, err)
 	}
-	defer file.Close()
 
-	// Write the simplified SSO content
-	if _, err := file.WriteString("package " + sso.PackageLine + ";\n\n"); err != nil {
-		return err
+	// Leave files that were not generated alone unless forced, and files that would not change unless touched, where
+	// a new generation time alone is no change, so that reruns and watch mode rewrite only the SSOs that changed
+	if existing, err := os.ReadFile(outputFilePath); err == nil {
+		if opts.Generator != "" && !opts.Force && !isGeneratedFile(string(existing)) {
+			return false, fmt.Errorf("%s: %w", outputFilePath, ErrNotGenerated)
+		}
+		if !opts.Touch && withoutGenerationTime(string(existing)) == withoutGenerationTime(rendered) {
+			return false, nil
+		}
+	}
+
+	if err := writeFileAtomically(outputFilePath, rendered); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeFileAtomically writes content to a temporary file and renames it into place, so a failed write never leaves a
+// partial file behind. Errors name the path being written.
+func writeFileAtomically(path string, content string) error {
+	// Ensure the output directory, including any package directories, exists
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	// Write to a temporary file next to the target, so the rename that replaces it stays on one filesystem
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	defer os.Remove(file.Name()) // Fails harmlessly once the file has been renamed
+
+	_, err = io.WriteString(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), generatedFileMode)
 	}
-	if _, err := file.WriteString("public class " + sso.ClassName + " {\n\n"); err != nil {
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// generatedFileMode is the permission of the simplified files, which temporary files do not get by default.
+const generatedFileMode = 0o644
+
+// RenderSimplifiedSSO returns the simplified source of a ServerSideObject, as WriteSimplifiedSSO would write it.
+func RenderSimplifiedSSO(sso *ServerSideObject, opts WriteOptions) (string, error) {
+	var builder strings.Builder
+	if err := WriteSimplifiedSSOTo(&builder, sso, opts); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// WriteSimplifiedSSOTo writes the simplified source of a ServerSideObject to w.
+func WriteSimplifiedSSOTo(w io.Writer, sso *ServerSideObject, opts WriteOptions) error {
+	if sso.ClassName == "" {
+		return errors.New("cannot simplify an SSO without a class name")
+	}
+
+	// Render the header, package line, class, and file enums through the built-in template unless another is set
+	tmpl := opts.Template
+	if tmpl == nil && opts.Language == LanguageKotlin {
+		tmpl = kotlinTemplate
+	} else if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	var builder strings.Builder
+	if err := executeTemplate(&builder, tmpl, sso, opts); err != nil {
 		return err
 	}
 
-	// Write public fields before constructor and methods
-	for _, field := range sso.DeclaredFields {
-		line := "    public " + field.Type + " " + field.Name + ";\n\n"
-		if _, err := file.WriteString(line); err != nil {
-			return err
+	// Terminate every line the same way, including those of the license, Javadoc, and template text
+	rendered := strings.ReplaceAll(builder.String(), "\r\n", "\n")
+	if terminator := opts.LineEnding.terminator(); terminator != "\n" {
+		rendered = strings.ReplaceAll(rendered, "\n", terminator)
+	}
+	_, err := io.WriteString(w, rendered)
+	return err
+}
+
+// renderHeader renders the comment at the top of a simplified file naming the generator, the generation time, and
+// the source file, if the SSO has one, or nothing when no generator is set.
+func renderHeader(sso *ServerSideObject, opts WriteOptions) string {
+	header := renderLicense(opts)
+	if opts.Generator == "" {
+		return header
+	}
+	header += generatedHeaderPrefix + opts.Generator + ". Do not edit.\n"
+	if !opts.Timestamp.IsZero() {
+		header += "// Generated at: " + opts.Timestamp.UTC().Format(time.RFC3339) + "\n"
+	}
+	if sso.FilePath != "" {
+		header += "// Source: " + filepath.ToSlash(sso.FilePath) + "\n"
+	}
+	return header + "\n"
+}
+
+// renderLicense renders the license header of the options as a comment followed by a blank line, or nothing when no
+// license is set.
+func renderLicense(opts WriteOptions) string {
+	year := opts.Timestamp
+	if year.IsZero() {
+		year = time.Now()
+	}
+	license := strings.ReplaceAll(opts.License, "\r\n", "\n")
+	license = strings.TrimSpace(strings.ReplaceAll(license, "${YEAR}", strconv.Itoa(year.Year())))
+	if license == "" {
+		return ""
+	}
+	if strings.HasPrefix(license, "/*") || strings.HasPrefix(license, "//") {
+		return license + "\n\n"
+	}
+
+	// Wrap plain text in a block comment, keeping any comment terminator in it from ending the comment early
+	comment := "/*\n"
+	for _, line := range strings.Split(strings.ReplaceAll(license, "*/", "* /"), "\n") {
+		comment += strings.TrimRight(" * "+line, " ") + "\n"
+	}
+	return comment + " */\n\n"
+}
+
+// isGeneratedFile reports whether file content carries the generated header, which may follow a license header.
+func isGeneratedFile(content string) bool {
+	head, _, _ := strings.Cut(content, "\npackage ")
+	return strings.HasPrefix(head, generatedHeaderPrefix) || strings.Contains(head, "\n"+generatedHeaderPrefix)
+}
+
+// renderClass renders a simplified class declaration, including its nested classes, at the emitter's indentation.
+func renderClass(e *javaEmitter, sso *ServerSideObject, opts WriteOptions) {
+	types := newTypeTable(opts.AllowedTypes, false)
+
+	// Abstract SSOs stay abstract, but their methods keep concrete stub bodies, which abstract classes allow
+	classModifiers := "public "
+	if sso.IsStatic {
+		classModifiers += "static "
+	}
+	if sso.IsAbstract {
+		classModifiers += "abstract "
+	}
+	extendsClause := ""
+	if !opts.OmitExtends && sso.SuperClass != "" {
+		extendsClause = " extends " + sso.SuperClass
+	}
+	// Serializable classes stay serializable even without EmitImplements, qualified since the stubs import nothing
+	var interfaces []string
+	for _, iface := range sso.Interfaces {
+		if simpleTypeName(iface) == "Serializable" {
+			interfaces = append(interfaces, "java.io.Serializable")
+		} else if opts.EmitImplements {
+			interfaces = append(interfaces, iface)
 		}
 	}
+	if opts.ImplementInterfaces && extractsInterface(sso) {
+		interfaces = append(interfaces, interfaceReference(sso, opts))
+	}
+	implementsClause := ""
+	if len(interfaces) > 0 {
+		implementsClause = " implements " + strings.Join(interfaces, ", ")
+	}
+	if !opts.StripJavadoc && sso.Javadoc != "" {
+		e.lines(sso.Javadoc)
+	}
+	if sso.IsDeprecated {
+		e.line("@Deprecated")
+	}
+	e.open(classModifiers + "class " + sso.ClassName + sso.TypeParameters + extendsClause + implementsClause)
+	e.line("")
 
-	// Write the empty public constructor
-	if _, err := file.WriteString("    public " + sso.ClassName + "() {}\n\n"); err != nil {
-		return err
+	// Reproduce the serialVersionUID exactly, so stub and real class serialize compatibly
+	if sso.SerialVersionUID != "" {
+		e.line("private static final long serialVersionUID = " + sso.SerialVersionUID + ";")
+		e.line("")
 	}
 
-	for _, method := range sso.DeclaredMethods {
-		methodSignature := "    public " + method.ReturnType + " " + method.MethodName + "("
-		for i, param := range method.Parameters {
-			if i > 0 {
-				methodSignature += ", "
-			}
-			methodSignature += param.Type + " " + param.Name
+	// Write public fields before constructor and methods, keeping constant values and defaulting everything else
+	fields, methods := sso.DeclaredFields, sso.DeclaredMethods
+	if opts.SortMembers {
+		fields, methods = sortedFields(fields), sortedMethods(methods)
+	}
+	for _, field := range fields {
+		initializer := field.Initializer
+		if initializer == "" {
+			initializer = types.defaultValueFor(field.Type)
 		}
-		methodSignature += ") {\n"
-
-		// Simplify the method body with a return statement for the simplest form of the return type
-		if method.ReturnType != "void" {
-			methodBody := "        return "
-			if defaultValue, ok := allowedTypes[method.ReturnType]; ok {
-				methodBody += defaultValue + ";"
-			} else {
-				methodBody += "null;" // Fallback for unsupported types
-			}
-			methodSignature += methodBody + "\n"
+		if field.IsDeprecated {
+			e.line("@Deprecated")
 		}
-		methodSignature += "    }\n\n"
+		e.line(accessModifier(field.AccessModifier) + " " + memberModifiers(field.IsStatic, field.IsFinal) + field.Type + " " + field.Name + " = " + initializer + ";")
+		e.line("")
+	}
+
+	// Write the empty public constructor
+	e.empty("public " + sso.ClassName + "()")
+	e.line("")
 
-		if _, err := file.WriteString(methodSignature); err != nil {
-			return err
+	for _, method := range methods {
+		if !opts.StripJavadoc && method.Javadoc != "" {
+			e.lines(method.Javadoc)
+		}
+		if method.IsDeprecated {
+			e.line("@Deprecated")
 		}
+		methodSignature := accessModifier(method.AccessModifier) + " " + memberModifiers(method.IsStatic, method.IsFinal) + method.ReturnType + " " + method.MethodName + "(" + joinParameters(method.Parameters) + ")"
+		if len(method.Exceptions) > 0 {
+			methodSignature += " throws " + strings.Join(method.Exceptions, ", ")
+		}
+		e.open(methodSignature)
+
+		// Simplify the method body with a throw, or a return statement for the simplest form of the return type
+		switch opts.StubBody {
+		case StubBodyThrow:
+			e.line(`throw new UnsupportedOperationException("SSO stub");`)
+		case StubBodyTODO:
+			e.line("// TODO: SSO stub")
+			fallthrough
+		default:
+			if method.ReturnType != "void" {
+				e.line("return " + types.defaultValueFor(method.ReturnType) + ";")
+			}
+		}
+		e.close()
+		e.line("")
 	}
-	if _, err := file.WriteString("}\n"); err != nil {
-		return err
+
+	// Write nested classes as nested stubs so references such as Outer.Inner still compile
+	for i := range sso.NestedClasses {
+		renderClass(e, &sso.NestedClasses[i], opts)
+		e.line("")
 	}
 
-	return nil
+	// Write nested enums verbatim, since they have no implementation to hide
+	for _, enum := range sso.NestedEnums {
+		e.line(enum.Source)
+		e.line("")
+	}
+
+	e.close()
+}
+
+// sortedFields returns a copy of the fields ordered by name.
+func sortedFields(fields []PublicField) []PublicField {
+	sorted := slices.Clone(fields)
+	slices.SortStableFunc(sorted, func(a, b PublicField) int { return strings.Compare(a.Name, b.Name) })
+	return sorted
+}
+
+// sortedMethods returns a copy of the methods ordered by name and then by erased parameter types, so overloads keep
+// the same order whatever their order in the source. The sort is stable, though signatures are unique after merging.
+func sortedMethods(methods []PublicMethod) []PublicMethod {
+	sorted := slices.Clone(methods)
+	slices.SortStableFunc(sorted, func(a, b PublicMethod) int {
+		if byName := strings.Compare(a.MethodName, b.MethodName); byName != 0 {
+			return byName
+		}
+		return strings.Compare(methodSignature(a), methodSignature(b))
+	})
+	return sorted
+}
+
+// accessModifier returns the recorded access modifier of a member, which is public when none was recorded.
+func accessModifier(recorded string) string {
+	if recorded == "" {
+		return "public"
+	}
+	return recorded
+}
+
+// memberModifiers returns the static and final modifiers of a member in canonical order, each followed by a space.
+func memberModifiers(isStatic bool, isFinal bool) string {
+	modifiers := ""
+	if isStatic {
+		modifiers += "static "
+	}
+	if isFinal {
+		modifiers += "final "
+	}
+	return modifiers
 }
@@ -0,0 +1,567 @@
+package utils
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// update makes the golden tests rewrite their golden files with the rendered output instead of comparing against them.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the rendered output")
+
+// renderGoldenSSO parses testdata/AccountSSO.java and renders its SSO with the options, failing the test if either
+// step fails.
+func renderGoldenSSO(t *testing.T, opts WriteOptions) string {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", "AccountSSO.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sso, _, err := ParseSSOSource("AccountSSO.java", src)
+	if err != nil || sso == nil {
+		t.Fatalf("ParseSSOSource = %v, %v", sso, err)
+	}
+	rendered, err := RenderSimplifiedSSO(sso, opts)
+	if err != nil {
+		t.Fatalf("RenderSimplifiedSSO: %v", err)
+	}
+	return rendered
+}
+
+// checkGolden compares output with the golden file testdata/name, byte for byte, or rewrites the golden file with
+// it when -update is set.
+func checkGolden(t *testing.T, name string, output string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if string(golden) != output {
+		t.Errorf("output differs from %s (run go test -update to accept it):\n%s", path, UnifiedDiff(path, "output", string(golden), output))
+	}
+}
+
+// renderTestSSO parses the source of a single file and renders its SSO with the options, failing the test if either
+// step fails.
+func renderTestSSO(t *testing.T, src string, opts WriteOptions) string {
+	t.Helper()
+	sso, _ := parseTestSSO(t, src)
+	rendered, err := RenderSimplifiedSSO(sso, opts)
+	if err != nil {
+		t.Fatalf("RenderSimplifiedSSO: %v", err)
+	}
+	return rendered
+}
+
+// compileSimplifiedSSOs writes the SSOs and the stubs of their base classes with the options and compiles them with
+// javac, skipping the test when javac is not on the PATH.
+func compileSimplifiedSSOs(t *testing.T, ssos []ServerSideObject, opts WriteOptions) {
+	t.Helper()
+	javac, err := exec.LookPath("javac")
+	if err != nil {
+		t.Skip("javac is not on the PATH")
+	}
+	outputDir := t.TempDir()
+	for _, sso := range append(slices.Clone(ssos), BaseClassStubs(ssos)...) {
+		if err := WriteSimplifiedSSO(outputDir, &sso, opts); err != nil {
+			t.Fatalf("WriteSimplifiedSSO: %v", err)
+		}
+	}
+	args := []string{"-d", filepath.Join(t.TempDir(), "classes")}
+	err = filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".java") {
+			args = append(args, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(javac, args...).CombinedOutput(); err != nil {
+		t.Errorf("javac: %v\n%s", err, output)
+	}
+}
+
+func TestRenderSimplifiedSSOVoidMethods(t *testing.T) {
+	src := `package com.example;
+
+public class Lifecycle extends ServerSideObject {
+    public void start() { running = true; }
+    public void stop(int code) { System.exit(code); }
+}
+`
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "empty body without parameters", want: "    public void start() {\n    }\n"},
+		{name: "empty body with parameters", want: "    public void stop(int code) {\n    }\n"},
+	}
+	rendered := renderTestSSO(t, src, WriteOptions{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !strings.Contains(rendered, test.want) {
+				t.Errorf("rendered SSO does not contain %q:\n%s", test.want, rendered)
+			}
+		})
+	}
+
+	// The inherited getLastError is the only method that returns a value
+	if count := strings.Count(rendered, "return "); count != 1 {
+		t.Errorf("rendered SSO has %d return statements, want 1:\n%s", count, rendered)
+	}
+}
+
+func TestRenderSimplifiedSSOArrays(t *testing.T) {
+	src := `package com.example;
+
+public class Blobs extends ServerSideObject {
+    public byte[] blob() { return data; }
+    public int sum(int values[], String[] names) { return 0; }
+}
+`
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "array returns null", want: "    public byte[] blob() {\n        return null;\n    }\n"},
+		{name: "brackets move to the type", want: "    public int sum(int[] values, String[] names) {\n"},
+	}
+	rendered := renderTestSSO(t, src, WriteOptions{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !strings.Contains(rendered, test.want) {
+				t.Errorf("rendered SSO does not contain %q:\n%s", test.want, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSOBoxedTypesReturnNull(t *testing.T) {
+	src := `package com.example;
+
+public class Counts extends ServerSideObject {
+    public Integer count() { return 1; }
+    public java.lang.Boolean enabled() { return true; }
+    public int primitive() { return 1; }
+}
+`
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "simple name", want: "    public Integer count() {\n        return null;\n    }\n"},
+		{name: "java.lang name", want: "    public Boolean enabled() {\n        return null;\n    }\n"},
+		{name: "primitive keeps its default", want: "    public int primitive() {\n        return 0;\n    }\n"},
+	}
+	rendered := renderTestSSO(t, src, WriteOptions{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !strings.Contains(rendered, test.want) {
+				t.Errorf("rendered SSO does not contain %q:\n%s", test.want, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSOOverriddenGetLastError(t *testing.T) {
+	rendered := renderTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    public String getLastError() { return error; }\n}\n", WriteOptions{})
+	if count := strings.Count(rendered, "getLastError()"); count != 1 {
+		t.Errorf("rendered SSO declares getLastError %d times, want 1:\n%s", count, rendered)
+	}
+}
+
+func TestRenderSimplifiedSSOThrowsClauses(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "single exception",
+			body: "public String fetchToken(int userId) throws SSOException { return null; }",
+			want: "    public String fetchToken(int userId) throws SSOException {\n",
+		},
+		{
+			name: "multiple exceptions",
+			body: "public void close()\n        throws SSOException,java.io.IOException { }",
+			want: "    public void close() throws SSOException, java.io.IOException {\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered := renderTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n", WriteOptions{})
+			if !strings.Contains(rendered, test.want) {
+				t.Errorf("rendered SSO does not contain %q:\n%s", test.want, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSOFieldDeclarators(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "two declarators",
+			body: "public int a, b;",
+			want: []string{"    public int a = 0;\n", "    public int b = 0;\n"},
+		},
+		{
+			name: "three declarators",
+			body: "public long c, d, e;",
+			want: []string{"    public long c = 0L;\n", "    public long d = 0L;\n", "    public long e = 0L;\n"},
+		},
+		{
+			name: "constant initializers",
+			body: "public static final String P = \"a,b\", Q = \"c\";",
+			want: []string{"    public static final String P = \"a,b\";\n", "    public static final String Q = \"c\";\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered := renderTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n    "+test.body+"\n}\n", WriteOptions{})
+			for _, want := range test.want {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("rendered SSO does not contain %q:\n%s", want, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSODeprecatedMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		opts    WriteOptions
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "deprecated class",
+			src:     "package com.example;\n\n@Deprecated\npublic class Foo extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+			want:    []string{"@Deprecated\npublic class Foo extends ServerSideObject {\n"},
+			notWant: []string{"    @Deprecated\n"},
+		},
+		{
+			name:    "deprecated method",
+			src:     "package com.example;\n\npublic class Foo extends ServerSideObject {\n    @Deprecated public int old() { return 0; }\n    public int current() { return 0; }\n}\n",
+			want:    []string{"    @Deprecated\n    public int old() {\n", "\n    public int current() {\n"},
+			notWant: []string{"@Deprecated\npublic class", "@Deprecated\n    public int current()"},
+		},
+		{
+			name:    "deprecated field",
+			src:     "package com.example;\n\npublic class Foo extends ServerSideObject {\n    @Deprecated\n    public String legacy;\n    public String name;\n}\n",
+			want:    []string{"    @Deprecated\n    public String legacy = null;\n", "\n    public String name = null;\n"},
+			notWant: []string{"@Deprecated\n    public String name"},
+		},
+		{
+			name: "deprecated Javadoc tag",
+			src:  "package com.example;\n\n/**\n * @deprecated Use Bar.\n */\npublic class Foo extends ServerSideObject {\n    /**\n     * @deprecated Use size.\n     */\n    public int count() { return 0; }\n}\n",
+			want: []string{"@Deprecated\npublic class Foo", "    @Deprecated\n    public int count() {\n"},
+		},
+		{
+			name: "qualified annotation",
+			src:  "package com.example;\n\n@java.lang.Deprecated\npublic class Foo extends ServerSideObject {\n}\n",
+			want: []string{"@Deprecated\npublic class Foo"},
+		},
+		{
+			name: "kotlin",
+			src:  "package com.example;\n\n@Deprecated\npublic class Foo extends ServerSideObject {\n    @Deprecated public int old() { return 0; }\n    @Deprecated public String legacy;\n}\n",
+			opts: WriteOptions{Language: LanguageKotlin},
+			want: []string{
+				"@Deprecated(\"Deprecated in the source\")\nopen class Foo",
+				"    @Deprecated(\"Deprecated in the source\")\n    open fun old(",
+				"    @Deprecated(\"Deprecated in the source\")\n    @JvmField var legacy: String? = null\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered := renderTestSSO(t, test.src, test.opts)
+			for _, want := range test.want {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("rendered SSO does not contain %q:\n%s", want, rendered)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(rendered, notWant) {
+					t.Errorf("rendered SSO contains %q:\n%s", notWant, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSOHeader(t *testing.T) {
+	generatedAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name string
+		opts WriteOptions
+		want string
+	}{
+		{
+			name: "no generator",
+			opts: WriteOptions{Timestamp: generatedAt},
+			want: "package com.example;\n",
+		},
+		{
+			name: "generator and timestamp",
+			opts: WriteOptions{Generator: "sso_simplifier v1.2.3", Timestamp: generatedAt},
+			want: "// Generated by sso_simplifier v1.2.3. Do not edit.\n// Generated at: 2024-03-01T11:30:00Z\n// Source: src/com/example/Foo.java\n\npackage com.example;\n",
+		},
+		{
+			name: "reproducible",
+			opts: WriteOptions{Generator: "sso_simplifier v1.2.3"},
+			want: "// Generated by sso_simplifier v1.2.3. Do not edit.\n// Source: src/com/example/Foo.java\n\npackage com.example;\n",
+		},
+		{
+			name: "kotlin",
+			opts: WriteOptions{Generator: "sso_simplifier v1.2.3", Timestamp: generatedAt, Language: LanguageKotlin},
+			want: "// Generated by sso_simplifier v1.2.3. Do not edit.\n// Generated at: 2024-03-01T11:30:00Z\n// Source: src/com/example/Foo.java\n\npackage com.example\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sso, _ := parseTestSSO(t, "package com.example;\n\npublic class Foo extends ServerSideObject {\n}\n")
+			sso.FilePath = filepath.Join("src", "com", "example", "Foo.java")
+			rendered, err := RenderSimplifiedSSO(sso, test.opts)
+			if err != nil {
+				t.Fatalf("RenderSimplifiedSSO: %v", err)
+			}
+			if !strings.HasPrefix(rendered, test.want) {
+				t.Errorf("rendered SSO does not start with %q:\n%s", test.want, rendered)
+			}
+			if got, want := isGeneratedFile(rendered), test.opts.Generator != ""; got != want {
+				t.Errorf("isGeneratedFile = %t, want %t", got, want)
+			}
+
+			// Rendering again must give the same bytes when no timestamp is set
+			if test.opts.Timestamp.IsZero() {
+				again, err := RenderSimplifiedSSO(sso, test.opts)
+				if err != nil {
+					t.Fatalf("RenderSimplifiedSSO: %v", err)
+				}
+				if again != rendered {
+					t.Errorf("second render differs:\n%s\nfirst:\n%s", again, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSimplifiedSSOGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		opts   WriteOptions
+	}{
+		{golden: "AccountSSO.java.golden"},
+		{golden: "AccountSSO.indent2.java.golden", opts: WriteOptions{IndentWidth: 2}},
+		{golden: "AccountSSO.tabs.java.golden", opts: WriteOptions{UseTabs: true}},
+		{golden: "AccountSSO.nextline.java.golden", opts: WriteOptions{BraceStyle: BraceNextLine}},
+		{golden: "AccountSSO.kt.golden", opts: WriteOptions{Language: LanguageKotlin}},
+		{golden: "AccountSSO.throw.kt.golden", opts: WriteOptions{Language: LanguageKotlin, StubBody: StubBodyThrow}},
+		{golden: "AccountSSO.tabs.kt.golden", opts: WriteOptions{Language: LanguageKotlin, UseTabs: true}},
+		{golden: "AccountSSO.crlf.java.golden", opts: WriteOptions{LineEnding: LineEndingCRLF}},
+		{golden: "AccountSSO.crlf.kt.golden", opts: WriteOptions{LineEnding: LineEndingCRLF, Language: LanguageKotlin}},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			test.opts.Generator = "sso_simplifier"
+			rendered := renderGoldenSSO(t, test.opts)
+			if test.opts.LineEnding == LineEndingCRLF && strings.Count(rendered, "\n") != strings.Count(rendered, "\r\n") {
+				t.Errorf("rendered SSO has lines not terminated with \\r\\n:\n%q", rendered)
+			}
+			checkGolden(t, test.golden, rendered)
+		})
+	}
+}
+
+func TestDefaultPackageSSO(t *testing.T) {
+	var log bytes.Buffer
+	ssos := scanTestFS(t, map[string]string{
+		"Foo.java": "public class Foo extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+	}, WithLogger(NewWriterLogger(&log)))
+	if len(ssos) != 1 {
+		t.Fatalf("found %d SSOs, want 1", len(ssos))
+	}
+	if ssos[0].PackageLine != "" {
+		t.Errorf("PackageLine = %q, want empty", ssos[0].PackageLine)
+	}
+	if !strings.Contains(log.String(), "default package") {
+		t.Errorf("scan did not warn about the default package:\n%s", log.String())
+	}
+
+	rendered, err := RenderSimplifiedSSO(&ssos[0], WriteOptions{})
+	if err != nil {
+		t.Fatalf("RenderSimplifiedSSO: %v", err)
+	}
+	if strings.Contains(rendered, "package") {
+		t.Errorf("rendered SSO has a package line:\n%s", rendered)
+	}
+	compileSimplifiedSSOs(t, ssos, WriteOptions{})
+}
+
+func TestRenderSimplifiedSSOStubBodies(t *testing.T) {
+	src := `package com.example;
+
+public class Counter extends ServerSideObject {
+    public Counter(int start) { count = start; }
+    public void reset() { count = 0; }
+    public int count() { return count; }
+    public String label() { return "counter"; }
+}
+`
+	tests := []struct {
+		stubBody StubBody
+		want     []string
+	}{
+		{
+			stubBody: StubBodyDefault,
+			want: []string{
+				"    public Counter() {}\n",
+				"    public void reset() {\n    }\n",
+				"    public int count() {\n        return 0;\n    }\n",
+				"    public String label() {\n        return null;\n    }\n",
+			},
+		},
+		{
+			stubBody: StubBodyThrow,
+			want: []string{
+				"    public Counter() {}\n",
+				"    public void reset() {\n        throw new UnsupportedOperationException(\"SSO stub\");\n    }\n",
+				"    public int count() {\n        throw new UnsupportedOperationException(\"SSO stub\");\n    }\n",
+				"    public String label() {\n        throw new UnsupportedOperationException(\"SSO stub\");\n    }\n",
+			},
+		},
+		{
+			stubBody: StubBodyTODO,
+			want: []string{
+				"    public Counter() {}\n",
+				"    public void reset() {\n        // TODO: SSO stub\n    }\n",
+				"    public int count() {\n        // TODO: SSO stub\n        return 0;\n    }\n",
+				"    public String label() {\n        // TODO: SSO stub\n        return null;\n    }\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.stubBody), func(t *testing.T) {
+			opts := WriteOptions{StubBody: test.stubBody}
+			rendered := renderTestSSO(t, src, opts)
+			for _, want := range test.want {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("rendered SSO does not contain %q:\n%s", want, rendered)
+				}
+			}
+			compileSimplifiedSSOs(t, scanTestFS(t, map[string]string{"com/example/Counter.java": src}), opts)
+		})
+	}
+}
+
+func TestUpdateSimplifiedSSOLineEndings(t *testing.T) {
+	sso, _ := parseTestSSO(t, "package com.example;\n\n/**\n * Counts.\n */\npublic class Foo extends ServerSideObject {\n    public int size() { return 0; }\n}\n")
+	outputDir := t.TempDir()
+	steps := []struct {
+		lineEnding  LineEnding
+		wantWritten bool
+	}{
+		{LineEndingLF, true},
+		{LineEndingLF, false},
+		{LineEndingCRLF, true},
+		{LineEndingCRLF, false},
+		{LineEndingLF, true},
+	}
+	for i, step := range steps {
+		opts := WriteOptions{Generator: "sso_simplifier", LineEnding: step.lineEnding}
+		written, err := UpdateSimplifiedSSO(outputDir, sso, opts)
+		if err != nil {
+			t.Fatalf("step %d: UpdateSimplifiedSSO: %v", i, err)
+		}
+		if written != step.wantWritten {
+			t.Errorf("step %d: written = %t with %s, want %t", i, written, step.lineEnding, step.wantWritten)
+		}
+		content, err := os.ReadFile(SimplifiedSSOPath(outputDir, sso, opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 0
+		if step.lineEnding == LineEndingCRLF {
+			want = bytes.Count(content, []byte("\n"))
+		}
+		if got := bytes.Count(content, []byte("\r\n")); got != want {
+			t.Errorf("step %d: file has %d CRLF line endings with %s, want %d:\n%q", i, got, step.lineEnding, want, content)
+		}
+	}
+}
+
+func TestBaseClassStubsGenericSuperclass(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "qualified with a type argument",
+			files: map[string]string{
+				"com/example/Foo.java": "package com.example;\n\npublic class Foo extends com.vip.ServerSideObject<String> {\n    public int size() { return 0; }\n}\n",
+			},
+			want: "package com.vip;\n\npublic abstract class ServerSideObject<T> {\n",
+		},
+		{
+			name: "two type arguments",
+			files: map[string]string{
+				"com/example/Foo.java": "package com.example;\n\npublic class Foo extends ServerSideObject<String, java.util.Map<String, Long>> {\n    public int size() { return 0; }\n}\n",
+			},
+			want: "package com.example;\n\npublic abstract class ServerSideObject<T1, T2> {\n",
+		},
+		{
+			name: "raw and generic extends of one base class",
+			files: map[string]string{
+				"com/example/Bar.java": "package com.example;\n\npublic class Bar extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+				"com/example/Foo.java": "package com.example;\n\npublic class Foo extends ServerSideObject<String> {\n    public int size() { return 0; }\n}\n",
+			},
+			want: "package com.example;\n\npublic abstract class ServerSideObject<T> {\n",
+		},
+		{
+			name: "plain",
+			files: map[string]string{
+				"com/example/Foo.java": "package com.example;\n\npublic class Foo extends ServerSideObject {\n    public int size() { return 0; }\n}\n",
+			},
+			want: "package com.example;\n\npublic abstract class ServerSideObject {\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ssos := scanTestFS(t, test.files)
+			stubs := BaseClassStubs(ssos)
+			if len(stubs) != 1 {
+				t.Fatalf("got %d base class stubs, want 1", len(stubs))
+			}
+			rendered, err := RenderSimplifiedSSO(&stubs[0], WriteOptions{})
+			if err != nil {
+				t.Fatalf("RenderSimplifiedSSO: %v", err)
+			}
+			if !strings.HasPrefix(rendered, test.want) {
+				t.Errorf("base class stub does not start with %q:\n%s", test.want, rendered)
+			}
+			compileSimplifiedSSOs(t, ssos, WriteOptions{})
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package version reports the version of sso_simplifier, so that the --version flag, the header of generated files,
+// and the manifest all name the same build.
+//
+// Release builds set the version, commit, and build date with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/JoshuaAtTrimble/SSO-Simplifier/version.version=1.2.3
+//	  -X github.com/JoshuaAtTrimble/SSO-Simplifier/version.commit=$(git rev-parse HEAD)
+//	  -X github.com/JoshuaAtTrimble/SSO-Simplifier/version.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them fall back to the module version and VCS stamp Go records in the binary.
+package version
+
+import (
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// The build information set with -ldflags, empty when not set.
+var (
+	version string // The semantic version, such as 1.2.3
+	commit  string // The git commit the binary was built from
+	date    string // The time the binary was built, in RFC 3339
+)
+
+// pseudoVersion matches the versions Go stamps on builds of untagged commits, such as
+// v0.0.0-20240501120000-0123456789ab+dirty, which name a commit rather than a release.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}(\+dirty)?$`)
+
+// shortCommitLength is the length Commit shortens a full git commit hash to, which is unique in practice.
+const shortCommitLength = 12
+
+// buildSetting returns the value of a setting Go recorded in the binary at build time, such as vcs.revision, or an
+// empty string when there is none.
+func buildSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+// Version returns the semantic version of the build: the one set with -ldflags, else the module version of a binary
+// installed with go install, else "dev", as for builds of untagged commits, whose commit Commit gives instead.
+func Version() string {
+	if version != "" {
+		return strings.TrimPrefix(version, "v")
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" && !pseudoVersion.MatchString(info.Main.Version) {
+		return strings.TrimPrefix(info.Main.Version, "v")
+	}
+	return "dev"
+}
+
+// Commit returns the git commit of the build, shortened, with a "-dirty" suffix when Go recorded uncommitted
+// changes, or an empty string when it is unknown.
+func Commit() string {
+	if commit != "" {
+		return shortCommit(commit)
+	}
+	revision := buildSetting("vcs.revision")
+	if revision == "" {
+		return ""
+	}
+	if buildSetting("vcs.modified") == "true" {
+		return shortCommit(revision) + "-dirty"
+	}
+	return shortCommit(revision)
+}
+
+// shortCommit shortens a git commit hash to shortCommitLength characters.
+func shortCommit(hash string) string {
+	return hash[:min(len(hash), shortCommitLength)]
+}
+
+// Date returns the build date set with -ldflags, else the time of the commit Go recorded, or an empty string when
+// neither is known.
+func Date() string {
+	if date != "" {
+		return date
+	}
+	return buildSetting("vcs.time")
+}
+
+// String describes the build on one line, such as "1.2.3 (commit 0123456789ab, built 2024-05-01T12:00:00Z)", leaving
+// out what is unknown.
+func String() string {
+	var details []string
+	if commit := Commit(); commit != "" {
+		details = append(details, "commit "+commit)
+	}
+	if date := Date(); date != "" {
+		details = append(details, "built "+date)
+	}
+	if len(details) == 0 {
+		return Version()
+	}
+	return Version() + " (" + strings.Join(details, ", ") + ")"
+}
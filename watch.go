@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JoshuaAtTrimble/SSO-Simplifier/utils"
+)
+
+// sourceStamp is what watch mode compares to tell that a source file changed.
+type sourceStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// sourceSnapshot holds the stamp of every source file under the input path, by path.
+type sourceSnapshot map[string]sourceStamp
+
+// takeSnapshot stamps the .java files and archives under the input path, or the input path itself when it is a file,
+// leaving out the directories and files written to, given as absolute paths, such as the output directory or a
+// --sourcesJar when they lie inside the input path. Polling stamps rather than subscribing to file system events keeps
+// watch mode portable and free of dependencies.
+func takeSnapshot(inputPath string, skipPaths []string) (sourceSnapshot, error) {
+	absInputPath, err := filepath.Abs(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(sourceSnapshot)
+	err = filepath.WalkDir(inputPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Compare absolute paths, since the outputs may be given relative to another directory than the input path
+		if path != inputPath {
+			relPath, err := filepath.Rel(inputPath, path)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(skipPaths, filepath.Join(absInputPath, relPath)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		extension := strings.ToLower(filepath.Ext(path))
+		if utils.IsJavaSourceFile(path, info) || extension == ".zip" || extension == ".jar" {
+			snapshot[path] = sourceStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// changesSince returns the files of the snapshot that are new or changed since the earlier one, and those that were
+// removed, each in order.
+func (s sourceSnapshot) changesSince(earlier sourceSnapshot) (changed []string, removed []string) {
+	for path, stamp := range s {
+		if previous, ok := earlier[path]; !ok || previous != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range earlier {
+		if _, ok := s[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	slices.Sort(changed)
+	slices.Sort(removed)
+	return changed, removed
+}
+
+// watch writes the simplified SSOs, and then rebuilds them whenever source files under the input path change, until
+// interrupted, returning the exit status of the last rebuild. Only the changed files are parsed again, and only the
+// simplified SSOs whose content changes are rewritten. A rebuild after files were deleted prunes their simplified
+// SSOs. A rebuild that fails, such as on colliding SSOs, is reported and watching goes on, so that the next save can
+// fix it.
+func watch(o *options) int {
+	if o.jsonSummary || o.verify || o.verifyChecksums {
+		usageError("Error: --watch cannot be used with --json, --verify, or --verifyChecksums.")
+	}
+	if o.watchInterval <= 0 {
+		usageError("Error: --watchInterval must be positive.")
+	}
+
+	// Finish the rebuild under way on Ctrl+C, and then stop with a summary
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return watchUntil(ctx, o)
+}
+
+// watchUntil is watch, stopping once the context is done rather than on Ctrl+C.
+func watchUntil(ctx context.Context, o *options) int {
+	// Leave out the directories and files written to, so that a rebuild does not trigger another
+	var skipPaths []string
+	outputs := []string{o.outputPath, o.interfacesDir, o.typescriptDir, o.docsDir, o.htmlDir, o.sourcesJar, o.manifestPath,
+		o.graphPath, o.sarifPath, o.diffJSON, o.junitReport, o.csvPath, o.logFile}
+	for _, output := range outputs {
+		if output == "" {
+			continue
+		}
+		absOutput, err := filepath.Abs(output)
+		if err != nil {
+			errorf("Error watching %s: %v\n", o.inputPath, err)
+			return exitScanFailed
+		}
+		skipPaths = append(skipPaths, absOutput)
+	}
+
+	watchStart := time.Now()
+	snapshot, err := takeSnapshot(o.inputPath, skipPaths)
+	if err != nil {
+		errorf("Error watching %s: %v\n", o.inputPath, err)
+		return exitScanFailed
+	}
+	run := *o
+	result := runPipeline(&run, stageWrite)
+	reportRebuildFailure(result)
+	rebuilds, written, failed, removed := 0, result.written, result.failed, result.removed
+	prunePending := false // Whether files were deleted since the last rebuild that succeeded
+	summaryf("Watching %s for changes every %s; press Ctrl+C to stop.\n", o.inputPath, o.watchInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			summaryf("Stopped watching after %s and %d rebuilds: wrote %d simplified SSOs, %d failed, removed %d.\n", time.Since(watchStart).Round(time.Second), rebuilds, written, failed, removed)
+			return result.status
+		case <-time.After(o.watchInterval):
+		}
+		next, err := takeSnapshot(o.inputPath, skipPaths)
+		if err != nil {
+			warnf("Warning: could not check %s for changes: %v\n", o.inputPath, err)
+			continue
+		}
+		changed, deleted := next.changesSince(snapshot)
+		if len(changed) == 0 && len(deleted) == 0 {
+			continue
+		}
+
+		// Wait for a burst of changes, such as a branch switch, to settle before rebuilding once for all of them
+		for settled := false; !settled && ctx.Err() == nil; {
+			time.Sleep(o.watchInterval)
+			settling, err := takeSnapshot(o.inputPath, skipPaths)
+			if err != nil {
+				break
+			}
+			moreChanged, moreDeleted := settling.changesSince(next)
+			settled = len(moreChanged) == 0 && len(moreDeleted) == 0
+			next = settling
+		}
+		changed, deleted = next.changesSince(snapshot)
+		snapshot = next
+		for _, path := range changed {
+			debugf("Changed: %s", path)
+		}
+		for _, path := range deleted {
+			debugf("Deleted: %s", path)
+		}
+		infof("%d source files changed and %d were deleted, rebuilding.\n", len(changed), len(deleted))
+
+		// Rebuild from the options as given, reusing the parse results of unchanged files, or of the last rebuild that
+		// got as far as parsing when one failed
+		parseCache := result.parseCache
+		run = *o
+		run.watchParseCache = parseCache
+		prunePending = prunePending || len(deleted) > 0
+		run.prune = o.prune || prunePending
+		result = runPipeline(&run, stageWrite)
+		prunePending = prunePending && result.status != exitSuccess
+		if result.parseCache == nil {
+			result.parseCache = parseCache
+		}
+		reportRebuildFailure(result)
+		rebuilds++
+		written, failed, removed = written+result.written, failed+result.failed, removed+result.removed
+	}
+}
+
+// reportRebuildFailure says that a run in watch mode failed, with the status a single run would have exited with,
+// and that watching goes on.
+func reportRebuildFailure(result pipelineResult) {
+	if result.status != exitSuccess {
+		errorf("Error: the build failed with exit status %d; still watching for changes.\n", result.status)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that the watch goroutine can log to while the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// writeLeafSSO writes a source file declaring an SSO of the class to the path.
+func writeLeafSSO(t *testing.T, path string, className string) {
+	t.Helper()
+	src := "package com.example;\n\npublic class " + className + " extends ServerSideObject {\n    public int size() { return 0; }\n}\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchSurvivesFailedRebuild(t *testing.T) {
+	var log lockedBuffer
+	previous := logger
+	logger = slog.New(slog.NewTextHandler(&log, nil))
+	t.Cleanup(func() { logger = previous })
+
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "src", "com", "example")
+	if err := os.MkdirAll(sourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeLeafSSO(t, filepath.Join(sourceDir, "Leaf.java"), "Leaf")
+
+	o := newOptions()
+	o.inputPath = filepath.Join(dir, "src")
+	o.outputPath = filepath.Join(dir, "out")
+	o.reproducible = true
+	o.watchInterval = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan int, 1)
+	go func() { done <- watchUntil(ctx, o) }()
+
+	// waitFor polls until the condition holds, failing the test if watching stops or it takes too long
+	waitFor := func(what string, condition func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); !condition(); {
+			select {
+			case status := <-done:
+				t.Fatalf("watching stopped with status %d while waiting for %s:\n%s", status, what, log.String())
+			case <-time.After(10 * time.Millisecond):
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s:\n%s", what, log.String())
+			}
+		}
+	}
+	exists := func(path string) func() bool {
+		return func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}
+	}
+	waitFor("the first build", exists(filepath.Join(o.outputPath, "com", "example", "Leaf.java")))
+
+	// A copy of Leaf.java collides with it, which fails the rebuild
+	writeLeafSSO(t, filepath.Join(sourceDir, "Leaf2.java"), "Leaf")
+	waitFor("the failed rebuild", func() bool { return strings.Contains(log.String(), "still watching for changes") })
+
+	// Watching goes on, so that removing the copy and adding another SSO rebuilds again
+	if err := os.Remove(filepath.Join(sourceDir, "Leaf2.java")); err != nil {
+		t.Fatal(err)
+	}
+	writeLeafSSO(t, filepath.Join(sourceDir, "Branch.java"), "Branch")
+	waitFor("the rebuild after the fix", exists(filepath.Join(o.outputPath, "com", "example", "Branch.java")))
+
+	cancel()
+	if status := <-done; status != exitSuccess {
+		t.Errorf("watch returned status %d, want %d, since the last rebuild succeeded", status, exitSuccess)
+	}
+}